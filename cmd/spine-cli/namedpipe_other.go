@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"net"
+)
+
+// connectNamedPipe 在非 Windows 平台上返回错误
+func connectNamedPipe(pipeName string) (net.Conn, error) {
+	return nil, fmt.Errorf("Named Pipe is only supported on Windows")
+}
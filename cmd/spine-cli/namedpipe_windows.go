@@ -0,0 +1,224 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// connectNamedPipe 连接到 Windows Named Pipe
+func connectNamedPipe(pipeName string) (net.Conn, error) {
+	// 转换管道名称为 UTF16
+	pipeName16, err := syscall.UTF16PtrFromString(pipeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert pipe name to UTF16: %v", err)
+	}
+
+	// 尝试连接，如果管道不存在则等待
+	var handle windows.Handle
+	for i := 0; i < 50; i++ { // 最多重试 50 次，每次等待 100ms
+		// 尝试打开 named pipe，使用重叠I/O以支持超时
+		handle, err = windows.CreateFile(
+			pipeName16,
+			windows.GENERIC_READ|windows.GENERIC_WRITE,
+			0,
+			nil,
+			windows.OPEN_EXISTING,
+			windows.FILE_FLAG_OVERLAPPED, // 使用重叠I/O以支持超时
+			0,
+		)
+		if err == nil {
+			break // 连接成功
+		}
+
+		// 如果是文件不存在错误，等待后重试
+		if err == windows.ERROR_FILE_NOT_FOUND {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		// 其他错误直接返回
+		return nil, fmt.Errorf("failed to open named pipe: %v", err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to named pipe after retries: %v", err)
+	}
+
+	return &NamedPipeConn{handle: handle}, nil
+}
+
+// NamedPipeConn Windows Named Pipe 连接包装器
+// 读写使用重叠 I/O，等待时间由 SetDeadline 系列方法设置的截止时间决定
+type NamedPipeConn struct {
+	handle windows.Handle
+
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+// waitMillis 根据截止时间计算 WaitForSingleObject 的等待毫秒数
+// 没有截止时间时无限等待，已过截止时间返回 os.ErrDeadlineExceeded
+func waitMillis(deadline time.Time) (uint32, error) {
+	if deadline.IsZero() {
+		return windows.INFINITE, nil
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0, os.ErrDeadlineExceeded
+	}
+	// 向上取整，避免剩余不足 1ms 时变成立即超时
+	millis := (remaining + time.Millisecond - 1) / time.Millisecond
+	if millis >= windows.INFINITE {
+		millis = windows.INFINITE - 1
+	}
+	return uint32(millis), nil
+}
+
+// overlappedIO 执行一次重叠 I/O 并按截止时间等待完成
+// 超时后取消 I/O 并返回 os.ErrDeadlineExceeded，它满足 net.Error 且 Timeout() 为 true
+func (c *NamedPipeConn) overlappedIO(deadline time.Time, start func(*windows.Overlapped, *uint32) error) (uint32, error) {
+	timeout, err := waitMillis(deadline)
+	if err != nil {
+		return 0, err
+	}
+
+	overlapped := &windows.Overlapped{}
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create event: %v", err)
+	}
+	defer windows.CloseHandle(event)
+	overlapped.HEvent = event
+
+	var transferred uint32
+	err = start(overlapped, &transferred)
+	if err == nil {
+		return transferred, nil
+	}
+	if err != windows.ERROR_IO_PENDING {
+		return 0, err
+	}
+
+	waitResult, waitErr := windows.WaitForSingleObject(event, timeout)
+	if waitErr != nil {
+		return 0, fmt.Errorf("wait failed: %v", waitErr)
+	}
+	if waitResult == uint32(windows.WAIT_TIMEOUT) {
+		// 取消后必须等待 I/O 真正结束，避免系统在返回后继续写入缓冲区
+		windows.CancelIoEx(c.handle, overlapped)
+		windows.GetOverlappedResult(c.handle, overlapped, &transferred, true)
+		return 0, os.ErrDeadlineExceeded
+	}
+
+	if err := windows.GetOverlappedResult(c.handle, overlapped, &transferred, false); err != nil {
+		return 0, err
+	}
+	return transferred, nil
+}
+
+func (c *NamedPipeConn) Read(b []byte) (n int, err error) {
+	c.mu.Lock()
+	deadline := c.readDeadline
+	c.mu.Unlock()
+
+	bytesRead, err := c.overlappedIO(deadline, func(o *windows.Overlapped, done *uint32) error {
+		return windows.ReadFile(c.handle, b, done, o)
+	})
+	if err != nil {
+		// 检查是否是管道断开
+		if err == windows.ERROR_BROKEN_PIPE || err == windows.ERROR_PIPE_NOT_CONNECTED {
+			return 0, io.EOF
+		}
+		if err == os.ErrDeadlineExceeded {
+			return 0, err
+		}
+		return 0, fmt.Errorf("ReadFile failed: %v", err)
+	}
+
+	// 如果读取了0字节但没有错误，可能是管道关闭
+	if bytesRead == 0 {
+		return 0, io.EOF
+	}
+	return int(bytesRead), nil
+}
+
+func (c *NamedPipeConn) Write(b []byte) (n int, err error) {
+	c.mu.Lock()
+	deadline := c.writeDeadline
+	c.mu.Unlock()
+
+	bytesWritten, err := c.overlappedIO(deadline, func(o *windows.Overlapped, done *uint32) error {
+		return windows.WriteFile(c.handle, b, done, o)
+	})
+	if err != nil {
+		if err == os.ErrDeadlineExceeded {
+			return 0, err
+		}
+		return 0, fmt.Errorf("failed to write to named pipe: %v", err)
+	}
+
+	if int(bytesWritten) != len(b) {
+		return int(bytesWritten), fmt.Errorf("incomplete write: wrote %d bytes, expected %d", bytesWritten, len(b))
+	}
+	return int(bytesWritten), nil
+}
+
+func (c *NamedPipeConn) Close() error {
+	return windows.CloseHandle(c.handle)
+}
+
+func (c *NamedPipeConn) LocalAddr() net.Addr {
+	return &NamedPipeAddr{pipeName: "local"}
+}
+
+func (c *NamedPipeConn) RemoteAddr() net.Addr {
+	return &NamedPipeAddr{pipeName: "remote"}
+}
+
+// SetDeadline 同时设置读写截止时间，零值表示不超时
+func (c *NamedPipeConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	c.writeDeadline = t
+	return nil
+}
+
+// SetReadDeadline 设置读取截止时间，只影响之后开始的 Read
+func (c *NamedPipeConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	return nil
+}
+
+// SetWriteDeadline 设置写入截止时间，只影响之后开始的 Write
+func (c *NamedPipeConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeDeadline = t
+	return nil
+}
+
+// NamedPipeAddr Named Pipe 地址实现
+type NamedPipeAddr struct {
+	pipeName string
+}
+
+func (a *NamedPipeAddr) Network() string {
+	return "namedpipe"
+}
+
+func (a *NamedPipeAddr) String() string {
+	return a.pipeName
+}
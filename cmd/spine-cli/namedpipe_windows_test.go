@@ -0,0 +1,61 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func TestNamedPipeReadDeadline(t *testing.T) {
+	pipeName := fmt.Sprintf(`\\.\pipe\spine-cli-deadline-%d`, os.Getpid())
+	pipeName16, err := syscall.UTF16PtrFromString(pipeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 服务端只建立管道，从不写入数据
+	server, err := windows.CreateNamedPipe(
+		pipeName16,
+		windows.PIPE_ACCESS_DUPLEX,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT,
+		1, 4096, 4096, 0, nil,
+	)
+	if err != nil {
+		t.Fatalf("CreateNamedPipe failed: %v", err)
+	}
+	defer windows.CloseHandle(server)
+
+	conn, err := connectNamedPipe(pipeName)
+	if err != nil {
+		t.Fatalf("connectNamedPipe failed: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+
+	start := time.Now()
+	_, err = conn.Read(make([]byte, 16))
+	elapsed := time.Since(start)
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed > 5*time.Second {
+		t.Fatalf("read returned after %v, deadline was not honoured", elapsed)
+	}
+
+	// 已过期的截止时间立即返回
+	_, err = conn.Read(make([]byte, 16))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"spine-go/libspine/common/resp"
+)
+
+// pipeResult --pipe 模式的统计结果
+type pipeResult struct {
+	replies int // 收到的回复数，不含结束标记
+	errors  int // 其中错误回复的数量
+}
+
+// runPipe 实现 redis-cli --pipe：把 in 中已编码为 RESP 的命令原样发给服务器，不等待逐条回复，
+// 最后发送带随机标记的 ECHO，收到标记即表示之前的命令都已处理完毕。错误回复写到 out
+func runPipe(conn net.Conn, in io.Reader, out io.Writer) (pipeResult, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return pipeResult{}, err
+	}
+	marker := hex.EncodeToString(buf)
+
+	// 写入和读取并行进行，避免服务器因回复积压而停止读取命令
+	sent := make(chan error, 1)
+	go func() {
+		if _, err := io.Copy(conn, in); err != nil {
+			sent <- err
+			return
+		}
+		echo, err := resp.SerializeCommand("ECHO", marker)
+		if err != nil {
+			sent <- err
+			return
+		}
+		_, err = conn.Write(echo)
+		sent <- err
+	}()
+
+	var result pipeResult
+	parser := resp.NewParser(bufio.NewReader(conn))
+	for {
+		reply, err := parser.Parse()
+		if err != nil {
+			select {
+			case sendErr := <-sent:
+				if sendErr != nil {
+					return result, sendErr
+				}
+			default:
+			}
+			return result, err
+		}
+		if reply.Type == resp.DataType(resp.TypeBulkString) && string(reply.Bulk) == marker {
+			break
+		}
+		result.replies++
+		if reply.Type == resp.DataType(resp.TypeError) {
+			result.errors++
+			fmt.Fprintln(out, reply.String)
+		}
+	}
+	return result, <-sent
+}
+
+// runPipeClient 连接服务器并以 --pipe 模式从标准输入批量导入数据，有错误回复时以非零状态退出
+func runPipeClient(protocol, serverAddr, localPath string) {
+	conn, err := dialServer(protocol, serverAddr, localPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	result, err := runPipe(conn, os.Stdin, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Pipe failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("All data transferred. Last reply received from server.")
+	fmt.Printf("errors: %d, replies: %d\n", result.errors, result.replies)
+	if result.errors > 0 {
+		os.Exit(1)
+	}
+}
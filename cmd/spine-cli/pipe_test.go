@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spine-go/libspine/client"
+	"spine-go/libspine/common/resp"
+)
+
+func TestPipeLoadsAllCommands(t *testing.T) {
+	address := serveRedisHandler(t)
+
+	path := filepath.Join(t.TempDir(), "data.resp")
+	var data strings.Builder
+	for i := 0; i < 1000; i++ {
+		command, err := resp.SerializeCommand("SET", fmt.Sprintf("key:%d", i), fmt.Sprintf("value %d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data.Write(command)
+	}
+	if err := os.WriteFile(path, []byte(data.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var out strings.Builder
+	result, err := runPipe(conn, file, &out)
+	if err != nil {
+		t.Fatalf("pipe failed: %v", err)
+	}
+	if result != (pipeResult{replies: 1000}) || out.Len() != 0 {
+		t.Fatalf("unexpected result %+v, output %q", result, out.String())
+	}
+
+	c, err := client.Dial(context.Background(), "tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	size, err := c.Do(context.Background(), "DBSIZE")
+	if err != nil || size.Int != 1000 {
+		t.Fatalf("DBSIZE = %d, %v", size.Int, err)
+	}
+	for _, i := range []int{0, 499, 999} {
+		value, err := c.Do(context.Background(), "GET", fmt.Sprintf("key:%d", i))
+		if err != nil || string(value.Bulk) != fmt.Sprintf("value %d", i) {
+			t.Fatalf("GET key:%d = %q, %v", i, value.Bulk, err)
+		}
+	}
+}
+
+func TestPipeCountsErrors(t *testing.T) {
+	conn, err := net.Dial("tcp", serveRedisHandler(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	var input strings.Builder
+	for _, command := range [][]string{{"SET", "a", "1"}, {"NOSUCHCOMMAND"}, {"GET", "a"}, {"GET"}} {
+		data, err := resp.SerializeCommand(command[0], command[1:]...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input.Write(data)
+	}
+
+	var out strings.Builder
+	result, err := runPipe(conn, strings.NewReader(input.String()), &out)
+	if err != nil {
+		t.Fatalf("pipe failed: %v", err)
+	}
+	if result != (pipeResult{replies: 4, errors: 2}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], "ERR unknown command") {
+		t.Fatalf("unexpected error output %q", out.String())
+	}
+}
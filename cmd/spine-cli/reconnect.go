@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 10 * time.Second
+
+	defaultHeartbeatInterval = 30 * time.Second
+	defaultPongTimeout       = 10 * time.Second
+)
+
+// errPongTimeout 发送心跳后在超时时间内没有收到服务器的任何消息
+var errPongTimeout = errors.New("heartbeat timed out waiting for pong")
+
+// reconnectingConn 在连接断开时按指数退避自动重连的连接包装
+// 读取循环发现连接断开后负责重连，写入总是使用当前连接
+type reconnectingConn struct {
+	dial        func() (net.Conn, error)
+	onReconnect func(conn net.Conn) // 重连成功后调用，例如重新加入聊天
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+
+	// sendPing 不为空时每隔 heartbeatInterval 发送一次心跳，
+	// 超过 pongTimeout 没有收到任何消息则断开连接并重连
+	sendPing          func(w io.Writer)
+	heartbeatInterval time.Duration
+	pongTimeout       time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+	done   chan struct{}
+}
+
+// newReconnectingConn 创建自动重连连接，dial 用于建立（和重新建立）底层连接
+func newReconnectingConn(dial func() (net.Conn, error)) *reconnectingConn {
+	return &reconnectingConn{
+		dial:              dial,
+		minBackoff:        defaultMinBackoff,
+		maxBackoff:        defaultMaxBackoff,
+		heartbeatInterval: defaultHeartbeatInterval,
+		pongTimeout:       defaultPongTimeout,
+		done:              make(chan struct{}),
+	}
+}
+
+// Connect 建立首次连接，失败时直接返回错误而不重试
+func (c *reconnectingConn) Connect() error {
+	conn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	return nil
+}
+
+// Write 写入当前连接，断线期间返回错误
+func (c *reconnectingConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return 0, fmt.Errorf("not connected")
+	}
+	return conn.Write(b)
+}
+
+// ReadLines 按行读取服务器消息并交给 handle 处理，连接断开后自动重连
+// 只有在 Close 之后才会返回
+func (c *reconnectingConn) ReadLines(handle func(line string), onDisconnect func(err error)) {
+	for {
+		c.mu.Lock()
+		conn := c.conn
+		c.mu.Unlock()
+		if conn == nil {
+			return
+		}
+
+		seen := make(chan struct{}, 1)
+		stop := make(chan struct{})
+		timedOut := make(chan struct{})
+		if c.sendPing != nil && c.heartbeatInterval > 0 {
+			go c.heartbeat(conn, seen, stop, timedOut)
+		}
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			select {
+			case seen <- struct{}{}:
+			default:
+			}
+			handle(scanner.Text())
+		}
+		close(stop)
+		conn.Close()
+
+		if c.isClosed() {
+			return
+		}
+		if onDisconnect != nil {
+			err := scanner.Err()
+			select {
+			case <-timedOut:
+				err = errPongTimeout
+			default:
+			}
+			onDisconnect(err)
+		}
+		if !c.reconnect() {
+			return
+		}
+	}
+}
+
+// heartbeat 定期发送心跳，收到任何一行消息都视为服务器仍然存活
+// 超时未收到回应时关闭连接，让读取循环进入重连流程
+func (c *reconnectingConn) heartbeat(conn net.Conn, seen <-chan struct{}, stop <-chan struct{}, timedOut chan<- struct{}) {
+	ticker := time.NewTicker(c.heartbeatInterval)
+	defer ticker.Stop()
+
+	var waiting <-chan time.Time // 等待回应的超时，为 nil 表示没有未回应的心跳
+	for {
+		select {
+		case <-stop:
+			return
+		case <-seen:
+			waiting = nil
+		case <-ticker.C:
+			if waiting == nil {
+				c.sendPing(conn)
+				waiting = time.After(c.pongTimeout)
+			}
+		case <-waiting:
+			close(timedOut)
+			conn.Close()
+			return
+		}
+	}
+}
+
+// reconnect 按指数退避重试直到连接成功，Close 后返回 false
+func (c *reconnectingConn) reconnect() bool {
+	backoff := c.minBackoff
+	for {
+		select {
+		case <-c.done:
+			return false
+		case <-time.After(backoff):
+		}
+
+		conn, err := c.dial()
+		if err == nil {
+			c.mu.Lock()
+			if c.closed {
+				c.mu.Unlock()
+				conn.Close()
+				return false
+			}
+			c.conn = conn
+			c.mu.Unlock()
+
+			if c.onReconnect != nil {
+				c.onReconnect(conn)
+			}
+			return true
+		}
+
+		backoff *= 2
+		if backoff > c.maxBackoff {
+			backoff = c.maxBackoff
+		}
+	}
+}
+
+func (c *reconnectingConn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// Close 关闭当前连接并停止重连
+func (c *reconnectingConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
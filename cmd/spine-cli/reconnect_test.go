@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// acceptLine 接受一个连接并读取第一行
+func acceptLine(t *testing.T, listener net.Listener) (net.Conn, string) {
+	t.Helper()
+	listener.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	return conn, strings.TrimSpace(line)
+}
+
+func TestReconnectingConnRejoinsAfterServerRestart(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+
+	conn := newReconnectingConn(func() (net.Conn, error) {
+		return net.Dial("tcp", address)
+	})
+	conn.minBackoff = 10 * time.Millisecond
+	conn.maxBackoff = 50 * time.Millisecond
+	conn.onReconnect = func(c net.Conn) {
+		sendChatRequest(c, "JOIN", "/chat", nil)
+	}
+	if err := conn.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	received := make(chan string, 10)
+	disconnected := make(chan struct{}, 10)
+	go conn.ReadLines(func(line string) {
+		received <- line
+	}, func(error) {
+		disconnected <- struct{}{}
+	})
+
+	sendChatRequest(conn, "JOIN", "/chat", nil)
+	serverConn, line := acceptLine(t, listener)
+	if !strings.Contains(line, `"method":"JOIN"`) {
+		t.Fatalf("unexpected first request: %s", line)
+	}
+
+	// 服务器在会话中途停止
+	serverConn.Close()
+	listener.Close()
+
+	select {
+	case <-disconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not notice the disconnect")
+	}
+
+	// 服务器在同一地址恢复后，客户端应自动重连并重新加入聊天
+	listener, err = net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("failed to restart listener: %v", err)
+	}
+	defer listener.Close()
+
+	serverConn, line = acceptLine(t, listener)
+	defer serverConn.Close()
+	if !strings.Contains(line, `"method":"JOIN"`) {
+		t.Fatalf("expected rejoin after reconnect, got: %s", line)
+	}
+
+	// 重连后的连接可以继续收发消息
+	if _, err := serverConn.Write([]byte("welcome back\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-received:
+		if got != "welcome back" {
+			t.Fatalf("unexpected message: %s", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received after reconnect")
+	}
+
+	sendChatRequest(conn, "GET", "/chat", nil)
+	serverConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	next, err := bufio.NewReader(serverConn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(next, `"method":"GET"`) {
+		t.Fatalf("write after reconnect went to the wrong place: %s", next)
+	}
+}
+
+// newHeartbeatConn 创建一个心跳间隔很短的聊天连接
+func newHeartbeatConn(t *testing.T, address string) (*reconnectingConn, chan error) {
+	t.Helper()
+	conn := newReconnectingConn(func() (net.Conn, error) {
+		return net.Dial("tcp", address)
+	})
+	conn.minBackoff = time.Hour // 测试只关心第一次断开
+	conn.heartbeatInterval = 20 * time.Millisecond
+	conn.pongTimeout = 100 * time.Millisecond
+	conn.sendPing = func(w io.Writer) {
+		sendChatRequest(w, "PING", "/chat", nil)
+	}
+	if err := conn.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	disconnected := make(chan error, 1)
+	go conn.ReadLines(func(string) {}, func(err error) {
+		disconnected <- err
+	})
+	return conn, disconnected
+}
+
+func TestHeartbeatDetectsStalledServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	_, disconnected := newHeartbeatConn(t, listener.Addr().String())
+
+	// 服务器收到心跳但从不回应
+	serverConn, line := acceptLine(t, listener)
+	defer serverConn.Close()
+	if !strings.Contains(line, `"method":"PING"`) {
+		t.Fatalf("expected a PING request, got: %s", line)
+	}
+
+	select {
+	case err := <-disconnected:
+		if err != errPongTimeout {
+			t.Fatalf("expected errPongTimeout, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not detect the missing pong")
+	}
+}
+
+func TestHeartbeatKeepsResponsiveConnection(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	_, disconnected := newHeartbeatConn(t, listener.Addr().String())
+
+	// 服务器对每个心跳都回应 pong
+	listener.(*net.TCPListener).SetDeadline(time.Now().Add(5 * time.Second))
+	serverConn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverConn.Close()
+	go func() {
+		scanner := bufio.NewScanner(serverConn)
+		for scanner.Scan() {
+			serverConn.Write([]byte(`{"status":200,"data":{"message":"pong"}}` + "\n"))
+		}
+	}()
+
+	select {
+	case err := <-disconnected:
+		t.Fatalf("responsive connection was dropped: %v", err)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
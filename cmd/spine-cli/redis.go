@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"spine-go/libspine/client"
+	"spine-go/libspine/common/resp"
+)
+
+// maxHistoryEntries 命令历史最多保留的条数
+const maxHistoryEntries = 1000
+
+// redisHistory REPL 的命令历史，path 不为空时每条命令追加到该文件，下次启动时加载
+type redisHistory struct {
+	entries []string
+	path    string
+}
+
+// defaultHistoryPath 返回默认的历史文件路径 ~/.spinecli_history，取不到主目录时不保存历史
+func defaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".spinecli_history")
+}
+
+// loadHistory 从 path 加载命令历史，文件不存在时返回空历史
+func loadHistory(path string) *redisHistory {
+	history := &redisHistory{path: path}
+	if path == "" {
+		return history
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return history
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			history.entries = append(history.entries, line)
+		}
+	}
+	if len(history.entries) > maxHistoryEntries {
+		history.entries = history.entries[len(history.entries)-maxHistoryEntries:]
+	}
+	return history
+}
+
+// add 记录一条命令，与上一条相同或带有密码时不记录
+func (h *redisHistory) add(line string) {
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return
+	}
+	if args, err := resp.SplitArgs(line); err == nil && sensitiveCommand(args) {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > maxHistoryEntries {
+		h.entries = h.entries[1:]
+	}
+	if h.path == "" {
+		return
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
+
+// sensitiveCommand 判断命令是否带有密码，与 redis-cli 一致，这些命令不写入历史：
+// AUTH、带 AUTH 的 HELLO 和 MIGRATE、ACL SETUSER 以及设置密码的 CONFIG SET
+func sensitiveCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		return true
+	case "HELLO", "MIGRATE":
+		for _, arg := range args[1:] {
+			if strings.EqualFold(arg, "AUTH") || strings.EqualFold(arg, "AUTH2") {
+				return true
+			}
+		}
+	case "ACL":
+		return len(args) > 1 && strings.EqualFold(args[1], "SETUSER")
+	case "CONFIG":
+		if len(args) < 2 || !strings.EqualFold(args[1], "SET") {
+			return false
+		}
+		for i := 2; i < len(args); i += 2 {
+			switch strings.ToLower(args[i]) {
+			case "requirepass", "masterauth", "masteruser":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expand 展开 !! 和 !n 形式的历史引用，n 从 1 开始，与 /history 的编号一致
+func (h *redisHistory) expand(line string) (string, error) {
+	if !strings.HasPrefix(line, "!") {
+		return line, nil
+	}
+	if line == "!!" {
+		if len(h.entries) == 0 {
+			return "", errors.New("no previous command")
+		}
+		return h.entries[len(h.entries)-1], nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(h.entries) {
+		return "", fmt.Errorf("%s: event not found", line)
+	}
+	return h.entries[n-1], nil
+}
+
+// runRedisREPL 从 in 逐行读取命令，以 RESP 数组发给服务器，并把回复按 format 写到 out：
+// "text" 为 redis-cli 的格式，"json" 为每行一个 JSON 值且不输出提示符。
+// 参数按 resp.SplitArgs 的规则拆分，带空格、引号或二进制内容的值需要加引号
+func runRedisREPL(c *client.Client, history *redisHistory, format string, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		if format != "json" {
+			fmt.Fprint(out, "redis> ")
+		}
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" {
+			continue
+		}
+		if input == "/quit" {
+			return nil
+		}
+		if input == "/history" {
+			for i, entry := range history.entries {
+				fmt.Fprintf(out, "%5d  %s\n", i+1, entry)
+			}
+			continue
+		}
+		input, err := history.expand(input)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			continue
+		}
+		history.add(input)
+
+		args, err := resp.SplitArgs(input)
+		if err != nil {
+			fmt.Fprintln(out, "Invalid argument(s)")
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if strings.EqualFold(args[0], "HELP") {
+			help, err := commandHelp(c, args[1:])
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(out, help)
+			continue
+		}
+		args = expandSetTTL(args)
+
+		reply, err := c.Do(context.Background(), args...)
+		var cmdErr *resp.CommandError
+		if errors.As(err, &cmdErr) {
+			reply, err = resp.Value{Type: resp.DataType(resp.TypeError), String: cmdErr.Error()}, nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := printReply(out, format, reply); err != nil {
+			return err
+		}
+	}
+}
+
+// printReply 按 format 输出一条回复
+func printReply(out io.Writer, format string, reply resp.Value) error {
+	if format == "json" {
+		data, err := client.ReplyJSON(reply)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", data)
+		return err
+	}
+	_, err := fmt.Fprintln(out, formatReply(reply, ""))
+	return err
+}
+
+// commandHelp 返回 HELP 的输出：根据服务器的 COMMAND DOCS 和 COMMAND INFO 生成命令语法和简介
+func commandHelp(c *client.Client, args []string) (string, error) {
+	if len(args) == 0 {
+		return "Type HELP <command> for the syntax of a command, e.g. HELP SET\n" +
+			"Type /history to list previous commands, !n or !! to run one again\n" +
+			"Type /quit to quit\n", nil
+	}
+
+	name := strings.Join(args, " ")
+	ctx := context.Background()
+	info, err := c.Do(ctx, "COMMAND", "INFO", args[0])
+	if err != nil {
+		return "", err
+	}
+	if len(info.Array) != 1 || len(info.Array[0].Array) < 2 {
+		return fmt.Sprintf("No help for '%s'\n", name), nil
+	}
+	arity := info.Array[0].Array[1].Int
+
+	docs, err := c.Do(ctx, "COMMAND", "DOCS", args[0])
+	if err != nil {
+		return "", err
+	}
+	var doc map[string]resp.Value
+	for _, value := range replyMap(docs) {
+		doc = replyMap(value)
+	}
+
+	syntax := strings.ToUpper(args[0])
+	if arguments, exists := doc["arguments"]; exists {
+		syntax += " " + argumentsSyntax(arguments.Array)
+	} else if arity < -1 {
+		syntax += fmt.Sprintf(" (at least %d arguments)", -arity-1)
+	} else if arity > 1 {
+		syntax += fmt.Sprintf(" (%d arguments)", arity-1)
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "\n  %s\n", syntax)
+	if summary := doc["summary"]; len(summary.Bulk) > 0 {
+		fmt.Fprintf(&builder, "  summary: %s\n", summary.Bulk)
+	}
+	if group := doc["group"]; len(group.Bulk) > 0 {
+		fmt.Fprintf(&builder, "  group: %s\n", group.Bulk)
+	}
+	builder.WriteString("\n")
+	return builder.String(), nil
+}
+
+// replyMap 把 RESP3 的 map 或 RESP2 中键值交替的数组转换为 map
+func replyMap(value resp.Value) map[string]resp.Value {
+	m := make(map[string]resp.Value)
+	if value.Type == resp.DataType(resp.TypeMap) {
+		for _, item := range value.Map {
+			m[replyText(item.Key)] = item.Value
+		}
+		return m
+	}
+	for i := 0; i+1 < len(value.Array); i += 2 {
+		m[replyText(value.Array[i])] = value.Array[i+1]
+	}
+	return m
+}
+
+// replyText 返回字符串类回复的内容
+func replyText(value resp.Value) string {
+	if value.Type == resp.DataType(resp.TypeBulkString) {
+		return string(value.Bulk)
+	}
+	return value.String
+}
+
+// argumentsSyntax 按 redis-cli 的格式把 COMMAND DOCS 的参数说明拼接为语法字符串，例如
+// key value [NX|XX] [EX seconds|PX milliseconds]
+func argumentsSyntax(arguments []resp.Value) string {
+	parts := make([]string, 0, len(arguments))
+	for _, argument := range arguments {
+		parts = append(parts, argumentSyntax(replyMap(argument)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// argumentSyntax 返回单个参数的语法
+func argumentSyntax(arg map[string]resp.Value) string {
+	var syntax string
+	switch replyText(arg["type"]) {
+	case "pure-token":
+		syntax = replyText(arg["token"])
+	case "oneof":
+		var choices []string
+		for _, choice := range arg["arguments"].Array {
+			choices = append(choices, argumentSyntax(replyMap(choice)))
+		}
+		syntax = strings.Join(choices, "|")
+	case "block":
+		syntax = argumentsSyntax(arg["arguments"].Array)
+	default:
+		syntax = replyText(arg["name"])
+		if token := replyText(arg["token"]); token != "" {
+			syntax = token + " " + syntax
+		}
+	}
+
+	for _, flag := range arg["flags"].Array {
+		if replyText(flag) == "multiple" {
+			syntax = fmt.Sprintf("%s [%s ...]", syntax, syntax)
+		}
+	}
+	for _, flag := range arg["flags"].Array {
+		if replyText(flag) == "optional" {
+			syntax = "[" + syntax + "]"
+		}
+	}
+	return syntax
+}
+
+// expandSetTTL 把简写的 SET key value ttl 转换为 SET key value EX ttl，
+// 其他命令和不是正整数的第四个参数原样发送，由服务器报告语法错误
+func expandSetTTL(args []string) []string {
+	if len(args) != 4 || !strings.EqualFold(args[0], "SET") {
+		return args
+	}
+	if ttl, err := strconv.ParseInt(args[3], 10, 64); err != nil || ttl <= 0 {
+		return args
+	}
+	return []string{args[0], args[1], args[2], "EX", args[3]}
+}
+
+// formatReply 按 redis-cli 的格式输出回复，indent 是嵌套数组的缩进
+func formatReply(reply resp.Value, indent string) string {
+	switch reply.Type {
+	case resp.DataType(resp.TypeSimpleString):
+		return reply.String
+	case resp.DataType(resp.TypeError):
+		return "(error) " + reply.String
+	case resp.DataType(resp.TypeInteger):
+		return fmt.Sprintf("(integer) %d", reply.Int)
+	case resp.DataType(resp.TypeNull):
+		return "(nil)"
+	case resp.DataType(resp.TypeBoolean):
+		if reply.Bool {
+			return "(true)"
+		}
+		return "(false)"
+	case resp.DataType(resp.TypeDouble):
+		return fmt.Sprintf("(double) %s", strconv.FormatFloat(reply.Double, 'g', -1, 64))
+	case resp.DataType(resp.TypeBulkString), resp.DataType(resp.TypeVerbatimString):
+		if reply.IsNull {
+			return "(nil)"
+		}
+		if reply.Type == resp.DataType(resp.TypeVerbatimString) {
+			return reply.String
+		}
+		return quoteString(string(reply.Bulk))
+	case resp.DataType(resp.TypeArray), resp.DataType(resp.TypeSet), resp.DataType(resp.TypePush):
+		if reply.IsNull {
+			return "(nil)"
+		}
+		if len(reply.Array) == 0 {
+			return "(empty array)"
+		}
+		return formatItems(len(reply.Array), indent, func(i int, nested string) string {
+			return formatReply(reply.Array[i], nested)
+		})
+	case resp.DataType(resp.TypeMap), resp.DataType(resp.TypeAttribute):
+		if len(reply.Map) == 0 {
+			return "(empty hash)"
+		}
+		return formatItems(len(reply.Map), indent, func(i int, nested string) string {
+			return formatReply(reply.Map[i].Key, nested) + " => " + formatReply(reply.Map[i].Value, nested)
+		})
+	}
+	return fmt.Sprintf("(unknown reply type %c)", byte(reply.Type))
+}
+
+// formatItems 输出带序号的多行列表，第一行不缩进，之后的行按序号宽度对齐
+func formatItems(n int, indent string, item func(i int, nested string) string) string {
+	width := len(strconv.Itoa(n))
+	nested := indent + strings.Repeat(" ", width+2)
+	var builder strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			builder.WriteString("\n" + indent)
+		}
+		fmt.Fprintf(&builder, "%*d) %s", width, i+1, item(i, nested))
+	}
+	return builder.String()
+}
+
+// quoteString 按 redis-cli 的规则给字符串加双引号并转义不可打印字符
+func quoteString(s string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '"':
+			builder.WriteByte('\\')
+			builder.WriteByte(c)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\a':
+			builder.WriteString(`\a`)
+		case '\b':
+			builder.WriteString(`\b`)
+		default:
+			if c >= ' ' && c <= '~' {
+				builder.WriteByte(c)
+			} else {
+				fmt.Fprintf(&builder, `\x%02x`, c)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
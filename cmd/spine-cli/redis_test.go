@@ -0,0 +1,287 @@
+package main
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"spine-go/libspine/client"
+	"spine-go/libspine/handler"
+	"spine-go/libspine/transport"
+)
+
+// serveRedisHandler 在随机端口上启动 redis 模式的处理器，返回监听地址
+func serveRedisHandler(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	redisHandler := handler.NewRedisHandler()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			ctx := &transport.Context{
+				ConnInfo: &transport.ConnInfo{
+					Remote: conn.RemoteAddr(),
+					Reader: conn,
+					Writer: conn,
+				},
+			}
+			go func() {
+				defer conn.Close()
+				redisHandler.Handle(ctx, conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// startRedisServer 启动 redis 模式的处理器，返回连接到它的客户端
+func startRedisServer(t *testing.T) *client.Client {
+	t.Helper()
+	conn, err := net.Dial("tcp", serveRedisHandler(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := client.NewClient(conn)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// runREPL 把 lines 作为输入运行 REPL，返回去掉提示符后的输出行
+func runREPL(t *testing.T, c *client.Client, lines ...string) []string {
+	t.Helper()
+	var out strings.Builder
+	if err := runRedisREPL(c, &redisHistory{}, "text", strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("REPL failed: %v", err)
+	}
+	var replies []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if line = strings.TrimPrefix(line, "redis> "); line != "" {
+			replies = append(replies, line)
+		}
+	}
+	return replies
+}
+
+func TestRedisREPLRoundTripsQuotedValues(t *testing.T) {
+	c := startRedisServer(t)
+
+	replies := runREPL(t, c,
+		`SET greeting "hello \"quoted\" world"`,
+		`GET greeting`,
+		`SET 'key with spaces' 'it\'s "single" quoted'`,
+		`GET "key with spaces"`,
+		`SET binary "\x00\xff\r\n"`,
+		`STRLEN binary`,
+		`GET binary`,
+	)
+	expected := []string{
+		"OK",
+		`"hello \"quoted\" world"`,
+		"OK",
+		`"it's \"single\" quoted"`,
+		"OK",
+		"(integer) 4",
+		`"\x00\xff\r\n"`,
+	}
+	if !reflect.DeepEqual(replies, expected) {
+		t.Fatalf("unexpected replies:\n got: %q\nwant: %q", replies, expected)
+	}
+}
+
+func TestRedisREPLErrors(t *testing.T) {
+	c := startRedisServer(t)
+
+	replies := runREPL(t, c,
+		`GET "unterminated`,
+		`GET missing`,
+		`NOSUCHCOMMAND`,
+		`/quit`,
+		`GET never-sent`,
+	)
+	if len(replies) != 3 {
+		t.Fatalf("unexpected replies: %q", replies)
+	}
+	if replies[0] != "Invalid argument(s)" {
+		t.Errorf("unterminated quote: got %q", replies[0])
+	}
+	if replies[1] != "(nil)" {
+		t.Errorf("missing key: got %q", replies[1])
+	}
+	if !strings.HasPrefix(replies[2], "(error) ERR unknown command") {
+		t.Errorf("unknown command: got %q", replies[2])
+	}
+}
+
+func TestFormatReplyNestedArrays(t *testing.T) {
+	c := startRedisServer(t)
+
+	replies := runREPL(t, c, `COMMAND INFO get`)
+	if len(replies) < 2 || replies[0] != `1)  1) "get"` || !strings.HasPrefix(replies[1], "    2) (integer) 2") {
+		t.Fatalf("unexpected nested array formatting: %q", replies)
+	}
+}
+
+func TestRedisREPLSetWithTTL(t *testing.T) {
+	c := startRedisServer(t)
+
+	replies := runREPL(t, c,
+		`SET session "some value" 60`,
+		`TTL session`,
+		`SET short value 1`,
+		`SET plain value`,
+		`TTL plain`,
+		`SET bad value nonsense`,
+	)
+	// TTL 向下取整，刚设置的 60 秒过期时间可能显示为 59
+	if len(replies) == 6 && replies[1] == "(integer) 59" {
+		replies[1] = "(integer) 60"
+	}
+	expected := []string{
+		"OK",
+		"(integer) 60",
+		"OK",
+		"OK",
+		"(integer) -1",
+		"(error) ERR syntax error",
+	}
+	if !reflect.DeepEqual(replies, expected) {
+		t.Fatalf("unexpected replies:\n got: %q\nwant: %q", replies, expected)
+	}
+
+	// 过期后键被删除
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		if replies := runREPL(t, c, `EXISTS short`); replies[0] == "(integer) 0" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("key set with a TTL did not expire")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestExpandSetTTL(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expanded []string
+	}{
+		{[]string{"SET", "k", "v", "60"}, []string{"SET", "k", "v", "EX", "60"}},
+		{[]string{"set", "k", "v", "5"}, []string{"set", "k", "v", "EX", "5"}},
+		{[]string{"SET", "k", "v", "0"}, []string{"SET", "k", "v", "0"}},
+		{[]string{"SET", "k", "v", "NX"}, []string{"SET", "k", "v", "NX"}},
+		{[]string{"SET", "k", "v", "EX", "60"}, []string{"SET", "k", "v", "EX", "60"}},
+		{[]string{"GET", "k", "v", "60"}, []string{"GET", "k", "v", "60"}},
+	}
+	for _, test := range tests {
+		if expanded := expandSetTTL(test.args); !reflect.DeepEqual(expanded, test.expanded) {
+			t.Errorf("expandSetTTL(%q) = %q, want %q", test.args, expanded, test.expanded)
+		}
+	}
+}
+
+func TestRedisREPLHelp(t *testing.T) {
+	c := startRedisServer(t)
+
+	replies := runREPL(t, c, `HELP set`, `HELP getrange`, `HELP nosuchcommand`)
+	expected := []string{
+		"  SET key value [NX|XX] [EX seconds|PX milliseconds|EXAT unix-time-seconds|PXAT unix-time-milliseconds]",
+		"  summary: Sets the string value of a key, ignoring its type. The key is created if it doesn't exist.",
+		"  group: string",
+		"  GETRANGE key start end",
+		"  summary: Returns a substring of the string stored at a key.",
+		"  group: string",
+		"No help for 'nosuchcommand'",
+	}
+	if !reflect.DeepEqual(replies, expected) {
+		t.Fatalf("unexpected help:\n got: %q\nwant: %q", replies, expected)
+	}
+}
+
+func TestRedisREPLHistory(t *testing.T) {
+	c := startRedisServer(t)
+	path := filepath.Join(t.TempDir(), "history")
+
+	var out strings.Builder
+	input := strings.Join([]string{
+		`SET counter 1`,
+		`GET counter`,
+		`GET counter`,
+		`!1`,
+		`!!`,
+		`!9`,
+		`/history`,
+	}, "\n")
+	if err := runRedisREPL(c, loadHistory(path), "text", strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "!9: event not found") {
+		t.Errorf("missing history error in output: %q", out.String())
+	}
+	if !strings.Contains(out.String(), "    1  SET counter 1\n    2  GET counter\n    3  SET counter 1\nredis> ") {
+		t.Errorf("unexpected /history output: %q", out.String())
+	}
+
+	// 历史保存在文件中，重新加载后可以继续引用
+	history := loadHistory(path)
+	expected := []string{"SET counter 1", "GET counter", "SET counter 1"}
+	if !reflect.DeepEqual(history.entries, expected) {
+		t.Fatalf("unexpected history entries: %q", history.entries)
+	}
+	if line, err := history.expand("!2"); err != nil || line != "GET counter" {
+		t.Fatalf("expand(!2) = %q, %v", line, err)
+	}
+}
+
+func TestRedisHistorySkipsSensitiveCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	history := loadHistory(path)
+	for _, line := range []string{
+		`GET key`,
+		`AUTH secret`,
+		`auth default secret`,
+		`HELLO 3 AUTH default secret`,
+		`CONFIG SET requirepass secret`,
+		`config set maxclients 10 masterauth secret`,
+		`ACL SETUSER alice on >secret`,
+		`MIGRATE 127.0.0.1 6379 key 0 1000 AUTH secret`,
+		`HELLO 3`,
+		`CONFIG SET maxclients 10`,
+		`ACL WHOAMI`,
+	} {
+		history.add(line)
+	}
+
+	expected := []string{"GET key", "HELLO 3", "CONFIG SET maxclients 10", "ACL WHOAMI"}
+	if !reflect.DeepEqual(history.entries, expected) {
+		t.Fatalf("unexpected history entries: %q", history.entries)
+	}
+	if entries := loadHistory(path).entries; !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("unexpected history file entries: %q", entries)
+	}
+}
+
+func TestRedisREPLJSONFormat(t *testing.T) {
+	c := startRedisServer(t)
+
+	var out strings.Builder
+	input := "SET k \"v w\"\nGET k\nGET missing\nCONFIG GET timeout\nGET\n"
+	if err := runRedisREPL(c, &redisHistory{}, "json", strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "\"OK\"\n\"v w\"\nnull\n[\"timeout\",\"0\"]\n{\"error\":\"ERR wrong number of arguments for 'get' command\"}\n"
+	if out.String() != expected {
+		t.Fatalf("unexpected output:\n got: %q\nwant: %q", out.String(), expected)
+	}
+}
@@ -159,6 +159,21 @@ func main() {
 
 			// 根据消息类型处理
 			if msg.Data != nil {
+				// 处理在线状态事件
+				if data, ok := msg.Data.(map[string]interface{}); ok {
+					if event, hasEvent := data["event"].(string); hasEvent {
+						user, _ := data["user"].(string)
+						switch event {
+						case "join":
+							fmt.Printf("系统: %s 加入了聊天\n", user)
+						case "leave":
+							fmt.Printf("系统: %s 离开了聊天\n", user)
+						case "typing":
+							fmt.Printf("系统: %s 正在输入...\n", user)
+						}
+						continue
+					}
+				}
 				// 处理聊天消息
 				if data, ok := msg.Data.(map[string]interface{}); ok {
 					if user, hasUser := data["user"].(string); hasUser {
@@ -268,7 +283,7 @@ func joinChat(conn *websocket.Conn, messageID *int, username string) {
 		ID:     fmt.Sprintf("%d", *messageID),
 		Method: "JOIN",
 		Path:   "/chat",
-		Data:   map[string]interface{}{},
+		Data:   map[string]interface{}{"user": username},
 	}
 	
 	requestData, err := json.Marshal(joinRequest)
@@ -7,8 +7,10 @@ import (
 	"os/signal"
 	"runtime"
 	"spine-go/libspine"
+	"spine-go/libspine/handler"
 	"strings"
 	"syscall"
+	"time"
 )
 
 // isWindows 检测当前操作系统是否为 Windows
@@ -16,112 +18,157 @@ func isWindows() bool {
 	return runtime.GOOS == "windows"
 }
 
-func main() {
-	// 解析命令行参数
+// defaultListenConfigs 没有指定监听地址时使用的默认配置
+func defaultListenConfigs() []libspine.ListenConfig {
+	listenConfigs := []libspine.ListenConfig{
+		{Schema: "tcp", Host: "", Port: "8080", Path: ""},
+		{Schema: "http", Host: "", Port: "8000", Path: ""},
+	}
+	// 添加本地传输监听示例
+	if isWindows() {
+		// Windows 上使用 Named Pipe 风格路径
+		listenConfigs = append(listenConfigs, libspine.ListenConfig{
+			Schema: "local",
+			Host:   "",
+			Port:   "",
+			Path:   "/spine",
+		})
+	} else {
+		// Unix 上使用 Unix Socket 路径
+		listenConfigs = append(listenConfigs, libspine.ListenConfig{
+			Schema: "local",
+			Host:   "",
+			Port:   "",
+			Path:   "/tmp/spine.sock",
+		})
+	}
+	return listenConfigs
+}
+
+// parseConfig 解析命令行参数并生成服务器配置。
+// 指定 --config 时先读取配置文件，命令行上显式给出的参数覆盖文件中的值
+func parseConfig(args []string) (*libspine.Config, error) {
+	flags := flag.NewFlagSet("spine", flag.ContinueOnError)
 	var (
-		listenArgs []string
-		staticPath = flag.String("static", "", "Static files path for chat webui")
-		serverMode = flag.String("mode", "chat", "Server mode (chat/redis)")
+		listenArgs      []string
+		staticPath      = flags.String("static", "", "Static files path for chat webui")
+		serverMode      = flags.String("mode", "chat", "Server mode (chat/redis)")
+		enableDebug     = flags.Bool("enable-debug-command", false, "Allow the DEBUG command in redis mode (testing only)")
+		aofPath         = flags.String("aof", "", "Append-only file path for redis mode (empty disables persistence)")
+		aofFsync        = flags.String("aof-fsync", "everysec", "AOF fsync policy (always/everysec/no)")
+		snapshot        = flags.String("snapshot", "", "Snapshot file path used by SAVE/BGSAVE in redis mode")
+		requirePass     = flags.String("requirepass", "", "Password clients must AUTH with in redis mode (empty disables authentication)")
+		idleTimeout     = flags.Duration("timeout", 0, "Close client connections after this much idle time (0 disables)")
+		shutdownTimeout = flags.Duration("shutdown-timeout", 10*time.Second, "Maximum time to wait for in-flight commands on shutdown")
+		historyLimit    = flags.Int("history-limit", handler.DefaultChatHistoryLimit, "Number of chat messages kept in history in chat mode")
+		slowlogSlower   = flags.Int64("slowlog-log-slower-than", handler.DefaultSlowlogLogSlowerThan, "Log redis commands slower than this many microseconds (negative disables)")
+		slowlogMaxLen   = flags.Int("slowlog-max-len", handler.DefaultSlowlogMaxLen, "Maximum number of entries kept in the slow log")
+		expireInterval  = flags.Duration("active-expire-interval", handler.DefaultActiveExpireInterval, "How often redis mode samples keys with a TTL and deletes expired ones (negative disables)")
+		expireSamples   = flags.Int("active-expire-samples", handler.DefaultActiveExpireSamples, "Number of keys with a TTL checked per active expire sample")
+		enableMetrics   = flags.Bool("metrics", false, "Serve Prometheus metrics at /metrics on http listeners in redis mode")
+		configFile      = flags.String("config", "", "TOML config file with listen addresses and server options, rewritten by CONFIG REWRITE (flags given on the command line take precedence)")
 	)
 
+	var unixSocketPerm os.FileMode
+	flags.Func("unixsocketperm", "Octal permissions of unix sockets created by local listeners, e.g. 770 (default keeps the umask)", func(value string) error {
+		perm, err := libspine.ParseSocketPerm(value)
+		unixSocketPerm = perm
+		return err
+	})
+	reusePort := flags.Bool("reuseport", false, "Set SO_REUSEPORT on tcp listeners so several processes can listen on the same port")
+	proxyProtocol := flags.Bool("proxy-protocol", false, "Expect a PROXY protocol v1/v2 header on tcp connections and use the client address it carries (for running behind an L4 load balancer)")
+	unixSocketGroup := flags.String("unixsocketgroup", "", "Group name or id that owns unix sockets created by local listeners")
+
 	// 自定义 flag 函数来收集多个 --listen 参数
-	flag.Func("listen", "Listen address (format: schema://host:port, e.g., tcp://:8080, http://:8000, local:///tmp/spine.sock, local:///spine). Can be specified multiple times.", func(value string) error {
+	flags.Func("listen", "Listen address (format: schema://host:port, e.g., tcp://:8080, tcp://[::1]:8080, http://:8000, local:///tmp/spine.sock, local:///spine, local://@spine for a Linux abstract socket). Append ?mode=chat or ?mode=redis to serve a different mode on that address. Can be specified multiple times.", func(value string) error {
 		listenArgs = append(listenArgs, value)
 		return nil
 	})
 
-	flag.Parse()
-
-	// 解析监听地址
-	var listenConfigs []libspine.ListenConfig
-	for _, addr := range listenArgs {
-		addr = strings.TrimSpace(addr)
-		if addr == "" {
-			continue
-		}
-
-		// 解析 schema://host:port 格式
-		parts := strings.SplitN(addr, "://", 2)
-		if len(parts) != 2 {
-			log.Printf("Invalid listen address format: %s (expected schema://host:port)", addr)
-			continue
-		}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
 
-		schema := parts[0]
-		hostPort := parts[1]
-
-		// 对于 local schema，hostPort 就是路径
-		if schema == "local" {
-			listenConfigs = append(listenConfigs, libspine.ListenConfig{
-				Schema: schema,
-				Host:   "",
-				Port:   "",
-				Path:   hostPort,
-			})
-		} else {
-			// 对于 tcp 和 ws，分割 host 和 port
-			host, port := "", hostPort
-			if strings.Contains(hostPort, ":") {
-				if strings.HasPrefix(hostPort, ":") {
-					// :8080 格式
-					port = hostPort[1:]
-				} else {
-					// host:port 格式
-					lastColon := strings.LastIndex(hostPort, ":")
-					host = hostPort[:lastColon]
-					port = hostPort[lastColon+1:]
+	config := &libspine.Config{}
+	// 每个参数如何写入配置，用于先应用默认值、再在读取配置文件后应用显式给出的参数
+	apply := map[string]func(){
+		"static":                  func() { config.StaticPath = *staticPath },
+		"mode":                    func() { config.ServerMode = *serverMode },
+		"enable-debug-command":    func() { config.EnableDebugCommand = *enableDebug },
+		"aof":                     func() { config.AOFPath = *aofPath },
+		"aof-fsync":               func() { config.AOFFsync = *aofFsync },
+		"snapshot":                func() { config.SnapshotPath = *snapshot },
+		"requirepass":             func() { config.RequirePass = *requirePass },
+		"timeout":                 func() { config.Timeout = *idleTimeout },
+		"shutdown-timeout":        func() { config.ShutdownTimeout = *shutdownTimeout },
+		"history-limit":           func() { config.ChatHistoryLimit = *historyLimit },
+		"slowlog-log-slower-than": func() { config.SlowlogLogSlowerThan = *slowlogSlower },
+		"slowlog-max-len":         func() { config.SlowlogMaxLen = *slowlogMaxLen },
+		"active-expire-interval":  func() { config.ActiveExpireInterval = *expireInterval },
+		"active-expire-samples":   func() { config.ActiveExpireSamples = *expireSamples },
+		"metrics":                 func() { config.EnableMetrics = *enableMetrics },
+		"reuseport":               func() { config.ReusePort = *reusePort },
+		"proxy-protocol":          func() { config.ProxyProtocol = *proxyProtocol },
+		"unixsocketperm":          func() { config.UnixSocketPerm = unixSocketPerm },
+		"unixsocketgroup":         func() { config.UnixSocketGroup = *unixSocketGroup },
+		"listen": func() {
+			// 解析监听地址
+			config.ListenConfigs = nil
+			for _, addr := range listenArgs {
+				if strings.TrimSpace(addr) == "" {
+					continue
+				}
+				listenConfig, err := libspine.ParseListenAddress(addr)
+				if err != nil {
+					log.Printf("%v", err)
+					continue
 				}
+				config.ListenConfigs = append(config.ListenConfigs, listenConfig)
 			}
+		},
+	}
+	flags.VisitAll(func(f *flag.Flag) {
+		if set, ok := apply[f.Name]; ok {
+			set()
+		}
+	})
 
-			listenConfigs = append(listenConfigs, libspine.ListenConfig{
-				Schema: schema,
-				Host:   host,
-				Port:   port,
-				Path:   "",
-			})
+	if *configFile != "" {
+		if err := libspine.LoadConfigFile(*configFile, config); err != nil {
+			return nil, err
 		}
+		// 命令行上显式指定的参数优先于配置文件
+		flags.Visit(func(f *flag.Flag) {
+			if set, ok := apply[f.Name]; ok {
+				set()
+			}
+		})
 	}
 
 	// 如果没有指定监听地址，使用默认配置
-	if len(listenConfigs) == 0 {
-		listenConfigs = []libspine.ListenConfig{
-			{Schema: "tcp", Host: "", Port: "8080", Path: ""},
-			{Schema: "http", Host: "", Port: "8000", Path: ""},
-		}
-		// 添加本地传输监听示例
-		if isWindows() {
-			// Windows 上使用 Named Pipe 风格路径
-			listenConfigs = append(listenConfigs, libspine.ListenConfig{
-				Schema: "local",
-				Host:   "",
-				Port:   "",
-				Path:   "/spine",
-			})
-		} else {
-			// Unix 上使用 Unix Socket 路径
-			listenConfigs = append(listenConfigs, libspine.ListenConfig{
-				Schema: "local",
-				Host:   "",
-				Port:   "",
-				Path:   "/tmp/spine.sock",
-			})
-		}
+	if len(config.ListenConfigs) == 0 {
+		config.ListenConfigs = defaultListenConfigs()
 	}
+	return config, nil
+}
 
-	// 创建服务器配置
-	config := &libspine.Config{
-		ListenConfigs: listenConfigs,
-		ServerMode:    *serverMode,
-		StaticPath:    *staticPath,
+func main() {
+	// 解析命令行参数
+	config, err := parseConfig(os.Args[1:])
+	if err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		log.Fatalf("Invalid configuration: %v", err)
 	}
 
 	// 创建服务器
 	server := libspine.NewServer(config)
 
 	// 如果有静态文件路径，设置到服务器上下文中
-	if *staticPath != "" {
+	if config.StaticPath != "" {
 		serverCtx := server.GetServerContext()
-		serverCtx.ServerInfo.Config["static_path"] = *staticPath
+		serverCtx.ServerInfo.Config["static_path"] = config.StaticPath
 	}
 
 	// 启动服务器
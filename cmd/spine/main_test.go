@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine"
+	"spine-go/libspine/handler"
+)
+
+const sampleConfig = `
+listen = ["tcp://127.0.0.1:6379", "http://:8000", "local:///tmp/spine-test.sock"]
+mode = "redis"
+metrics = true
+snapshot = "/var/lib/spine/dump.spine"
+shutdown-timeout = "30s"
+active-expire-interval = 1
+requirepass = "secret"
+timeout = 300
+appendonly = "yes"
+appendfilename = "/var/lib/spine/appendonly.aof"
+appendfsync = "always"
+maxmemory = "100mb"
+save = "3600 1"
+slowlog-max-len = 64
+unixsocketperm = 770
+unixsocketgroup = "spine"
+reuseport = true
+proxy-protocol = true
+`
+
+// writeConfig 把配置写入临时文件并返回路径
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spine.toml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestParseConfigFile(t *testing.T) {
+	path := writeConfig(t, sampleConfig)
+	config, err := parseConfig([]string{"--config", path})
+	require.NoError(t, err)
+
+	assert.Equal(t, &libspine.Config{
+		ListenConfigs: []libspine.ListenConfig{
+			{Schema: "tcp", Host: "127.0.0.1", Port: "6379"},
+			{Schema: "http", Port: "8000"},
+			{Schema: "local", Path: "/tmp/spine-test.sock"},
+		},
+		ServerMode:           "redis",
+		EnableMetrics:        true,
+		SnapshotPath:         "/var/lib/spine/dump.spine",
+		ShutdownTimeout:      30 * time.Second,
+		ActiveExpireInterval: time.Second,
+		RequirePass:          "secret",
+		Timeout:              300 * time.Second,
+		AOFPath:              "/var/lib/spine/appendonly.aof",
+		AOFFsync:             "always",
+		MaxMemory:            "100mb",
+		Save:                 "3600 1",
+		SlowlogMaxLen:        64,
+		ReusePort:            true,
+		ProxyProtocol:        true,
+		UnixSocketPerm:       0770,
+		UnixSocketGroup:      "spine",
+		// 文件中没有的选项保留命令行参数的默认值
+		ChatHistoryLimit:     handler.DefaultChatHistoryLimit,
+		SlowlogLogSlowerThan: handler.DefaultSlowlogLogSlowerThan,
+		ActiveExpireSamples:  handler.DefaultActiveExpireSamples,
+		ConfigFile:           path,
+	}, config)
+}
+
+func TestParseConfigFlagsOverrideFile(t *testing.T) {
+	path := writeConfig(t, sampleConfig)
+	config, err := parseConfig([]string{
+		"--listen", "tcp://:7000",
+		"--requirepass", "override",
+		"--timeout", "1m",
+		"--config", path,
+		"--aof-fsync", "no",
+		"--unixsocketperm", "700",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []libspine.ListenConfig{{Schema: "tcp", Port: "7000"}}, config.ListenConfigs)
+	assert.Equal(t, "override", config.RequirePass)
+	assert.Equal(t, time.Minute, config.Timeout)
+	assert.Equal(t, "no", config.AOFFsync)
+	assert.Equal(t, os.FileMode(0700), config.UnixSocketPerm)
+	// 没有在命令行上指定的参数仍取自配置文件
+	assert.Equal(t, "redis", config.ServerMode)
+	assert.Equal(t, "/var/lib/spine/appendonly.aof", config.AOFPath)
+}
+
+func TestParseConfigWithoutFile(t *testing.T) {
+	config, err := parseConfig([]string{"--mode", "redis"})
+	require.NoError(t, err)
+	assert.Equal(t, "redis", config.ServerMode)
+	assert.Equal(t, "everysec", config.AOFFsync)
+	assert.Equal(t, defaultListenConfigs(), config.ListenConfigs)
+	assert.Empty(t, config.ConfigFile)
+
+	_, err = parseConfig([]string{"--config", writeConfig(t, "listen = \"tcp://:1\"\n")})
+	assert.Error(t, err, "listen must be an array")
+	_, err = parseConfig([]string{"--config", writeConfig(t, "listen = [\"nonsense\"]\n")})
+	assert.Error(t, err)
+}
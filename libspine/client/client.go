@@ -0,0 +1,223 @@
+// Package client 提供连接 redis 模式 spine 服务器的 Go 客户端
+//
+// 客户端直接使用 RESP 协议通信，支持 tcp 和 unix 两种网络类型。
+// 所有命令都接受 context，context 的截止时间和取消会作用在底层连接的读写上。
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"spine-go/libspine/common/resp"
+)
+
+// ErrNil 表示服务器返回了空回复，例如 GET 一个不存在的键
+var ErrNil = resp.ErrNil
+
+// ErrBroken 表示连接在一次请求中途出错，协议流已无法继续使用
+var ErrBroken = errors.New("client: connection is broken")
+
+// Client 单个连接上的客户端，并发调用会按顺序执行
+type Client struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	parser *resp.Parser
+	broken bool
+}
+
+// Dial 建立到服务器的连接，network 为 "tcp" 或 "unix"
+func Dial(ctx context.Context, network, address string) (*Client, error) {
+	switch network {
+	case "tcp", "unix":
+	default:
+		return nil, fmt.Errorf("unsupported network: %s", network)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient 用已建立的连接创建客户端
+func NewClient(conn net.Conn) *Client {
+	return &Client{
+		conn:   conn,
+		parser: resp.NewParser(conn),
+	}
+}
+
+// Do 发送一条命令并返回解析后的回复
+// 服务器返回的错误回复会转换为 *resp.CommandError
+func (c *Client) Do(ctx context.Context, args ...string) (resp.Value, error) {
+	if len(args) == 0 {
+		return resp.Value{}, errors.New("client: empty command")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.broken {
+		return resp.Value{}, ErrBroken
+	}
+
+	reply, err := c.roundTrip(ctx, args)
+	if err != nil {
+		// 读写中途失败后无法确定流的位置，连接不能再使用
+		c.broken = true
+		c.conn.Close()
+		return resp.Value{}, contextError(ctx, err)
+	}
+	return reply, replyError(reply)
+}
+
+// roundTrip 在 context 的约束下完成一次请求和回复
+func (c *Client) roundTrip(ctx context.Context, args []string) (resp.Value, error) {
+	if err := ctx.Err(); err != nil {
+		return resp.Value{}, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return resp.Value{}, err
+	}
+
+	// context 被取消时立刻让阻塞的读写返回
+	stop := make(chan struct{})
+	defer close(stop)
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				c.conn.SetDeadline(time.Unix(1, 0))
+			case <-stop:
+			}
+		}()
+	}
+
+	data, err := resp.SerializeCommand(args[0], args[1:]...)
+	if err != nil {
+		return resp.Value{}, err
+	}
+	if _, err := c.conn.Write(data); err != nil {
+		return resp.Value{}, err
+	}
+	return c.parser.Parse()
+}
+
+// contextError 把由 context 触发的连接超时还原为 context 的错误
+// 连接的截止时间可能比 context 自身的计时器先到期，因此不能只看 ctx.Err()
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	var netErr net.Error
+	if _, ok := ctx.Deadline(); ok && errors.As(err, &netErr) && netErr.Timeout() {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// replyError 将错误回复转换为 error
+func replyError(reply resp.Value) error {
+	var text string
+	switch reply.Type {
+	case resp.DataType(resp.TypeError):
+		text = reply.String
+	case resp.DataType(resp.TypeBlobError):
+		text = string(reply.Bulk)
+	default:
+		return nil
+	}
+
+	code, message, _ := strings.Cut(text, " ")
+	return &resp.CommandError{Code: code, Message: message}
+}
+
+// isBroken 连接是否已关闭或不可再用
+func (c *Client) isBroken() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.broken
+}
+
+// Close 关闭连接
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.broken = true
+	return c.conn.Close()
+}
+
+// Ping 检查连接是否可用
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Auth 使用密码认证，username 为空时只发送密码
+func (c *Client) Auth(ctx context.Context, username, password string) error {
+	if username == "" {
+		_, err := c.Do(ctx, "AUTH", password)
+		return err
+	}
+	_, err := c.Do(ctx, "AUTH", username, password)
+	return err
+}
+
+// Set 设置键值，ttl 大于 0 时同时设置毫秒精度的过期时间
+func (c *Client) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	args := []string{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.Do(ctx, args...)
+	return err
+}
+
+// Get 获取键值，键不存在时返回 ErrNil
+func (c *Client) Get(ctx context.Context, key string) (string, error) {
+	reply, err := c.Do(ctx, "GET", key)
+	if err != nil {
+		return "", err
+	}
+	if reply.IsNil() {
+		return "", ErrNil
+	}
+	return string(reply.Bulk), nil
+}
+
+// Del 删除键，返回实际删除的数量
+func (c *Client) Del(ctx context.Context, keys ...string) (int64, error) {
+	return c.intCommand(ctx, "DEL", keys...)
+}
+
+// Exists 返回存在的键数量
+func (c *Client) Exists(ctx context.Context, keys ...string) (int64, error) {
+	return c.intCommand(ctx, "EXISTS", keys...)
+}
+
+// TTL 返回键的剩余生存时间（秒），-1 表示没有过期时间，-2 表示键不存在
+func (c *Client) TTL(ctx context.Context, key string) (int64, error) {
+	return c.intCommand(ctx, "TTL", key)
+}
+
+// intCommand 执行返回整数的命令
+func (c *Client) intCommand(ctx context.Context, command string, args ...string) (int64, error) {
+	reply, err := c.Do(ctx, append([]string{command}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	if reply.Type != resp.DataType(resp.TypeInteger) {
+		return 0, fmt.Errorf("client: unexpected reply type %q for %s", byte(reply.Type), command)
+	}
+	return reply.Int, nil
+}
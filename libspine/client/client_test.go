@@ -0,0 +1,141 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine"
+	"spine-go/libspine/client"
+	"spine-go/libspine/common/resp"
+)
+
+// startServer 启动一个 redis 模式的进程内服务器，返回 tcp 地址
+func startServer(t *testing.T, listens ...libspine.ListenConfig) {
+	t.Helper()
+	server := libspine.NewServer(&libspine.Config{
+		ListenConfigs:      listens,
+		ServerMode:         "redis",
+		EnableDebugCommand: true,
+		ShutdownTimeout:    time.Second,
+	})
+	require.NoError(t, server.Start())
+	t.Cleanup(func() { server.Stop() })
+}
+
+// tcpListen 分配一个可用端口并返回监听配置和地址
+func tcpListen(t *testing.T) (libspine.ListenConfig, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+	_, port, err := net.SplitHostPort(address)
+	require.NoError(t, err)
+	return libspine.ListenConfig{Schema: "tcp", Host: "127.0.0.1", Port: port}, address
+}
+
+func dialTCP(t *testing.T) *client.Client {
+	t.Helper()
+	listen, address := tcpListen(t)
+	startServer(t, listen)
+
+	c, err := client.Dial(context.Background(), "tcp", address)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClientDo(t *testing.T) {
+	c := dialTCP(t)
+	ctx := context.Background()
+
+	reply, err := c.Do(ctx, "PING")
+	require.NoError(t, err)
+	assert.Equal(t, "PONG", reply.String)
+
+	reply, err = c.Do(ctx, "SET", "key", "value")
+	require.NoError(t, err)
+	assert.Equal(t, "OK", reply.String)
+
+	reply, err = c.Do(ctx, "GET", "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(reply.Bulk))
+
+	// 错误回复转换为 CommandError，连接仍然可用
+	_, err = c.Do(ctx, "NOSUCHCOMMAND")
+	cmdErr, ok := resp.AsCommandError(err)
+	require.True(t, ok, "expected CommandError, got %v", err)
+	assert.Equal(t, resp.ErrCodeGeneric, cmdErr.Code)
+	assert.Contains(t, cmdErr.Message, "unknown command")
+
+	require.NoError(t, c.Ping(ctx))
+}
+
+func TestClientTypedCommands(t *testing.T) {
+	c := dialTCP(t)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "a", "1", 0))
+	require.NoError(t, c.Set(ctx, "b", "2", time.Minute))
+
+	value, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", value)
+
+	_, err = c.Get(ctx, "missing")
+	assert.ErrorIs(t, err, client.ErrNil)
+
+	ttl, err := c.TTL(ctx, "b")
+	require.NoError(t, err)
+	assert.InDelta(t, 60, ttl, 1)
+
+	n, err := c.Exists(ctx, "a", "b", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = c.Del(ctx, "a", "missing")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestClientContextTimeout(t *testing.T) {
+	c := dialTCP(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.Do(ctx, "DEBUG", "SLEEP", "1")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+	assert.Less(t, time.Since(start), 900*time.Millisecond)
+
+	// 超时后回复可能还在路上，连接不再可用
+	_, err = c.Do(context.Background(), "PING")
+	assert.ErrorIs(t, err, client.ErrBroken)
+}
+
+func TestClientUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket is not available on windows")
+	}
+	path := filepath.Join(t.TempDir(), "spine.sock")
+	startServer(t, libspine.ListenConfig{Schema: "local", Path: path})
+
+	c, err := client.Dial(context.Background(), "unix", path)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", "value", 0))
+	value, err := c.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
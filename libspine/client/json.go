@@ -0,0 +1,130 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"spine-go/libspine/common/resp"
+)
+
+// ReplyJSON 把回复编码为 JSON，便于脚本处理：
+//
+//   - 简单字符串、批量字符串、verbatim 字符串和大数编码为字符串
+//   - 整数和浮点数编码为数字，无法用 JSON 表示的 inf 和 nan 编码为字符串
+//   - 空回复（RESP3 null 以及 RESP2 的空批量字符串和空数组）编码为 null
+//   - 数组、集合和 push 编码为数组，map 和属性编码为对象，保留服务器返回的顺序
+//   - 错误回复编码为 {"error": "ERR ..."}
+//
+// 批量字符串中不是合法 UTF-8 的字节会被替换为 U+FFFD
+func ReplyJSON(reply resp.Value) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeReply(&buf, reply); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeReply 把一个回复编码后追加到 buf
+func encodeReply(buf *bytes.Buffer, reply resp.Value) error {
+	switch reply.Type {
+	case resp.DataType(resp.TypeSimpleString):
+		return encodeJSON(buf, reply.String)
+	case resp.DataType(resp.TypeBigNumber):
+		return encodeJSON(buf, reply.BigNum)
+	case resp.DataType(resp.TypeError):
+		return encodeError(buf, reply.String)
+	case resp.DataType(resp.TypeBlobError):
+		return encodeError(buf, string(reply.Bulk))
+	case resp.DataType(resp.TypeInteger):
+		buf.WriteString(strconv.FormatInt(reply.Int, 10))
+		return nil
+	case resp.DataType(resp.TypeDouble):
+		if math.IsInf(reply.Double, 0) || math.IsNaN(reply.Double) {
+			return encodeJSON(buf, strconv.FormatFloat(reply.Double, 'g', -1, 64))
+		}
+		return encodeJSON(buf, reply.Double)
+	case resp.DataType(resp.TypeBoolean):
+		return encodeJSON(buf, reply.Bool)
+	case resp.DataType(resp.TypeNull):
+		buf.WriteString("null")
+		return nil
+	case resp.DataType(resp.TypeBulkString):
+		if reply.IsNull {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeJSON(buf, string(reply.Bulk))
+	case resp.DataType(resp.TypeVerbatimString):
+		return encodeJSON(buf, reply.String)
+	case resp.DataType(resp.TypeArray), resp.DataType(resp.TypeSet), resp.DataType(resp.TypePush):
+		if reply.IsNull {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, item := range reply.Array {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeReply(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case resp.DataType(resp.TypeMap), resp.DataType(resp.TypeAttribute):
+		buf.WriteByte('{')
+		for i, item := range reply.Map {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSON(buf, mapKey(item.Key)); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := encodeReply(buf, item.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+	return &json.UnsupportedValueError{Str: "reply type " + string(rune(reply.Type))}
+}
+
+// mapKey 返回 map 键的文本形式，JSON 对象的键只能是字符串
+func mapKey(key resp.Value) string {
+	switch key.Type {
+	case resp.DataType(resp.TypeBulkString):
+		return string(key.Bulk)
+	case resp.DataType(resp.TypeInteger):
+		return strconv.FormatInt(key.Int, 10)
+	case resp.DataType(resp.TypeDouble):
+		return strconv.FormatFloat(key.Double, 'g', -1, 64)
+	case resp.DataType(resp.TypeBoolean):
+		return strconv.FormatBool(key.Bool)
+	}
+	return key.String
+}
+
+// encodeError 编码错误回复
+func encodeError(buf *bytes.Buffer, text string) error {
+	buf.WriteString(`{"error":`)
+	if err := encodeJSON(buf, text); err != nil {
+		return err
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// encodeJSON 用 encoding/json 编码标量值
+func encodeJSON(buf *bytes.Buffer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
@@ -0,0 +1,63 @@
+package client_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/client"
+	"spine-go/libspine/common/resp"
+)
+
+func TestReplyJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply resp.Value
+		json  string
+	}{
+		{"simple string", resp.NewSimpleString("OK"), `"OK"`},
+		{"bulk string", resp.NewBulkStringString("say \"hi\"\n"), `"say \"hi\"\n"`},
+		{"null bulk string", resp.NewBulkString(nil), `null`},
+		{"null", resp.NewNull(), `null`},
+		{"integer", resp.NewInteger(-42), `-42`},
+		{"double", resp.NewDouble(1.5), `1.5`},
+		{"infinite double", resp.NewDouble(math.Inf(1)), `"+Inf"`},
+		{"boolean", resp.NewBoolean(true), `true`},
+		{"error", resp.NewError("ERR boom"), `{"error":"ERR boom"}`},
+		{"array", resp.NewArray([]resp.Value{
+			resp.NewBulkStringString("a"),
+			resp.NewInteger(1),
+			resp.NewBulkString(nil),
+			resp.NewArray([]resp.Value{}),
+		}), `["a",1,null,[]]`},
+		{"map keeps order", resp.NewMap([]resp.MapItem{
+			{Key: resp.NewBulkStringString("z"), Value: resp.NewInteger(1)},
+			{Key: resp.NewSimpleString("a"), Value: resp.NewArray([]resp.Value{resp.NewBulkStringString("x")})},
+		}), `{"z":1,"a":["x"]}`},
+	}
+	for _, test := range tests {
+		data, err := client.ReplyJSON(test.reply)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.json, string(data), test.name)
+	}
+}
+
+func TestReplyJSONFromServer(t *testing.T) {
+	c := dialTCP(t)
+	ctx := context.Background()
+
+	reply, err := c.Do(ctx, "CONFIG", "GET", "timeout")
+	require.NoError(t, err)
+	data, err := client.ReplyJSON(reply)
+	require.NoError(t, err)
+	assert.Equal(t, `["timeout","0"]`, string(data))
+
+	reply, err = c.Do(ctx, "GET", "missing")
+	require.NoError(t, err)
+	data, err = client.ReplyJSON(reply)
+	require.NoError(t, err)
+	assert.Equal(t, `null`, string(data))
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"spine-go/libspine/common/resp"
+)
+
+// ErrPoolClosed 表示连接池已关闭
+var ErrPoolClosed = errors.New("client: pool is closed")
+
+// defaultHealthCheckInterval 空闲连接超过该时间后取出前先 PING 检查
+const defaultHealthCheckInterval = time.Minute
+
+// PoolStats 连接池状态
+type PoolStats struct {
+	Active int // 已打开的连接数，包括空闲连接
+	Idle   int // 空闲连接数
+}
+
+// idleConn 池中的空闲连接
+type idleConn struct {
+	client *Client
+	since  time.Time
+}
+
+// Pool 可并发使用的连接池
+// 连接通过 Get 取出，用完后必须调用 Put 归还
+type Pool struct {
+	dial                func(ctx context.Context) (*Client, error)
+	maxIdle             int
+	maxActive           int
+	healthCheckInterval time.Duration
+
+	mu     sync.Mutex
+	idle   []idleConn
+	active int
+	closed bool
+
+	// available 在连接归还或关闭时发出通知，唤醒等待中的 Get
+	available chan struct{}
+	done      chan struct{}
+}
+
+// NewPool 创建连接池
+// maxIdle 为最多保留的空闲连接数，maxActive 为最多同时打开的连接数，0 表示不限制
+func NewPool(dial func(ctx context.Context) (*Client, error), maxIdle, maxActive int) *Pool {
+	size := maxActive
+	if size <= 0 {
+		size = 1
+	}
+	return &Pool{
+		dial:                dial,
+		maxIdle:             maxIdle,
+		maxActive:           maxActive,
+		healthCheckInterval: defaultHealthCheckInterval,
+		available:           make(chan struct{}, size),
+		done:                make(chan struct{}),
+	}
+}
+
+// SetHealthCheckInterval 设置空闲连接的健康检查间隔
+// 空闲时间达到该值的连接在取出前会先 PING，0 表示每次取出都检查
+func (p *Pool) SetHealthCheckInterval(interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.healthCheckInterval = interval
+}
+
+// Get 取出一个连接，达到 maxActive 时等待其他连接归还或 ctx 结束
+func (p *Pool) Get(ctx context.Context) (*Client, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+
+		// 优先复用最近归还的空闲连接
+		if n := len(p.idle); n > 0 {
+			conn := p.idle[n-1]
+			p.idle = p.idle[:n-1]
+			check := time.Since(conn.since) >= p.healthCheckInterval
+			p.mu.Unlock()
+
+			if !check || conn.client.Ping(ctx) == nil {
+				return conn.client, nil
+			}
+			p.release(conn.client)
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if p.maxActive <= 0 || p.active < p.maxActive {
+			p.active++
+			p.mu.Unlock()
+
+			client, err := p.dial(ctx)
+			if err != nil {
+				p.mu.Lock()
+				p.active--
+				p.mu.Unlock()
+				p.notify()
+				return nil, err
+			}
+			return client, nil
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-p.available:
+		case <-p.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Put 归还连接，已损坏的连接或超出 maxIdle 的连接会被关闭
+func (p *Pool) Put(client *Client) {
+	p.mu.Lock()
+	if p.closed || client.isBroken() || len(p.idle) >= p.maxIdle {
+		p.mu.Unlock()
+		p.release(client)
+		return
+	}
+	p.idle = append(p.idle, idleConn{client: client, since: time.Now()})
+	p.mu.Unlock()
+	p.notify()
+}
+
+// release 关闭连接并释放它占用的名额
+func (p *Pool) release(client *Client) {
+	client.Close()
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+	p.notify()
+}
+
+// notify 唤醒一个等待中的 Get，没有等待者时通知会留给下一次等待
+func (p *Pool) notify() {
+	select {
+	case p.available <- struct{}{}:
+	default:
+	}
+}
+
+// Do 从池中取出连接执行一条命令并归还连接
+func (p *Pool) Do(ctx context.Context, args ...string) (resp.Value, error) {
+	client, err := p.Get(ctx)
+	if err != nil {
+		return resp.Value{}, err
+	}
+	defer p.Put(client)
+	return client.Do(ctx, args...)
+}
+
+// Stats 返回连接池当前状态
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{Active: p.active, Idle: len(p.idle)}
+}
+
+// Close 关闭连接池和所有空闲连接，使用中的连接在归还时关闭
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.active -= len(idle)
+	close(p.done)
+	p.mu.Unlock()
+
+	for _, conn := range idle {
+		conn.client.Close()
+	}
+	return nil
+}
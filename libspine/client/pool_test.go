@@ -0,0 +1,145 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/client"
+)
+
+// newTestPool 启动服务器并返回指向它的连接池和一个独立的管理连接
+func newTestPool(t *testing.T, maxIdle, maxActive int) (*client.Pool, *client.Client) {
+	t.Helper()
+	listen, address := tcpListen(t)
+	startServer(t, listen)
+
+	pool := client.NewPool(func(ctx context.Context) (*client.Client, error) {
+		return client.Dial(ctx, "tcp", address)
+	}, maxIdle, maxActive)
+	t.Cleanup(func() { pool.Close() })
+
+	admin, err := client.Dial(context.Background(), "tcp", address)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+	return pool, admin
+}
+
+// serverClients 返回服务器端当前的连接数
+func serverClients(t *testing.T, admin *client.Client) int {
+	t.Helper()
+	reply, err := admin.Do(context.Background(), "CLIENT", "LIST")
+	require.NoError(t, err)
+	return strings.Count(string(reply.Bulk), "\n")
+}
+
+func TestPoolConcurrentDo(t *testing.T) {
+	pool, admin := newTestPool(t, 10, 10)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key:%d", i)
+			value := fmt.Sprintf("value:%d", i)
+			if _, err := pool.Do(ctx, "SET", key, value); err != nil {
+				errs <- err
+				return
+			}
+			reply, err := pool.Do(ctx, "GET", key)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if string(reply.Bulk) != value {
+				errs <- fmt.Errorf("GET %s = %q, want %q", key, reply.Bulk, value)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// 所有连接都已归还，且从未超过 maxActive
+	stats := pool.Stats()
+	assert.LessOrEqual(t, stats.Active, 10)
+	assert.Equal(t, stats.Active, stats.Idle)
+	// 服务器看到的连接数 = 池中连接 + 管理连接
+	assert.Equal(t, stats.Active+1, serverClients(t, admin))
+
+	require.NoError(t, pool.Close())
+	assert.Equal(t, client.PoolStats{}, pool.Stats())
+	require.Eventually(t, func() bool {
+		return serverClients(t, admin) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+
+	_, err := pool.Get(ctx)
+	assert.ErrorIs(t, err, client.ErrPoolClosed)
+}
+
+func TestPoolWaitsForMaxActive(t *testing.T) {
+	pool, _ := newTestPool(t, 1, 1)
+
+	first, err := pool.Get(context.Background())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = pool.Get(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 归还后等待者可以拿到同一个连接
+	got := make(chan *client.Client, 1)
+	go func() {
+		c, err := pool.Get(context.Background())
+		if err == nil {
+			got <- c
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	pool.Put(first)
+
+	select {
+	case c := <-got:
+		assert.Same(t, first, c)
+		pool.Put(c)
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiting Get was not woken by Put")
+	}
+}
+
+func TestPoolHealthCheckReplacesDeadConnection(t *testing.T) {
+	pool, admin := newTestPool(t, 1, 1)
+	pool.SetHealthCheckInterval(0)
+	ctx := context.Background()
+
+	c, err := pool.Get(ctx)
+	require.NoError(t, err)
+	reply, err := c.Do(ctx, "CLIENT", "ID")
+	require.NoError(t, err)
+	id := reply.Int
+	pool.Put(c)
+
+	// 服务器端断开空闲连接
+	_, err = admin.Do(ctx, "CLIENT", "KILL", "ID", fmt.Sprint(id))
+	require.NoError(t, err)
+
+	c, err = pool.Get(ctx)
+	require.NoError(t, err)
+	defer pool.Put(c)
+	reply, err = c.Do(ctx, "CLIENT", "ID")
+	require.NoError(t, err)
+	assert.NotEqual(t, id, reply.Int)
+	assert.Equal(t, 1, pool.Stats().Active)
+}
@@ -0,0 +1,62 @@
+// Package cluster implements the key distribution used by Redis Cluster, so
+// that keys can be mapped to hash slots the same way cluster-aware clients do.
+package cluster
+
+// Slots is the number of hash slots keys are distributed over.
+const Slots = 16384
+
+// crc16Table is the lookup table for CRC16-CCITT (XModem), polynomial 0x1021.
+var crc16Table = func() [256]uint16 {
+	var table [256]uint16
+	for i := range table {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// CRC16 returns the CRC16-CCITT (XModem) checksum of key, the variant Redis
+// Cluster uses for key hashing.
+func CRC16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^key[i]]
+	}
+	return crc
+}
+
+// KeySlot returns the hash slot of key. If the key contains a hash tag, a
+// non-empty substring between the first '{' and the next '}', only the tag
+// is hashed, so that related keys such as {user1000}.following and
+// {user1000}.followers are stored in the same slot.
+func KeySlot(key string) int {
+	return int(CRC16(hashTag(key)) % Slots)
+}
+
+// hashTag returns the part of key that is hashed: the hash tag if there is
+// one, otherwise the whole key.
+func hashTag(key string) string {
+	for start := 0; start < len(key); start++ {
+		if key[start] != '{' {
+			continue
+		}
+		for end := start + 1; end < len(key); end++ {
+			if key[end] == '}' {
+				if end == start+1 {
+					// An empty tag "{}" hashes the whole key
+					return key
+				}
+				return key[start+1 : end]
+			}
+		}
+		return key
+	}
+	return key
+}
@@ -0,0 +1,49 @@
+package cluster
+
+import "testing"
+
+func TestCRC16(t *testing.T) {
+	// The check value of CRC16-CCITT (XModem), also used by the Redis test suite
+	if crc := CRC16("123456789"); crc != 0x31c3 {
+		t.Fatalf("CRC16(123456789) = %#x, want 0x31c3", crc)
+	}
+}
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		slot int
+	}{
+		{"foo", 12182},
+		{"bar", 5061},
+		{"", 0},
+		{"123456789", 12739},
+		{"{user1000}.following", 3443},
+		{"{user1000}.followers", 3443},
+		{"user1000", 3443},
+	}
+	for _, test := range tests {
+		if slot := KeySlot(test.key); slot != test.slot {
+			t.Errorf("KeySlot(%q) = %d, want %d", test.key, slot, test.slot)
+		}
+	}
+}
+
+func TestHashTag(t *testing.T) {
+	tests := []struct {
+		key, tag string
+	}{
+		{"foo", "foo"},
+		{"{user1000}.following", "user1000"},
+		{"foo{}{bar}", "foo{}{bar}"},
+		{"foo{{bar}}zap", "{bar"},
+		{"foo{bar}{zap}", "bar"},
+		{"foo{bar", "foo{bar"},
+		{"foo}bar{", "foo}bar{"},
+	}
+	for _, test := range tests {
+		if tag := hashTag(test.key); tag != test.tag {
+			t.Errorf("hashTag(%q) = %q, want %q", test.key, tag, test.tag)
+		}
+	}
+}
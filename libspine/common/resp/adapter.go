@@ -23,6 +23,26 @@ func (r *RespReader) ReadValue() (Value, error) {
 	return r.parser.Parse()
 }
 
+// SetMaxBulkLength limits the length of bulk strings the reader accepts, see Parser.SetMaxBulkLength
+func (r *RespReader) SetMaxBulkLength(n int64) {
+	r.parser.SetMaxBulkLength(n)
+}
+
+// SetMaxMultiBulkLength limits the element count of aggregates the reader accepts, see Parser.SetMaxMultiBulkLength
+func (r *RespReader) SetMaxMultiBulkLength(n int64) {
+	r.parser.SetMaxMultiBulkLength(n)
+}
+
+// ReadRequest reads a client request, either a RESP array or an inline command, see Parser.ParseRequest
+func (r *RespReader) ReadRequest() (Value, error) {
+	return r.parser.ParseRequest()
+}
+
+// Buffered returns the number of bytes of pipelined input waiting to be parsed
+func (r *RespReader) Buffered() int {
+	return r.parser.Buffered()
+}
+
 // ReadCommand reads a RESP array as a Redis command
 func (r *RespReader) ReadCommand() ([]Value, error) {
 	return r.parser.ParseCommand()
@@ -37,6 +57,8 @@ func (r *RespReader) Close() error {
 type RespWriter struct {
 	writer     io.WriteCloser
 	serializer *Serializer
+	// deferFlush keeps replies in the buffer until Flush is called
+	deferFlush bool
 }
 
 // NewRespWriter creates a new RESP writer from a transport.Writer
@@ -52,6 +74,21 @@ func (w *RespWriter) WriteValue(v Value) error {
 	if err := w.serializer.Serialize(v); err != nil {
 		return err
 	}
+	if w.deferFlush {
+		return nil
+	}
+	return w.serializer.Flush()
+}
+
+// SetDeferFlush controls whether each written value is flushed immediately.
+// When enabled, replies accumulate in the buffer (it still flushes itself
+// when full) until Flush is called, so pipelined replies can share a write.
+func (w *RespWriter) SetDeferFlush(enabled bool) {
+	w.deferFlush = enabled
+}
+
+// Flush writes any buffered replies to the underlying writer
+func (w *RespWriter) Flush() error {
 	return w.serializer.Flush()
 }
 
@@ -85,6 +122,20 @@ func (w *RespWriter) WriteArray(values []Value) error {
 	return w.WriteValue(NewArray(values))
 }
 
+// WriteArrayHeader starts a streamed array reply of n elements. Each
+// element must then be written with the other Write methods; the header is
+// buffered and goes out together with the first element. An empty array is
+// already a complete reply and is flushed like any other value.
+func (w *RespWriter) WriteArrayHeader(n int) error {
+	if err := w.serializer.WriteArrayHeader(n); err != nil {
+		return err
+	}
+	if n > 0 || w.deferFlush {
+		return nil
+	}
+	return w.serializer.Flush()
+}
+
 // WriteNil writes a nil response
 func (w *RespWriter) WriteNil() error {
 	return w.WriteValue(NewBulkString(nil))
@@ -127,9 +178,14 @@ func (w *RespWriter) WriteWrongTypeError() error {
 	return w.WriteErrorString("WRONGTYPE", "Operation against a key holding the wrong kind of value")
 }
 
+// WriteCommandErr writes a CommandError as a RESP error reply
+func (w *RespWriter) WriteCommandErr(err *CommandError) error {
+	return w.WriteError(err.Error())
+}
+
 // WriteWrongNumberOfArgumentsError writes a standard Redis wrong number of arguments error
 func (w *RespWriter) WriteWrongNumberOfArgumentsError(cmd string) error {
-	return w.WriteErrorString("ERR wrong number of arguments for", cmd+" command")
+	return w.WriteCommandErr(NewWrongNumberOfArgumentsError(cmd))
 }
 
 // RESP v3 writer methods
@@ -0,0 +1,86 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error codes used as the prefix of RESP error replies
+const (
+	ErrCodeGeneric   = "ERR"
+	ErrCodeWrongType = "WRONGTYPE"
+	ErrCodeNoAuth    = "NOAUTH"
+	ErrCodeWrongPass = "WRONGPASS"
+	ErrCodeNoPerm    = "NOPERM"
+	ErrCodeBusyKey   = "BUSYKEY"
+	ErrCodeIOError   = "IOERR"
+	ErrCodeReadOnly  = "READONLY"
+	ErrCodeOOM       = "OOM"
+)
+
+// CommandError is an error meant to be sent to the client as a RESP error reply.
+// Code is the wire prefix (ERR, WRONGTYPE, ...) and Message is the text after it.
+type CommandError struct {
+	Code    string
+	Message string
+}
+
+// Error returns the error as it appears on the wire, without the leading '-'
+func (e *CommandError) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+	return e.Code + " " + e.Message
+}
+
+// NewCommandError creates a new generic ERR command error
+func NewCommandError(format string, args ...interface{}) *CommandError {
+	return &CommandError{
+		Code:    ErrCodeGeneric,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// NewCommandErrorWithCode creates a new command error with the given code
+func NewCommandErrorWithCode(code string, format string, args ...interface{}) *CommandError {
+	return &CommandError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// NewSyntaxError creates the standard Redis syntax error
+func NewSyntaxError() *CommandError {
+	return &CommandError{
+		Code:    ErrCodeGeneric,
+		Message: "syntax error",
+	}
+}
+
+// NewWrongNumberOfArgumentsError creates the standard Redis arity error.
+// cmd is the command name, or "container|subcommand" for subcommands; it is lowercased
+// as in Redis: ERR wrong number of arguments for 'get' command
+func NewWrongNumberOfArgumentsError(cmd string) *CommandError {
+	return &CommandError{
+		Code:    ErrCodeGeneric,
+		Message: fmt.Sprintf("wrong number of arguments for '%s' command", strings.ToLower(cmd)),
+	}
+}
+
+// NewWrongTypeError creates the standard Redis wrong type error
+func NewWrongTypeError() *CommandError {
+	return &CommandError{
+		Code:    ErrCodeWrongType,
+		Message: "Operation against a key holding the wrong kind of value",
+	}
+}
+
+// AsCommandError reports whether err is (or wraps) a CommandError and returns it
+func AsCommandError(err error) (*CommandError, bool) {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr, true
+	}
+	return nil, false
+}
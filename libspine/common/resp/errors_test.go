@@ -0,0 +1,71 @@
+package resp
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// nopWriteCloser wraps a bytes.Buffer as an io.WriteCloser
+type nopWriteCloser struct {
+	*bytes.Buffer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestCommandErrorWirePrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      *CommandError
+		expected string
+	}{
+		{
+			name:     "generic error",
+			err:      NewCommandError("unknown command '%s'", "FOO"),
+			expected: "-ERR unknown command 'FOO'\r\n",
+		},
+		{
+			name:     "syntax error",
+			err:      NewSyntaxError(),
+			expected: "-ERR syntax error\r\n",
+		},
+		{
+			name:     "wrong type",
+			err:      NewWrongTypeError(),
+			expected: "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n",
+		},
+		{
+			name:     "custom code",
+			err:      NewCommandErrorWithCode("NOSCRIPT", "No matching script"),
+			expected: "-NOSCRIPT No matching script\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := nopWriteCloser{&bytes.Buffer{}}
+			writer := NewRespWriter(buf)
+			if err := writer.WriteCommandErr(tt.err); err != nil {
+				t.Fatalf("WriteCommandErr() error = %v", err)
+			}
+			if got := buf.String(); got != tt.expected {
+				t.Errorf("WriteCommandErr() got = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAsCommandError(t *testing.T) {
+	wrapped := fmt.Errorf("zscore: %w", NewWrongTypeError())
+	cmdErr, ok := AsCommandError(wrapped)
+	if !ok {
+		t.Fatalf("AsCommandError() did not find wrapped CommandError")
+	}
+	if cmdErr.Code != ErrCodeWrongType {
+		t.Errorf("AsCommandError() code = %s, want %s", cmdErr.Code, ErrCodeWrongType)
+	}
+
+	if _, ok := AsCommandError(fmt.Errorf("plain error")); ok {
+		t.Errorf("AsCommandError() matched a plain error")
+	}
+}
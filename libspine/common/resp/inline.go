@@ -0,0 +1,168 @@
+package resp
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+)
+
+// maxInlineLength is the longest inline request accepted, the same as
+// Redis' PROTO_INLINE_MAX_SIZE
+const maxInlineLength = 64 * 1024
+
+// ParseRequest reads a client request. Requests starting with '*' are RESP
+// arrays; anything else is an inline command, a line of space separated
+// arguments as typed into telnet, which is returned as an array of bulk
+// strings. Empty inline lines are skipped.
+func (p *Parser) ParseRequest() (Value, error) {
+	for {
+		first, err := p.reader.Peek(1)
+		if err != nil {
+			return Value{}, err
+		}
+		if first[0] == TypeArray {
+			return p.Parse()
+		}
+
+		line, err := p.readInlineLine()
+		if err != nil {
+			return Value{}, err
+		}
+		args, err := SplitArgs(line)
+		if err != nil {
+			return Value{}, err
+		}
+		if len(args) == 0 {
+			continue
+		}
+		elements := make([]Value, len(args))
+		for i, arg := range args {
+			elements[i] = NewBulkString([]byte(arg))
+		}
+		return NewArray(elements), nil
+	}
+}
+
+// readInlineLine reads a line terminated by LF, dropping the LF and an
+// optional CR before it
+func (p *Parser) readInlineLine() (string, error) {
+	var line []byte
+	for {
+		chunk, err := p.reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxInlineLength {
+			return "", fmt.Errorf("%w: %w: inline request longer than %d bytes", ErrInvalidSyntax, ErrLengthLimit, maxInlineLength)
+		}
+		if err == nil {
+			break
+		}
+		if err != bufio.ErrBufferFull {
+			return "", err
+		}
+	}
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return string(line), nil
+}
+
+// SplitArgs splits a command line the way redis-cli and Redis inline
+// commands do: arguments are separated by whitespace, double quoted
+// arguments support \n \r \t \b \a \" \\ and \xHH escapes, single quoted
+// arguments support only \'. A closing quote must be followed by
+// whitespace or the end of the line, otherwise ErrUnbalancedQuotes is
+// returned.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		var current []byte
+		inDouble, inSingle := false, false
+	token:
+		for {
+			if i == len(line) {
+				if inDouble || inSingle {
+					return nil, ErrUnbalancedQuotes
+				}
+				break
+			}
+			ch := line[i]
+			switch {
+			case inDouble:
+				if ch == '\\' && i+3 < len(line) && line[i+1] == 'x' && isHex(line[i+2]) && isHex(line[i+3]) {
+					b, _ := strconv.ParseUint(line[i+2:i+4], 16, 8)
+					current = append(current, byte(b))
+					i += 3
+				} else if ch == '\\' && i+1 < len(line) {
+					i++
+					switch line[i] {
+					case 'n':
+						current = append(current, '\n')
+					case 'r':
+						current = append(current, '\r')
+					case 't':
+						current = append(current, '\t')
+					case 'b':
+						current = append(current, '\b')
+					case 'a':
+						current = append(current, '\a')
+					default:
+						current = append(current, line[i])
+					}
+				} else if ch == '"' {
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+					i++
+					break token
+				} else {
+					current = append(current, ch)
+				}
+			case inSingle:
+				if ch == '\\' && i+1 < len(line) && line[i+1] == '\'' {
+					current = append(current, '\'')
+					i++
+				} else if ch == '\'' {
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+					i++
+					break token
+				} else {
+					current = append(current, ch)
+				}
+			default:
+				switch {
+				case isSpace(ch):
+					break token
+				case ch == '"':
+					inDouble = true
+				case ch == '\'':
+					inSingle = true
+				default:
+					current = append(current, ch)
+				}
+			}
+			i++
+		}
+		args = append(args, string(current))
+	}
+}
+
+// isSpace reports whether ch separates arguments
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\v' || ch == '\f'
+}
+
+// isHex reports whether ch is a hexadecimal digit
+func isHex(ch byte) bool {
+	return ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'f' || ch >= 'A' && ch <= 'F'
+}
@@ -0,0 +1,92 @@
+package resp
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRequestInline(t *testing.T) {
+	input := "PING\r\n" +
+		"set \"a b\" c\r\n" +
+		"\r\n" +
+		"  get   'a b'  \n" +
+		"*2\r\n$3\r\nGET\r\n$1\r\nk\r\n" +
+		"ECHO \"\\x41\\n\"\r\n"
+	parser := NewParser(strings.NewReader(input))
+
+	expected := [][]string{
+		{"PING"},
+		{"set", "a b", "c"},
+		{"get", "a b"},
+		{"GET", "k"},
+		{"ECHO", "A\n"},
+	}
+	for _, want := range expected {
+		got, err := parser.ParseRequest()
+		if err != nil {
+			t.Fatalf("ParseRequest() error = %v", err)
+		}
+		if got.Type != DataType(TypeArray) {
+			t.Fatalf("ParseRequest() type = %c, want array", got.Type)
+		}
+		var args []string
+		for _, item := range got.Array {
+			args = append(args, string(item.Bulk))
+		}
+		if !reflect.DeepEqual(args, want) {
+			t.Errorf("ParseRequest() = %q, want %q", args, want)
+		}
+	}
+}
+
+func TestParseRequestInlineErrors(t *testing.T) {
+	_, err := NewParser(strings.NewReader("set \"a b c\r\n")).ParseRequest()
+	if !errors.Is(err, ErrUnbalancedQuotes) {
+		t.Errorf("unbalanced quotes: error = %v, want %v", err, ErrUnbalancedQuotes)
+	}
+
+	long := bytes.Repeat([]byte("a"), maxInlineLength+1)
+	_, err = NewParser(bytes.NewReader(long)).ParseRequest()
+	if !errors.Is(err, ErrLengthLimit) || !errors.Is(err, ErrInvalidSyntax) {
+		t.Errorf("long inline request: error = %v, want %v", err, ErrLengthLimit)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+	}{
+		{"", nil},
+		{"  GET   key  ", []string{"GET", "key"}},
+		{`SET k "a b"`, []string{"SET", "k", "a b"}},
+		{`SET k "say \"hi\""`, []string{"SET", "k", `say "hi"`}},
+		{`SET k "\x41\x7a\n\t\\"`, []string{"SET", "k", "Az\n\t\\"}},
+		{`SET k "\xZZ"`, []string{"SET", "k", "xZZ"}},
+		{`SET k 'a \'b\' \n'`, []string{"SET", "k", `a 'b' \n`}},
+		{`SET k ""`, []string{"SET", "k", ""}},
+		{`SET k ab"c d"`, []string{"SET", "k", "abc d"}},
+		{"SET\tk\t\"v w\"", []string{"SET", "k", "v w"}},
+		{`"SET" 'k' "it's" 'say "hi"'`, []string{"SET", "k", "it's", `say "hi"`}},
+		{`SET "k" "\\x41"`, []string{"SET", "k", `\x41`}},
+	}
+	for _, test := range tests {
+		args, err := SplitArgs(test.line)
+		if err != nil {
+			t.Errorf("SplitArgs(%q) failed: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("SplitArgs(%q) = %q, want %q", test.line, args, test.args)
+		}
+	}
+
+	for _, line := range []string{`GET "key`, `GET 'key`, `GET "a"b`, `GET 'a'b`} {
+		if _, err := SplitArgs(line); err == nil {
+			t.Errorf("SplitArgs(%q) should fail", line)
+		}
+	}
+}
@@ -9,9 +9,18 @@ import (
 	"strconv"
 )
 
+// preallocLimit caps how many elements or bytes are allocated up front from a
+// declared length, so a client announcing a huge value that never arrives
+// cannot make the parser allocate it.
+const preallocLimit = 64 * 1024
+
 // Parser represents a RESP protocol parser
 type Parser struct {
 	reader *bufio.Reader
+	// maxBulkLength limits bulk strings, blob errors and verbatim strings, 0 means no limit
+	maxBulkLength int64
+	// maxMultiBulkLength limits the element count of arrays, maps, sets, attributes and pushes, 0 means no limit
+	maxMultiBulkLength int64
 }
 
 // NewParser creates a new RESP parser from an io.Reader
@@ -21,6 +30,66 @@ func NewParser(r io.Reader) *Parser {
 	}
 }
 
+// SetMaxBulkLength limits the declared length of bulk strings, blob errors
+// and verbatim strings. Longer values fail with an error wrapping both
+// ErrInvalidBulkLength and ErrLengthLimit before any data is read. Zero
+// disables the limit.
+func (p *Parser) SetMaxBulkLength(n int64) {
+	p.maxBulkLength = n
+}
+
+// SetMaxMultiBulkLength limits the declared element count of arrays, maps,
+// sets, attributes and pushes. Larger aggregates fail with an error wrapping
+// ErrLengthLimit. Zero disables the limit.
+func (p *Parser) SetMaxMultiBulkLength(n int64) {
+	p.maxMultiBulkLength = n
+}
+
+// checkBulkLength rejects bulk lengths above the configured limit
+func (p *Parser) checkBulkLength(length int) error {
+	if p.maxBulkLength > 0 && int64(length) > p.maxBulkLength {
+		return fmt.Errorf("%w: %w (%d > %d)", ErrInvalidBulkLength, ErrLengthLimit, length, p.maxBulkLength)
+	}
+	return nil
+}
+
+// checkMultiBulkLength rejects aggregate lengths above the configured limit,
+// kind is the sentinel error of the aggregate type
+func (p *Parser) checkMultiBulkLength(kind error, length int) error {
+	if p.maxMultiBulkLength > 0 && int64(length) > p.maxMultiBulkLength {
+		return fmt.Errorf("%w: %w (%d > %d)", kind, ErrLengthLimit, length, p.maxMultiBulkLength)
+	}
+	return nil
+}
+
+// readBulk reads length bytes of bulk data. Small values are read into a
+// buffer of the exact size; larger ones grow the buffer as data arrives.
+func (p *Parser) readBulk(length int) ([]byte, error) {
+	if length <= preallocLimit {
+		data := make([]byte, length)
+		if _, err := io.ReadFull(p.reader, data); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrIncompleteMessage, err)
+		}
+		return data, nil
+	}
+	var buf bytes.Buffer
+	buf.Grow(preallocLimit)
+	if _, err := io.CopyN(&buf, p.reader, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("%w: %v", ErrIncompleteMessage, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Buffered returns the number of bytes already read from the underlying
+// reader but not yet parsed. A non-zero value means more pipelined input
+// is waiting and can be parsed without blocking on the connection.
+func (p *Parser) Buffered() int {
+	return p.reader.Buffered()
+}
+
 // Parse reads and parses a complete RESP value from the reader
 func (p *Parser) Parse() (Value, error) {
 	// Read the type byte
@@ -122,12 +191,14 @@ func (p *Parser) parseBulkString() (Value, error) {
 	if length < 0 {
 		return Value{}, fmt.Errorf("%w: negative bulk length %d", ErrInvalidBulkLength, length)
 	}
+	if err := p.checkBulkLength(length); err != nil {
+		return Value{}, err
+	}
 	
 	// Read the bulk string data
-	data := make([]byte, length)
-	_, err = io.ReadFull(p.reader, data)
+	data, err := p.readBulk(length)
 	if err != nil {
-		return Value{}, fmt.Errorf("%w: %v", ErrIncompleteMessage, err)
+		return Value{}, err
 	}
 	
 	// Read and discard CRLF
@@ -167,15 +238,18 @@ func (p *Parser) parseArray() (Value, error) {
 	if length < 0 {
 		return Value{}, fmt.Errorf("%w: negative array length %d", ErrInvalidArrayLength, length)
 	}
+	if err := p.checkMultiBulkLength(ErrInvalidArrayLength, length); err != nil {
+		return Value{}, err
+	}
 	
 	// Parse array elements
-	elements := make([]Value, length)
+	elements := make([]Value, 0, min(length, preallocLimit))
 	for i := 0; i < length; i++ {
 		val, err := p.Parse()
 		if err != nil {
 			return Value{}, err
 		}
-		elements[i] = val
+		elements = append(elements, val)
 	}
 	
 	return NewArray(elements), nil
@@ -300,11 +374,14 @@ func (p *Parser) parseBlobError() (Value, error) {
 		return Value{}, fmt.Errorf("%w: negative blob error length %d", ErrInvalidBulkLength, length)
 	}
 	
+	if err := p.checkBulkLength(length); err != nil {
+		return Value{}, err
+	}
+	
 	// Read the blob error data
-	data := make([]byte, length)
-	_, err = io.ReadFull(p.reader, data)
+	data, err := p.readBulk(length)
 	if err != nil {
-		return Value{}, fmt.Errorf("%w: %v", ErrIncompleteMessage, err)
+		return Value{}, err
 	}
 	
 	// Read and discard CRLF
@@ -341,11 +418,14 @@ func (p *Parser) parseVerbatimString() (Value, error) {
 		return Value{}, fmt.Errorf("%w: verbatim string length too short %d", ErrInvalidBulkLength, length)
 	}
 	
+	if err := p.checkBulkLength(length); err != nil {
+		return Value{}, err
+	}
+	
 	// Read the verbatim string data
-	data := make([]byte, length)
-	_, err = io.ReadFull(p.reader, data)
+	data, err := p.readBulk(length)
 	if err != nil {
-		return Value{}, fmt.Errorf("%w: %v", ErrIncompleteMessage, err)
+		return Value{}, err
 	}
 	
 	// Read and discard CRLF
@@ -393,9 +473,12 @@ func (p *Parser) parseMap() (Value, error) {
 	if length < 0 {
 		return Value{}, fmt.Errorf("%w: negative map length %d", ErrInvalidMapLength, length)
 	}
+	if err := p.checkMultiBulkLength(ErrInvalidMapLength, length); err != nil {
+		return Value{}, err
+	}
 	
 	// Parse map elements (key-value pairs)
-	items := make([]MapItem, length)
+	items := make([]MapItem, 0, min(length, preallocLimit))
 	for i := 0; i < length; i++ {
 		// Parse key
 		key, err := p.Parse()
@@ -409,7 +492,7 @@ func (p *Parser) parseMap() (Value, error) {
 			return Value{}, err
 		}
 		
-		items[i] = MapItem{Key: key, Value: val}
+		items = append(items, MapItem{Key: key, Value: val})
 	}
 	
 	return NewMap(items), nil
@@ -436,15 +519,18 @@ func (p *Parser) parseSet() (Value, error) {
 	if length < 0 {
 		return Value{}, fmt.Errorf("%w: negative set length %d", ErrInvalidSetLength, length)
 	}
+	if err := p.checkMultiBulkLength(ErrInvalidSetLength, length); err != nil {
+		return Value{}, err
+	}
 	
 	// Parse set elements
-	elements := make([]Value, length)
+	elements := make([]Value, 0, min(length, preallocLimit))
 	for i := 0; i < length; i++ {
 		val, err := p.Parse()
 		if err != nil {
 			return Value{}, err
 		}
-		elements[i] = val
+		elements = append(elements, val)
 	}
 	
 	return NewSet(elements), nil
@@ -471,9 +557,12 @@ func (p *Parser) parseAttribute() (Value, error) {
 	if length < 0 {
 		return Value{}, fmt.Errorf("%w: negative attribute length %d", ErrInvalidMapLength, length)
 	}
+	if err := p.checkMultiBulkLength(ErrInvalidMapLength, length); err != nil {
+		return Value{}, err
+	}
 	
 	// Parse attribute elements (key-value pairs)
-	items := make([]MapItem, length)
+	items := make([]MapItem, 0, min(length, preallocLimit))
 	for i := 0; i < length; i++ {
 		// Parse key
 		key, err := p.Parse()
@@ -487,7 +576,7 @@ func (p *Parser) parseAttribute() (Value, error) {
 			return Value{}, err
 		}
 		
-		items[i] = MapItem{Key: key, Value: val}
+		items = append(items, MapItem{Key: key, Value: val})
 	}
 	
 	return NewAttribute(items), nil
@@ -514,15 +603,18 @@ func (p *Parser) parsePush() (Value, error) {
 	if length < 0 {
 		return Value{}, fmt.Errorf("%w: negative push length %d", ErrInvalidArrayLength, length)
 	}
+	if err := p.checkMultiBulkLength(ErrInvalidArrayLength, length); err != nil {
+		return Value{}, err
+	}
 	
 	// Parse push elements
-	elements := make([]Value, length)
+	elements := make([]Value, 0, min(length, preallocLimit))
 	for i := 0; i < length; i++ {
 		val, err := p.Parse()
 		if err != nil {
 			return Value{}, err
 		}
-		elements[i] = val
+		elements = append(elements, val)
 	}
 	
 	return NewPush(elements), nil
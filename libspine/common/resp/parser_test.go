@@ -2,7 +2,9 @@ package resp
 
 import (
 	"bytes"
+	"errors"
 	"reflect"
+	"runtime"
 	"testing"
 )
 
@@ -349,3 +351,80 @@ func TestParseFromBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLengthLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		kind  error
+	}{
+		{"bulk string", "$2147483648\r\n", ErrInvalidBulkLength},
+		{"blob error", "!2147483648\r\n", ErrInvalidBulkLength},
+		{"verbatim string", "=2147483648\r\n", ErrInvalidBulkLength},
+		{"array", "*2147483648\r\n", ErrInvalidArrayLength},
+		{"nested bulk string", "*2\r\n$3\r\nSET\r\n$2147483648\r\n", ErrInvalidBulkLength},
+		{"map", "%2000\r\n", ErrInvalidMapLength},
+		{"set", "~2000\r\n", ErrInvalidSetLength},
+		{"push", ">2000\r\n", ErrInvalidArrayLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser(bytes.NewReader([]byte(tt.input)))
+			parser.SetMaxBulkLength(512 * 1024 * 1024)
+			parser.SetMaxMultiBulkLength(1024)
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			_, err := parser.Parse()
+			runtime.ReadMemStats(&after)
+
+			if !errors.Is(err, ErrLengthLimit) || !errors.Is(err, tt.kind) {
+				t.Fatalf("Parse() error = %v, want %v and %v", err, ErrLengthLimit, tt.kind)
+			}
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1024*1024 {
+				t.Errorf("Parse() allocated %d bytes before rejecting the value", allocated)
+			}
+		})
+	}
+
+	// 限制以内的值正常解析
+	parser := NewParser(bytes.NewReader([]byte("*2\r\n$3\r\nGET\r\n$1\r\nk\r\n")))
+	parser.SetMaxBulkLength(3)
+	parser.SetMaxMultiBulkLength(2)
+	got, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, NewArray([]Value{NewBulkString([]byte("GET")), NewBulkString([]byte("k"))})) {
+		t.Errorf("Parse() = %v", got)
+	}
+}
+
+func TestParseTruncatedLargeBulkString(t *testing.T) {
+	// 没有限制时，声明了很大长度但数据不足的值也不会按声明的长度分配内存
+	parser := NewParser(bytes.NewReader([]byte("$2147483648\r\nshort")))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := parser.Parse()
+	runtime.ReadMemStats(&after)
+
+	if !errors.Is(err, ErrIncompleteMessage) {
+		t.Fatalf("Parse() error = %v, want %v", err, ErrIncompleteMessage)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1024*1024 {
+		t.Errorf("Parse() allocated %d bytes for a truncated value", allocated)
+	}
+
+	// 超过预分配大小的值按实际数据读取
+	data := bytes.Repeat([]byte("x"), preallocLimit*3+1)
+	input := append([]byte("$196609\r\n"), data...)
+	input = append(input, "\r\n"...)
+	got, err := NewParser(bytes.NewReader(input)).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !bytes.Equal(got.Bulk, data) {
+		t.Errorf("Parse() returned %d bytes, want %d", len(got.Bulk), len(data))
+	}
+}
@@ -142,19 +142,21 @@ func (s *Serializer) writeNullBulkString() error {
 
 // writeBulkString writes a RESP bulk string
 func (s *Serializer) writeBulkString(data []byte) error {
-	if _, err := s.writer.Write([]byte{TypeBulkString}); err != nil {
+	// WriteByte/WriteString avoid allocating a slice per call, which adds
+	// up when a large array of bulk strings is streamed
+	if err := s.writer.WriteByte(TypeBulkString); err != nil {
 		return err
 	}
 	if _, err := s.writer.WriteString(strconv.Itoa(len(data))); err != nil {
 		return err
 	}
-	if _, err := s.writer.Write([]byte{'\r', '\n'}); err != nil {
+	if _, err := s.writer.WriteString("\r\n"); err != nil {
 		return err
 	}
 	if _, err := s.writer.Write(data); err != nil {
 		return err
 	}
-	if _, err := s.writer.Write([]byte{'\r', '\n'}); err != nil {
+	if _, err := s.writer.WriteString("\r\n"); err != nil {
 		return err
 	}
 	return nil
@@ -176,13 +178,7 @@ func (s *Serializer) writeNullArray() error {
 
 // writeArray writes a RESP array
 func (s *Serializer) writeArray(array []Value) error {
-	if _, err := s.writer.Write([]byte{TypeArray}); err != nil {
-		return err
-	}
-	if _, err := s.writer.WriteString(strconv.Itoa(len(array))); err != nil {
-		return err
-	}
-	if _, err := s.writer.Write([]byte{'\r', '\n'}); err != nil {
+	if err := s.WriteArrayHeader(len(array)); err != nil {
 		return err
 	}
 	
@@ -195,6 +191,20 @@ func (s *Serializer) writeArray(array []Value) error {
 	return nil
 }
 
+// WriteArrayHeader writes only the header of an array with n elements.
+// The caller must follow it with exactly n serialized values, which lets
+// large replies be streamed without building the whole []Value first.
+func (s *Serializer) WriteArrayHeader(n int) error {
+	if err := s.writer.WriteByte(TypeArray); err != nil {
+		return err
+	}
+	if _, err := s.writer.WriteString(strconv.Itoa(n)); err != nil {
+		return err
+	}
+	_, err := s.writer.WriteString("\r\n")
+	return err
+}
+
 // SerializeToBytes serializes a RESP value to a byte slice
 func SerializeToBytes(v Value) ([]byte, error) {
 	var buf io.Writer = &bytesWriter{bytes: make([]byte, 0, 64)}
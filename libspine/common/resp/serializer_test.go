@@ -2,6 +2,7 @@ package resp
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 )
 
@@ -395,7 +396,29 @@ func TestRespWriter(t *testing.T) {
 			fn: func(w *RespWriter) error {
 				return w.WriteWrongNumberOfArgumentsError("GET")
 			},
-			expected: []byte("-ERR wrong number of arguments for GET command\r\n"),
+			expected: []byte("-ERR wrong number of arguments for 'get' command\r\n"),
+			wantErr:  false,
+		},
+		{
+			name: "write streamed array",
+			fn: func(w *RespWriter) error {
+				if err := w.WriteArrayHeader(2); err != nil {
+					return err
+				}
+				if err := w.WriteBulkStringString("a"); err != nil {
+					return err
+				}
+				return w.WriteInteger(1)
+			},
+			expected: []byte("*2\r\n$1\r\na\r\n:1\r\n"),
+			wantErr:  false,
+		},
+		{
+			name: "write empty streamed array",
+			fn: func(w *RespWriter) error {
+				return w.WriteArrayHeader(0)
+			},
+			expected: []byte("*0\r\n"),
 			wantErr:  false,
 		},
 	}
@@ -416,6 +439,45 @@ func TestRespWriter(t *testing.T) {
 	}
 }
 
+func TestRespWriterStreamsLargeArray(t *testing.T) {
+	const n = 10000
+	entries := make([][]byte, n)
+	for i := range entries {
+		entries[i] = []byte(fmt.Sprintf("entry-%d", i))
+	}
+
+	var buf bytes.Buffer
+	writer := NewRespWriter(&testWriter{&buf})
+	writer.SetDeferFlush(true)
+	stream := func() {
+		buf.Reset()
+		writer.WriteArrayHeader(n)
+		for _, entry := range entries {
+			writer.WriteBulkString(entry)
+		}
+		writer.Flush()
+	}
+
+	// Streaming elements one by one never builds a []Value for the whole
+	// reply, so the number of allocations does not grow with the element count
+	if allocs := testing.AllocsPerRun(5, stream); allocs > 10 {
+		t.Errorf("streaming %d elements made %.0f allocations, want at most 10", n, allocs)
+	}
+
+	value, err := ParseFromBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseFromBytes() error = %v", err)
+	}
+	if len(value.Array) != n {
+		t.Fatalf("got %d elements, want %d", len(value.Array), n)
+	}
+	for i, item := range value.Array {
+		if !bytes.Equal(item.Bulk, entries[i]) {
+			t.Fatalf("element %d = %q, want %q", i, item.Bulk, entries[i])
+		}
+	}
+}
+
 // testWriter implements transport.Writer for testing
 type testWriter struct {
 	buf *bytes.Buffer
@@ -38,6 +38,8 @@ var (
 	ErrInvalidMapLength    = errors.New("resp: invalid map length")
 	ErrInvalidSetLength    = errors.New("resp: invalid set length")
 	ErrInvalidFormat       = errors.New("resp: invalid format")
+	ErrLengthLimit         = errors.New("resp: length exceeds limit")
+	ErrUnbalancedQuotes    = errors.New("resp: unbalanced quotes")
 	ErrNil                 = errors.New("resp: nil value")
 )
 
@@ -0,0 +1,195 @@
+package libspine
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// LoadConfigFile 从 TOML 配置文件读取服务器配置，覆盖 config 中对应的字段，并记录为 CONFIG REWRITE 的目标文件。
+// redis 模式的参数使用 CONFIG GET 的参数名，其他选项使用 cmd/spine 的命令行参数名，例如：
+//
+//	listen = ["tcp://:6379", "local:///tmp/spine.sock"]
+//	mode = "redis"
+//	snapshot = "dump.spine"
+//	shutdown-timeout = "30s"
+//	requirepass = "secret"
+//	timeout = 300
+//	appendonly = "yes"
+//	save = "3600 1 300 100"
+//	unixsocketperm = 770
+//
+// 时长选项可以写成 Go 的时长字符串，也可以写成秒数；timeout 与 Redis 一致只接受秒数
+func LoadConfigFile(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]interface{})
+	if err := toml.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	// appendfilename 只在 appendonly 开启时使用，与键的先后顺序无关
+	appendOnly := false
+	appendFilename := "appendonly.aof"
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := loadConfigFileKey(config, key, values[key], &appendOnly, &appendFilename); err != nil {
+			return fmt.Errorf("%s: %s: %v", path, key, err)
+		}
+	}
+
+	if appendOnly {
+		config.AOFPath = appendFilename
+	} else {
+		config.AOFPath = ""
+	}
+	config.ConfigFile = path
+	return nil
+}
+
+// loadConfigFileKey 把配置文件中的一个键应用到 config
+func loadConfigFileKey(config *Config, key string, raw interface{}, appendOnly *bool, appendFilename *string) error {
+	if key == "listen" {
+		addrs, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("must be an array of listen addresses")
+		}
+		listenConfigs := make([]ListenConfig, 0, len(addrs))
+		for _, addr := range addrs {
+			s, ok := addr.(string)
+			if !ok {
+				return fmt.Errorf("must be an array of listen addresses")
+			}
+			listenConfig, err := ParseListenAddress(s)
+			if err != nil {
+				return err
+			}
+			listenConfigs = append(listenConfigs, listenConfig)
+		}
+		config.ListenConfigs = listenConfigs
+		return nil
+	}
+
+	value, err := configFileValue(raw)
+	if err != nil {
+		return err
+	}
+
+	switch key {
+	case "mode":
+		config.ServerMode = value
+	case "static":
+		config.StaticPath = value
+	case "enable-debug-command":
+		config.EnableDebugCommand, err = configFileBool(value)
+	case "metrics":
+		config.EnableMetrics, err = configFileBool(value)
+	case "snapshot":
+		config.SnapshotPath = value
+	case "shutdown-timeout":
+		config.ShutdownTimeout, err = configFileDuration(value)
+	case "history-limit":
+		config.ChatHistoryLimit, err = strconv.Atoi(value)
+	case "active-expire-interval":
+		config.ActiveExpireInterval, err = configFileDuration(value)
+	case "active-expire-samples":
+		config.ActiveExpireSamples, err = strconv.Atoi(value)
+	case "appendonly":
+		*appendOnly, err = configFileBool(value)
+	case "appendfilename":
+		*appendFilename = value
+	case "appendfsync":
+		config.AOFFsync = value
+	case "masterauth":
+		config.MasterAuth = value
+	case "maxmemory":
+		config.MaxMemory = value
+	case "maxmemory-policy":
+		config.MaxMemoryPolicy = value
+	case "proto-max-bulk-len":
+		config.ProtoMaxBulkLen = value
+	case "proto-max-multibulk-len":
+		config.ProtoMaxMultiBulkLen = value
+	case "replica-read-only":
+		config.ReplicaReadOnly = value
+	case "requirepass":
+		config.RequirePass = value
+	case "save":
+		config.Save = value
+	case "slowlog-log-slower-than":
+		config.SlowlogLogSlowerThan, err = strconv.ParseInt(value, 10, 64)
+	case "slowlog-max-len":
+		config.SlowlogMaxLen, err = strconv.Atoi(value)
+	case "reuseport":
+		config.ReusePort, err = configFileBool(value)
+	case "proxy-protocol":
+		config.ProxyProtocol, err = configFileBool(value)
+	case "unixsocketperm":
+		config.UnixSocketPerm, err = ParseSocketPerm(value)
+	case "unixsocketgroup":
+		config.UnixSocketGroup = value
+	case "timeout":
+		seconds, parseErr := strconv.ParseInt(value, 10, 64)
+		if parseErr != nil || seconds < 0 {
+			return fmt.Errorf("must be a non-negative number of seconds")
+		}
+		config.Timeout = time.Duration(seconds) * time.Second
+	default:
+		return fmt.Errorf("unknown config key")
+	}
+	if _, ok := err.(*strconv.NumError); ok {
+		return fmt.Errorf("must be an integer")
+	}
+	return err
+}
+
+// configFileValue 把配置文件中的标量值转换为 CONFIG SET 使用的字符串形式
+func configFileValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case bool:
+		if v {
+			return "yes", nil
+		}
+		return "no", nil
+	}
+	return "", fmt.Errorf("unsupported value %v", value)
+}
+
+// configFileBool 解析 yes/no 或 true/false
+func configFileBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes", "true":
+		return true, nil
+	case "no", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("must be 'yes' or 'no'")
+}
+
+// configFileDuration 解析时长，整数表示秒数
+func configFileDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be a duration such as 10s or a number of seconds")
+	}
+	return duration, nil
+}
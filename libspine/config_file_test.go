@@ -0,0 +1,102 @@
+package libspine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/client"
+)
+
+// startRedisServer 启动只监听本地 TCP 端口的 redis 模式服务器，返回连接到它的客户端
+func startRedisServer(t *testing.T, config *Config) (*Server, *client.Client) {
+	t.Helper()
+	port := freePort(t)
+	config.ListenConfigs = []ListenConfig{{Schema: "tcp", Host: "127.0.0.1", Port: port}}
+	config.ServerMode = "redis"
+	server := NewServer(config)
+	require.NoError(t, server.Start())
+
+	c, err := client.Dial(context.Background(), "tcp", "127.0.0.1:"+port)
+	require.NoError(t, err)
+	return server, c
+}
+
+// configValues 通过 CONFIG GET * 读取全部配置参数
+func configValues(t *testing.T, c *client.Client) map[string]string {
+	t.Helper()
+	reply, err := c.Do(context.Background(), "CONFIG", "GET", "*")
+	require.NoError(t, err)
+	values := make(map[string]string)
+	for i := 0; i+1 < len(reply.Array); i += 2 {
+		values[string(reply.Array[i].Bulk)] = string(reply.Array[i+1].Bulk)
+	}
+	return values
+}
+
+func TestConfigRewriteReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "spine.toml")
+	require.NoError(t, os.WriteFile(path, []byte("timeout = 60\n"), 0644))
+
+	config := &Config{}
+	require.NoError(t, LoadConfigFile(path, config))
+	assert.Equal(t, time.Minute, config.Timeout)
+	server, c := startRedisServer(t, config)
+
+	ctx := context.Background()
+	_, err := c.Do(ctx, "CONFIG", "SET",
+		"maxmemory", "10mb",
+		"maxmemory-policy", "allkeys-lru",
+		"proto-max-bulk-len", "2mb",
+		"proto-max-multibulk-len", "4096",
+		"replica-read-only", "no",
+		"save", "3600 1 300 100",
+		"slowlog-log-slower-than", "5000",
+		"slowlog-max-len", "64",
+		"appendfsync", "always",
+		"timeout", "120",
+		"requirepass", "secret",
+	)
+	require.NoError(t, err)
+	require.NoError(t, c.Auth(ctx, "", "secret"))
+	_, err = c.Do(ctx, "CONFIG", "REWRITE")
+	require.NoError(t, err)
+	want := configValues(t, c)
+	c.Close()
+	require.NoError(t, server.Stop())
+
+	reloaded := &Config{}
+	require.NoError(t, LoadConfigFile(path, reloaded))
+	assert.Equal(t, path, reloaded.ConfigFile)
+	assert.Equal(t, "secret", reloaded.RequirePass)
+	assert.Equal(t, 2*time.Minute, reloaded.Timeout)
+	server, c = startRedisServer(t, reloaded)
+	defer server.Stop()
+	defer c.Close()
+	require.NoError(t, c.Auth(ctx, "", "secret"))
+	assert.Equal(t, want, configValues(t, c))
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"unknown key":     "nosuchparam = 1\n",
+		"bad timeout":     "timeout = -1\n",
+		"bad integer":     "slowlog-max-len = 'many'\n",
+		"bad appendonly":  "appendonly = 'maybe'\n",
+		"bad socket perm": "unixsocketperm = 999\n",
+		"not toml":        "timeout = \n",
+	} {
+		path := filepath.Join(dir, "spine.toml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		assert.Error(t, LoadConfigFile(path, &Config{}), name)
+	}
+
+	assert.Error(t, LoadConfigFile(filepath.Join(dir, "missing.toml"), &Config{}))
+}
@@ -14,12 +14,28 @@ import (
 type ChatMessage struct {
 	ID        string    `json:"id"`
 	User      string    `json:"user"`
+	To        string    `json:"to,omitempty"` // 私信接收者，为空表示广播
 	Message   string    `json:"message"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// 在线状态事件类型
+const (
+	ChatEventJoin   = "join"
+	ChatEventLeave  = "leave"
+	ChatEventTyping = "typing"
+)
+
+// ChatEvent 在线状态事件，加入、离开和正在输入时广播给房间内的其他连接
+type ChatEvent struct {
+	Event     string    `json:"event"`
+	User      string    `json:"user"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // ChatRequest 聊天请求结构
 type ChatRequest struct {
+	ID     string      `json:"id,omitempty"`
 	Method string      `json:"method"`
 	Path   string      `json:"path"`
 	Data   interface{} `json:"data"`
@@ -27,6 +43,7 @@ type ChatRequest struct {
 
 // ChatResponse 聊天响应结构
 type ChatResponse struct {
+	ID     string      `json:"id,omitempty"` // 对应请求的 ID，广播消息为空
 	Status int         `json:"status"`
 	Data   interface{} `json:"data"`
 	Error  string      `json:"error"`
@@ -34,9 +51,11 @@ type ChatResponse struct {
 
 // ChatHandler 聊天处理器
 type ChatHandler struct {
-	messages      []*ChatMessage
+	messages      *chatHistory // 最近的消息，超过上限时丢弃最旧的
 	mu            sync.RWMutex
 	activeConns   map[string]bool // connectionID -> active
+	users         map[string]string // 用户名 -> connectionID，用于私信投递
+	connUsers     map[string]string // connectionID -> 用户名
 	connectionsMu sync.RWMutex
 	wsTransport   interface{} // WebSocket transport for broadcasting
 	staticPath    string      // 静态文件路径
@@ -45,8 +64,10 @@ type ChatHandler struct {
 // NewChatHandler 创建新的聊天处理器
 func NewChatHandler() *ChatHandler {
 	return &ChatHandler{
-		messages:    make([]*ChatMessage, 0),
+		messages:    newChatHistory(DefaultChatHistoryLimit),
 		activeConns: make(map[string]bool),
+		users:       make(map[string]string),
+		connUsers:   make(map[string]string),
 	}
 }
 
@@ -60,6 +81,16 @@ func (h *ChatHandler) SetStaticPath(path string) {
 	h.staticPath = path
 }
 
+// SetHistoryLimit 设置保留的历史消息条数，超出时丢弃最旧的消息，0 表示使用默认值
+func (h *ChatHandler) SetHistoryLimit(limit int) {
+	if limit <= 0 {
+		limit = DefaultChatHistoryLimit
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages.resize(limit)
+}
+
 // Handle 处理聊天请求
 func (h *ChatHandler) Handle(ctx *transport.Context, req transport.Reader, res transport.Writer) error {
 	// 使用 ConnInfo 中的 Reader 和 Writer
@@ -80,9 +111,7 @@ func (h *ChatHandler) Handle(ctx *transport.Context, req transport.Reader, res t
 		if err != nil {
 			// 连接关闭或读取错误，清理连接并退出
 			if ctx.ConnInfo != nil {
-				h.connectionsMu.Lock()
-				delete(h.activeConns, ctx.ConnInfo.ID)
-				h.connectionsMu.Unlock()
+				h.leaveRoom(ctx)
 				log.Printf("Connection %s closed, removed from active connections", ctx.ConnInfo.ID)
 			}
 			// 如果是 EOF，表示正常结束，不返回错误
@@ -98,7 +127,7 @@ func (h *ChatHandler) Handle(ctx *transport.Context, req transport.Reader, res t
 		log.Printf("Received request: %s", string(data))
 		if err := json.Unmarshal(data, &chatReq); err != nil {
 			// 发送错误响应但不关闭连接
-			h.writeError(res, "Invalid request format", 400)
+			h.writeError(res, "", "Invalid request format", 400)
 			continue
 		}
 
@@ -113,14 +142,16 @@ func (h *ChatHandler) Handle(ctx *transport.Context, req transport.Reader, res t
 			handleErr = h.handleJoin(ctx, req, res, &chatReq)
 		case "LEAVE":
 			handleErr = h.handleLeave(ctx, req, res, &chatReq)
+		case "TYPING":
+			handleErr = h.handleTyping(ctx, req, res, &chatReq)
 		case "PING":
 			// 处理心跳请求
-			handleErr = h.writeSuccess(res, map[string]interface{}{
+			handleErr = h.writeSuccess(res, chatReq.ID, map[string]interface{}{
 				"status":  "success",
 				"message": "pong",
 			})
 		default:
-			handleErr = h.writeError(res, "Method not allowed", 405)
+			handleErr = h.writeError(res, chatReq.ID, "Method not allowed", 405)
 		}
 
 		if handleErr != nil {
@@ -134,36 +165,43 @@ func (h *ChatHandler) handlePostMessage(ctx *transport.Context, req transport.Re
 	// 解析消息数据
 	dataBytes, err := json.Marshal(chatReq.Data)
 	if err != nil {
-		return h.writeError(res, "Invalid message data", 400)
+		return h.writeError(res, chatReq.ID, "Invalid message data", 400)
 	}
 
 	var msgData map[string]interface{}
 	if err := json.Unmarshal(dataBytes, &msgData); err != nil {
-		return h.writeError(res, "Invalid message format", 400)
+		return h.writeError(res, chatReq.ID, "Invalid message format", 400)
 	}
 
 	user, _ := msgData["user"].(string)
 	message, _ := msgData["message"].(string)
+	to, _ := msgData["to"].(string)
 
 	if user == "" || message == "" {
-		return h.writeError(res, "Missing required fields", 400)
+		return h.writeError(res, chatReq.ID, "Missing required fields", 400)
 	}
 
 	msg := &ChatMessage{
 		ID:        generateID(),
 		User:      user,
+		To:        to,
 		Message:   message,
 		Timestamp: time.Now(),
 	}
 
+	// 带 to 字段的消息是私信，只投递给接收者且不进入历史记录
+	if to != "" {
+		return h.sendDirectMessage(ctx, res, chatReq, msg)
+	}
+
 	h.mu.Lock()
-	h.messages = append(h.messages, msg)
+	h.messages.add(msg)
 	h.mu.Unlock()
 
 	// 广播消息给所有活跃连接
 	h.broadcastToAll(ctx, msg)
 
-	return h.writeSuccess(res, map[string]interface{}{
+	return h.writeSuccess(res, chatReq.ID, map[string]interface{}{
 		"status":  "success",
 		"message": "Message sent",
 	})
@@ -172,28 +210,40 @@ func (h *ChatHandler) handlePostMessage(ctx *transport.Context, req transport.Re
 // handleGetMessages 处理获取消息 - 返回最新的广播消息
 func (h *ChatHandler) handleGetMessages(ctx *transport.Context, req transport.Reader, res transport.Writer, chatReq *ChatRequest) error {
 	h.mu.RLock()
-	messages := make([]*ChatMessage, len(h.messages))
-	copy(messages, h.messages)
+	messages := h.messages.list()
 	h.mu.RUnlock()
 
-	// 返回所有消息
-	return h.writeSuccess(res, messages)
+	// 返回保留的全部历史消息
+	return h.writeSuccess(res, chatReq.ID, messages)
 }
 
 // handleJoin 处理加入聊天
 func (h *ChatHandler) handleJoin(ctx *transport.Context, req transport.Reader, res transport.Writer, chatReq *ChatRequest) error {
 	// 使用连接ID而不是Writer
 	if ctx.ConnInfo == nil {
-		return h.writeError(res, "Connection info not available", 400)
+		return h.writeError(res, chatReq.ID, "Connection info not available", 400)
 	}
 
 	connID := ctx.ConnInfo.ID
+	user := chatRequestUser(chatReq)
 
 	h.connectionsMu.Lock()
+	alreadyJoined := h.activeConns[connID]
 	h.activeConns[connID] = true
+	if user != "" {
+		// 同名用户重新加入（例如断线重连）时以最新的连接为准
+		h.unbindUserLocked(connID)
+		h.users[user] = connID
+		h.connUsers[connID] = user
+	}
 	h.connectionsMu.Unlock()
 
-	return h.writeSuccess(res, map[string]interface{}{
+	// 通知房间内已有的成员
+	if !alreadyJoined && user != "" {
+		h.broadcastEvent(ctx, ChatEventJoin, user)
+	}
+
+	return h.writeSuccess(res, chatReq.ID, map[string]interface{}{
 		"status":  "success",
 		"message": "Joined chat",
 	})
@@ -203,23 +253,125 @@ func (h *ChatHandler) handleJoin(ctx *transport.Context, req transport.Reader, r
 func (h *ChatHandler) handleLeave(ctx *transport.Context, req transport.Reader, res transport.Writer, chatReq *ChatRequest) error {
 	// 使用连接ID而不是Writer
 	if ctx.ConnInfo == nil {
-		return h.writeError(res, "Connection info not available", 400)
+		return h.writeError(res, chatReq.ID, "Connection info not available", 400)
 	}
 
+	h.leaveRoom(ctx)
+
+	return h.writeSuccess(res, chatReq.ID, map[string]interface{}{
+		"status":  "success",
+		"message": "Left chat",
+	})
+}
+
+// leaveRoom 将连接移出房间，已加入的具名用户离开时通知其他成员
+func (h *ChatHandler) leaveRoom(ctx *transport.Context) {
 	connID := ctx.ConnInfo.ID
 
 	h.connectionsMu.Lock()
+	joined := h.activeConns[connID]
+	user := h.connUsers[connID]
 	delete(h.activeConns, connID)
+	h.unbindUserLocked(connID)
 	h.connectionsMu.Unlock()
 
-	return h.writeSuccess(res, map[string]interface{}{
+	if joined && user != "" {
+		h.broadcastEvent(ctx, ChatEventLeave, user)
+	}
+}
+
+// handleTyping 处理正在输入提示，只广播给其他成员，不保存
+func (h *ChatHandler) handleTyping(ctx *transport.Context, req transport.Reader, res transport.Writer, chatReq *ChatRequest) error {
+	if ctx.ConnInfo == nil {
+		return h.writeError(res, chatReq.ID, "Connection info not available", 400)
+	}
+
+	h.connectionsMu.RLock()
+	joined := h.activeConns[ctx.ConnInfo.ID]
+	user := h.connUsers[ctx.ConnInfo.ID]
+	h.connectionsMu.RUnlock()
+
+	if !joined || user == "" {
+		return h.writeError(res, chatReq.ID, "Join the chat with a user name first", 400)
+	}
+
+	h.broadcastEvent(ctx, ChatEventTyping, user)
+	return h.writeSuccess(res, chatReq.ID, map[string]interface{}{
 		"status":  "success",
-		"message": "Left chat",
+		"message": "Typing sent",
+	})
+}
+
+// broadcastEvent 向房间内除当前连接外的所有成员广播在线状态事件
+func (h *ChatHandler) broadcastEvent(ctx *transport.Context, event, user string) {
+	h.broadcast(ctx, &ChatEvent{
+		Event:     event,
+		User:      user,
+		Timestamp: time.Now(),
+	}, ctx.ConnInfo.ID)
+}
+
+// chatRequestUser 从请求数据中读取 user 字段
+func chatRequestUser(chatReq *ChatRequest) string {
+	data, ok := chatReq.Data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	user, _ := data["user"].(string)
+	return user
+}
+
+// unbindUserLocked 解除连接与用户名的绑定，调用方必须持有 connectionsMu
+func (h *ChatHandler) unbindUserLocked(connID string) {
+	user, ok := h.connUsers[connID]
+	if !ok {
+		return
+	}
+	delete(h.connUsers, connID)
+	if h.users[user] == connID {
+		delete(h.users, user)
+	}
+}
+
+// sendDirectMessage 将私信只发送给接收者所在的连接
+func (h *ChatHandler) sendDirectMessage(ctx *transport.Context, res transport.Writer, chatReq *ChatRequest, msg *ChatMessage) error {
+	h.connectionsMu.RLock()
+	connID, ok := h.users[msg.To]
+	h.connectionsMu.RUnlock()
+
+	var connInfo *transport.ConnInfo
+	if ok && ctx != nil && ctx.ConnectionManager != nil {
+		connInfo, ok = ctx.ConnectionManager.GetConnection(connID)
+	}
+	if !ok || connInfo.Writer == nil {
+		return h.writeError(res, chatReq.ID, "User not found", 404)
+	}
+
+	data, err := json.Marshal(&ChatResponse{
+		Status: 200,
+		Data:   msg,
+	})
+	if err != nil {
+		return h.writeError(res, chatReq.ID, "Failed to marshal message", 500)
+	}
+	if _, err := connInfo.Writer.Write(append(data, '\n')); err != nil {
+		log.Printf("sendDirectMessage: Failed to write to connection %s: %v", connID, err)
+		return h.writeError(res, chatReq.ID, "Failed to deliver message", 500)
+	}
+
+	return h.writeSuccess(res, chatReq.ID, map[string]interface{}{
+		"status":  "success",
+		"message": "Message sent",
 	})
 }
 
 // broadcastToAll 使用ConnectionManager向所有活跃连接广播消息
 func (h *ChatHandler) broadcastToAll(ctx *transport.Context, msg *ChatMessage) {
+	h.broadcast(ctx, msg, "")
+}
+
+// broadcast 向所有活跃连接广播数据，exclude 不为空时跳过该连接
+func (h *ChatHandler) broadcast(ctx *transport.Context, payload interface{}, exclude string) {
 	if ctx == nil || ctx.ConnectionManager == nil {
 		return
 	}
@@ -227,21 +379,23 @@ func (h *ChatHandler) broadcastToAll(ctx *transport.Context, msg *ChatMessage) {
 	h.connectionsMu.RLock()
 	activeConnIDs := make([]string, 0, len(h.activeConns))
 	for connID := range h.activeConns {
-		activeConnIDs = append(activeConnIDs, connID)
+		if connID != exclude {
+			activeConnIDs = append(activeConnIDs, connID)
+		}
 	}
 	h.connectionsMu.RUnlock()
 
 	response := &ChatResponse{
 		Status: 200,
-		Data:   msg,
+		Data:   payload,
 	}
 
 	data, err := json.Marshal(response)
 	if err != nil {
-		log.Printf("broadcastToAll: Error marshaling response: %v", err)
+		log.Printf("broadcast: Error marshaling response: %v", err)
 		return
 	}
-	log.Printf("broadcastToAll: Broadcasting JSON message: %s", string(data))
+	log.Printf("broadcast: Broadcasting JSON message: %s", string(data))
 
 	// 向所有活跃连接广播消息
 	for _, connID := range activeConnIDs {
@@ -251,13 +405,13 @@ func (h *ChatHandler) broadcastToAll(ctx *transport.Context, msg *ChatMessage) {
 				dataWithNewline := append(data, '\n')
 				// 立即写入并刷新，确保消息被发送
 				if _, err := connInfo.Writer.Write(dataWithNewline); err != nil {
-					log.Printf("broadcastToAll: Failed to write to connection %s: %v", connID, err)
+					log.Printf("broadcast: Failed to write to connection %s: %v", connID, err)
 					// 如果写入失败，从活跃连接中移除该连接
 					h.connectionsMu.Lock()
 					delete(h.activeConns, connID)
 					h.connectionsMu.Unlock()
 				} else {
-					log.Printf("broadcastToAll: Successfully sent message to connection %s", connID)
+					log.Printf("broadcast: Successfully sent message to connection %s", connID)
 				}
 			}
 		}
@@ -272,15 +426,17 @@ func (h *ChatHandler) broadcastToAll(ctx *transport.Context, msg *ChatMessage) {
 }
 
 // writeSuccess 写入成功响应
-func (h *ChatHandler) writeSuccess(res transport.Writer, data interface{}) error {
+// id 为对应请求的 ID，客户端据此把回复与请求对应起来
+func (h *ChatHandler) writeSuccess(res transport.Writer, id string, data interface{}) error {
 	response := &ChatResponse{
+		ID:     id,
 		Status: 200,
 		Data:   data,
 	}
 
 	respData, err := json.Marshal(response)
 	if err != nil {
-		return h.writeError(res, "Failed to marshal response", 500)
+		return h.writeError(res, id, "Failed to marshal response", 500)
 	}
 
 	// 为 JSONL 协议添加换行符
@@ -292,8 +448,9 @@ func (h *ChatHandler) writeSuccess(res transport.Writer, data interface{}) error
 }
 
 // writeError 写入错误响应
-func (h *ChatHandler) writeError(res transport.Writer, message string, status int) error {
+func (h *ChatHandler) writeError(res transport.Writer, id string, message string, status int) error {
 	response := &ChatResponse{
+		ID:     id,
 		Status: status,
 		Error:  message,
 	}
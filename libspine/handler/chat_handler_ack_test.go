@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"spine-go/libspine/transport"
+	"testing"
+)
+
+func TestChatHandler_AckCarriesRequestID(t *testing.T) {
+	handler := NewChatHandler()
+	helpers := NewTestHelpers()
+	ctx := helpers.CreateTestContext()
+
+	requests := []*transport.Request{
+		helpers.CreateTestRequest("JOIN", "/chat", map[string]interface{}{"user": "alice"}),
+		helpers.CreateTestRequest("POST", "/chat", helpers.CreateChatMessage("alice", "hello")),
+		helpers.CreateTestRequest("LEAVE", "/chat", nil),
+		helpers.CreateTestRequest("BOGUS", "/chat", nil),
+	}
+	ids := []string{"join-1", "post-42", "leave-7", "bogus-3"}
+	for i, request := range requests {
+		request.ID = ids[i]
+	}
+
+	writer := NewMockWriter()
+	if err := handler.Handle(ctx, NewMockReaderFromRequests(requests), writer); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	responses := writer.GetResponses()
+	if len(responses) != len(requests) {
+		t.Fatalf("Expected %d responses, got %d", len(requests), len(responses))
+	}
+	for i, data := range responses {
+		var response ChatResponse
+		if err := json.Unmarshal(data, &response); err != nil {
+			t.Fatalf("Invalid response %q: %v", data, err)
+		}
+		if response.ID != ids[i] {
+			t.Errorf("Response %d: expected id '%s', got '%s'", i, ids[i], response.ID)
+		}
+	}
+
+	var last ChatResponse
+	json.Unmarshal(responses[3], &last)
+	if last.Status != 405 {
+		t.Errorf("Expected status 405 for unknown method, got %d", last.Status)
+	}
+}
+
+func TestChatHandler_BroadcastHasNoRequestID(t *testing.T) {
+	handler := NewChatHandler()
+	manager := transport.NewConnectionManager()
+
+	alice := joinChatSession(t, handler, manager, "alice")
+	bob := joinChatSession(t, handler, manager, "bob")
+
+	request, _ := json.Marshal(ChatRequest{
+		ID:     "post-1",
+		Method: "POST",
+		Path:   "/chat",
+		Data:   map[string]interface{}{"user": "alice", "message": "hi"},
+	})
+	if _, err := alice.input.Write(request); err != nil {
+		t.Fatal(err)
+	}
+
+	// 广播给 bob 的消息不带 alice 的请求 ID
+	broadcast := bob.output.next(t)
+	if _, ok := broadcast["id"]; ok {
+		t.Errorf("Broadcast should not carry a request id, got %v", broadcast)
+	}
+
+	// alice 先收到广播，再收到带请求 ID 的确认
+	for {
+		response := alice.output.next(t)
+		if id, ok := response["id"]; ok {
+			if id != "post-1" {
+				t.Errorf("Expected ack id 'post-1', got %v", id)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"spine-go/libspine/transport"
+	"testing"
+	"time"
+)
+
+// chanWriter 把每次写入发送到通道，便于在其他 goroutine 中等待消息
+type chanWriter struct {
+	ch chan []byte
+}
+
+func newChanWriter() *chanWriter {
+	return &chanWriter{ch: make(chan []byte, 16)}
+}
+
+func (w *chanWriter) Write(p []byte) (int, error) {
+	w.ch <- append([]byte(nil), p...)
+	return len(p), nil
+}
+
+func (w *chanWriter) Close() error {
+	return nil
+}
+
+// receive 等待下一条消息并解析为响应
+func (w *chanWriter) receive(t *testing.T) map[string]interface{} {
+	t.Helper()
+	select {
+	case data := <-w.ch:
+		var response map[string]interface{}
+		if err := json.Unmarshal(data, &response); err != nil {
+			t.Fatalf("Invalid response %q: %v", data, err)
+		}
+		return response
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for message")
+		return nil
+	}
+}
+
+// isEvent 判断响应是否为在线状态事件
+func isEvent(response map[string]interface{}) bool {
+	data, ok := response["data"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = data["event"]
+	return ok
+}
+
+// next 等待下一条不是在线状态事件的消息
+func (w *chanWriter) next(t *testing.T) map[string]interface{} {
+	t.Helper()
+	for {
+		if response := w.receive(t); !isEvent(response) {
+			return response
+		}
+	}
+}
+
+// nextEvent 等待下一条在线状态事件，返回事件数据
+func (w *chanWriter) nextEvent(t *testing.T) map[string]interface{} {
+	t.Helper()
+	for {
+		if response := w.receive(t); isEvent(response) {
+			return response["data"].(map[string]interface{})
+		}
+	}
+}
+
+// chatSession 一个保持连接的聊天客户端
+type chatSession struct {
+	conn   *transport.ConnInfo
+	input  *io.PipeWriter
+	output *chanWriter
+}
+
+// joinChatSession 建立连接并以 user 的身份加入聊天
+func joinChatSession(t *testing.T, handler *ChatHandler, manager transport.ConnectionManager, user string) *chatSession {
+	t.Helper()
+	reader, input := io.Pipe()
+	output := newChanWriter()
+	conn := &transport.ConnInfo{
+		ID:       "conn-" + user,
+		Protocol: "test",
+		Metadata: make(map[string]interface{}),
+		Reader:   reader,
+		Writer:   output,
+	}
+	manager.AddConnection(conn)
+
+	ctx := &transport.Context{ConnInfo: conn, ConnectionManager: manager}
+	go handler.Handle(ctx, reader, output)
+	t.Cleanup(func() { input.Close() })
+
+	session := &chatSession{conn: conn, input: input, output: output}
+	session.send(t, "JOIN", map[string]interface{}{"user": user})
+	if status := session.output.next(t)["status"]; status != float64(200) {
+		t.Fatalf("JOIN failed with status %v", status)
+	}
+	return session
+}
+
+func (s *chatSession) send(t *testing.T, method string, data interface{}) {
+	t.Helper()
+	request, _ := json.Marshal(ChatRequest{Method: method, Path: "/chat", Data: data})
+	if _, err := s.input.Write(request); err != nil {
+		t.Fatalf("Failed to send request: %v", err)
+	}
+}
+
+func TestChatHandler_DirectMessage(t *testing.T) {
+	handler := NewChatHandler()
+	manager := transport.NewConnectionManager()
+
+	alice := joinChatSession(t, handler, manager, "alice")
+	bob := joinChatSession(t, handler, manager, "bob")
+	carol := joinChatSession(t, handler, manager, "carol")
+
+	alice.send(t, "POST", map[string]interface{}{"user": "alice", "to": "bob", "message": "secret"})
+	if status := alice.output.next(t)["status"]; status != float64(200) {
+		t.Fatalf("Expected status 200 for DM, got %v", status)
+	}
+
+	received := bob.output.next(t)
+	data, _ := received["data"].(map[string]interface{})
+	if data["message"] != "secret" || data["user"] != "alice" || data["to"] != "bob" {
+		t.Fatalf("Unexpected DM delivered to bob: %v", received)
+	}
+
+	// carol 和 alice 都不会收到这条私信
+	select {
+	case data := <-carol.output.ch:
+		t.Fatalf("carol should not receive the DM, got %s", data)
+	case data := <-alice.output.ch:
+		t.Fatalf("alice should not receive her own DM, got %s", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// 私信不进入公共历史
+	carol.send(t, "GET", nil)
+	if history, _ := carol.output.next(t)["data"].([]interface{}); len(history) != 0 {
+		t.Fatalf("DM should not be stored in history, got %v", history)
+	}
+}
+
+func TestChatHandler_DirectMessageUnknownUser(t *testing.T) {
+	handler := NewChatHandler()
+	manager := transport.NewConnectionManager()
+
+	alice := joinChatSession(t, handler, manager, "alice")
+	bob := joinChatSession(t, handler, manager, "bob")
+
+	// bob 离开后不再能收到私信
+	bob.send(t, "LEAVE", nil)
+	bob.output.next(t)
+
+	for _, to := range []string{"bob", "nobody"} {
+		alice.send(t, "POST", map[string]interface{}{"user": "alice", "to": to, "message": "hello"})
+		response := alice.output.next(t)
+		if response["status"] != float64(404) || response["error"] != "User not found" {
+			t.Fatalf("Expected 404 for DM to %s, got %v", to, response)
+		}
+	}
+}
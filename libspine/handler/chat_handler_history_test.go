@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"spine-go/libspine/transport"
+	"testing"
+)
+
+// postChatMessages 依次发送多条聊天消息
+func postChatMessages(t *testing.T, handler *ChatHandler, ctx *transport.Context, texts ...string) {
+	t.Helper()
+	helpers := NewTestHelpers()
+	requests := make([]*transport.Request, 0, len(texts))
+	for _, text := range texts {
+		requests = append(requests, helpers.CreateTestRequest("POST", "/chat", helpers.CreateChatMessage("alice", text)))
+	}
+	if err := handler.Handle(ctx, NewMockReaderFromRequests(requests), NewMockWriter()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+// getChatMessages 通过 GET 请求读取历史消息
+func getChatMessages(t *testing.T, handler *ChatHandler, ctx *transport.Context) []ChatMessage {
+	t.Helper()
+	helpers := NewTestHelpers()
+	writer := NewMockWriter()
+	request := helpers.CreateTestRequest("GET", "/chat", nil)
+	if err := handler.Handle(ctx, NewMockReaderFromRequests([]*transport.Request{request}), writer); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var messages []ChatMessage
+	if data, ok := writer.GetLastResponseAsMap()["data"]; ok {
+		if dataBytes, err := json.Marshal(data); err == nil {
+			json.Unmarshal(dataBytes, &messages)
+		}
+	}
+	return messages
+}
+
+func TestChatHandler_HistoryLimit(t *testing.T) {
+	handler := NewChatHandler()
+	handler.SetHistoryLimit(3)
+	ctx := NewTestHelpers().CreateTestContext()
+
+	texts := make([]string, 5)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("message %d", i)
+	}
+	postChatMessages(t, handler, ctx, texts...)
+
+	// 超过上限后只保留最近的 3 条，且保持发送顺序
+	messages := getChatMessages(t, handler, ctx)
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	for i, msg := range messages {
+		if expected := texts[i+2]; msg.Message != expected {
+			t.Errorf("Message %d: expected '%s', got '%s'", i, expected, msg.Message)
+		}
+	}
+}
+
+func TestChatHandler_ShrinkHistoryLimit(t *testing.T) {
+	handler := NewChatHandler()
+	ctx := NewTestHelpers().CreateTestContext()
+	postChatMessages(t, handler, ctx, "first", "second", "third")
+
+	handler.SetHistoryLimit(2)
+	postChatMessages(t, handler, ctx, "fourth")
+
+	messages := getChatMessages(t, handler, ctx)
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Message != "third" || messages[1].Message != "fourth" {
+		t.Errorf("Expected [third fourth], got [%s %s]", messages[0].Message, messages[1].Message)
+	}
+}
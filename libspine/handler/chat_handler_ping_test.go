@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"spine-go/libspine/transport"
+	"testing"
+)
+
+func TestChatHandler_Ping(t *testing.T) {
+	handler := NewChatHandler()
+	helpers := NewTestHelpers()
+	ctx := helpers.CreateTestContext()
+
+	request := helpers.CreateTestRequest("PING", "/chat", nil)
+	request.ID = "ping-1"
+	writer := NewMockWriter()
+	if err := handler.Handle(ctx, NewMockReaderFromRequests([]*transport.Request{request}), writer); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// 心跳不需要先加入聊天，回应中带有请求 ID
+	response := writer.GetLastResponseAsMap()
+	if response["status"] != float64(200) || response["id"] != "ping-1" {
+		t.Fatalf("Unexpected PING response: %v", response)
+	}
+	if data, _ := response["data"].(map[string]interface{}); data["message"] != "pong" {
+		t.Fatalf("Expected pong, got %v", response["data"])
+	}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"spine-go/libspine/transport"
+	"testing"
+	"time"
+)
+
+func TestChatHandler_JoinEvent(t *testing.T) {
+	handler := NewChatHandler()
+	manager := transport.NewConnectionManager()
+
+	alice := joinChatSession(t, handler, manager, "alice")
+	bob := joinChatSession(t, handler, manager, "bob")
+
+	// 已在房间内的 alice 收到 bob 加入的事件
+	event := alice.output.nextEvent(t)
+	if event["event"] != ChatEventJoin || event["user"] != "bob" {
+		t.Fatalf("Expected join event for bob, got %v", event)
+	}
+
+	// bob 不会收到自己加入的事件
+	select {
+	case data := <-bob.output.ch:
+		t.Fatalf("bob should not receive his own join event, got %s", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestChatHandler_LeaveEventOnDisconnect(t *testing.T) {
+	handler := NewChatHandler()
+	manager := transport.NewConnectionManager()
+
+	alice := joinChatSession(t, handler, manager, "alice")
+	bob := joinChatSession(t, handler, manager, "bob")
+	alice.output.nextEvent(t) // bob 的加入事件
+
+	// bob 断开连接
+	bob.input.Close()
+
+	event := alice.output.nextEvent(t)
+	if event["event"] != ChatEventLeave || event["user"] != "bob" {
+		t.Fatalf("Expected leave event for bob, got %v", event)
+	}
+}
+
+func TestChatHandler_TypingEvent(t *testing.T) {
+	handler := NewChatHandler()
+	manager := transport.NewConnectionManager()
+
+	alice := joinChatSession(t, handler, manager, "alice")
+	bob := joinChatSession(t, handler, manager, "bob")
+	alice.output.nextEvent(t)
+
+	bob.send(t, "TYPING", nil)
+	if status := bob.output.next(t)["status"]; status != float64(200) {
+		t.Fatalf("Expected status 200 for TYPING, got %v", status)
+	}
+
+	event := alice.output.nextEvent(t)
+	if event["event"] != ChatEventTyping || event["user"] != "bob" {
+		t.Fatalf("Expected typing event for bob, got %v", event)
+	}
+
+	// 正在输入提示不进入历史记录
+	alice.send(t, "GET", nil)
+	if history, _ := alice.output.next(t)["data"].([]interface{}); len(history) != 0 {
+		t.Fatalf("Typing indicator should not be stored, got %v", history)
+	}
+}
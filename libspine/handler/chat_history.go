@@ -0,0 +1,50 @@
+package handler
+
+// DefaultChatHistoryLimit 默认保留的聊天消息条数
+const DefaultChatHistoryLimit = 1000
+
+// chatHistory 固定容量的环形缓冲区，写满后新消息覆盖最旧的消息
+type chatHistory struct {
+	buf   []*ChatMessage
+	start int // 最旧消息的位置
+	size  int
+}
+
+// newChatHistory 创建容量为 limit 的历史记录
+func newChatHistory(limit int) *chatHistory {
+	if limit <= 0 {
+		limit = DefaultChatHistoryLimit
+	}
+	return &chatHistory{buf: make([]*ChatMessage, limit)}
+}
+
+// add 追加一条消息
+func (h *chatHistory) add(msg *ChatMessage) {
+	if h.size < len(h.buf) {
+		h.buf[(h.start+h.size)%len(h.buf)] = msg
+		h.size++
+		return
+	}
+	h.buf[h.start] = msg
+	h.start = (h.start + 1) % len(h.buf)
+}
+
+// list 按时间顺序返回所有消息的副本
+func (h *chatHistory) list() []*ChatMessage {
+	messages := make([]*ChatMessage, h.size)
+	for i := 0; i < h.size; i++ {
+		messages[i] = h.buf[(h.start+i)%len(h.buf)]
+	}
+	return messages
+}
+
+// resize 调整容量，只保留最近的 limit 条消息
+func (h *chatHistory) resize(limit int) {
+	messages := h.list()
+	if len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	h.buf = make([]*ChatMessage, limit)
+	h.start = 0
+	h.size = copy(h.buf, messages)
+}
@@ -0,0 +1,472 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"sort"
+	"spine-go/libspine/common/resp"
+	"strings"
+	"sync"
+)
+
+// defaultUser 默认用户名，未认证的连接以该用户身份执行命令
+const defaultUser = "default"
+
+// aclUser ACL 用户
+type aclUser struct {
+	name      string
+	enabled   bool
+	nopass    bool
+	passwords map[string]struct{} // 密码的 SHA-256 十六进制摘要
+	// 命令规则，按顺序生效，如 +@all、-@dangerous、+get
+	commandRules []string
+	allKeys      bool
+}
+
+// newACLUser 创建新用户，与 Redis 一致，新用户默认禁用且没有任何权限
+func newACLUser(name string) *aclUser {
+	return &aclUser{
+		name:      name,
+		passwords: make(map[string]struct{}),
+	}
+}
+
+// hashPassword 计算密码摘要
+func hashPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkPassword 校验密码
+func (u *aclUser) checkPassword(password string) bool {
+	if u.nopass {
+		return true
+	}
+	hash := hashPassword(password)
+	for stored := range u.passwords {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// canRun 按规则顺序判断用户能否执行命令，后面的规则覆盖前面的规则
+func (u *aclUser) canRun(cmd *redisCommand) bool {
+	allowed := false
+	for _, rule := range u.commandRules {
+		target := rule[1:]
+		var matched bool
+		if strings.HasPrefix(target, "@") {
+			matched = target == "@all" || containsString(cmd.Categories, target)
+		} else {
+			matched = target == cmd.Name
+		}
+		if matched {
+			allowed = rule[0] == '+'
+		}
+	}
+	return allowed
+}
+
+// applyRule 应用一条 ACL SETUSER 规则
+func (u *aclUser) applyRule(rule string, commands map[string]*redisCommand) error {
+	switch strings.ToLower(rule) {
+	case "on":
+		u.enabled = true
+	case "off":
+		u.enabled = false
+	case "nopass":
+		u.nopass = true
+		u.passwords = make(map[string]struct{})
+	case "resetpass":
+		u.nopass = false
+		u.passwords = make(map[string]struct{})
+	case "allcommands":
+		u.commandRules = []string{"+@all"}
+	case "nocommands":
+		u.commandRules = nil
+	case "allkeys":
+		u.allKeys = true
+	case "resetkeys":
+		u.allKeys = false
+	case "reset":
+		*u = *newACLUser(u.name)
+	default:
+		return u.applyPrefixedRule(rule, commands)
+	}
+	return nil
+}
+
+// applyPrefixedRule 应用带前缀的规则：>密码、<密码、#摘要、+命令、-命令、~键模式
+func (u *aclUser) applyPrefixedRule(rule string, commands map[string]*redisCommand) error {
+	if len(rule) < 2 {
+		return resp.NewCommandError("Error in ACL SETUSER modifier '%s': Syntax error", rule)
+	}
+
+	value := rule[1:]
+	switch rule[0] {
+	case '>':
+		u.passwords[hashPassword(value)] = struct{}{}
+		u.nopass = false
+	case '<':
+		delete(u.passwords, hashPassword(value))
+	case '#':
+		// 密码按小写十六进制摘要比较，大写的摘要永远无法匹配，与 Redis 一样直接拒绝
+		if _, err := hex.DecodeString(value); err != nil || len(value) != sha256.Size*2 || value != strings.ToLower(value) {
+			return resp.NewCommandError("Error in ACL SETUSER modifier '%s': The password hash must be exactly 64 characters and contain only lowercase hexadecimal characters", rule)
+		}
+		u.passwords[value] = struct{}{}
+		u.nopass = false
+	case '+', '-':
+		value = strings.ToLower(value)
+		if value == "@all" || strings.HasPrefix(value, "@") && categoryExists(commands, value) {
+			u.commandRules = append(u.commandRules, rule[:1]+value)
+			break
+		}
+		if _, exists := commands[value]; !exists {
+			return resp.NewCommandError("Error in ACL SETUSER modifier '%s': Unknown command or category name in ACL", rule)
+		}
+		u.commandRules = append(u.commandRules, rule[:1]+value)
+	case '~':
+		if value != "*" {
+			return resp.NewCommandError("Error in ACL SETUSER modifier '%s': only the '~*' key pattern is supported", rule)
+		}
+		u.allKeys = true
+	default:
+		return resp.NewCommandError("Error in ACL SETUSER modifier '%s': Syntax error", rule)
+	}
+	return nil
+}
+
+// flags 返回用户标志，用于 ACL GETUSER
+func (u *aclUser) flags() []string {
+	flags := []string{"off"}
+	if u.enabled {
+		flags[0] = "on"
+	}
+	if u.nopass {
+		flags = append(flags, "nopass")
+	}
+	return flags
+}
+
+// sortedPasswords 返回排序后的密码摘要
+func (u *aclUser) sortedPasswords() []string {
+	passwords := make([]string, 0, len(u.passwords))
+	for hash := range u.passwords {
+		passwords = append(passwords, hash)
+	}
+	sort.Strings(passwords)
+	return passwords
+}
+
+// commandsDescription 返回命令规则描述
+func (u *aclUser) commandsDescription() string {
+	if len(u.commandRules) == 0 {
+		return "-@all"
+	}
+	return strings.Join(u.commandRules, " ")
+}
+
+// keysDescription 返回键规则描述
+func (u *aclUser) keysDescription() string {
+	if u.allKeys {
+		return "~*"
+	}
+	return ""
+}
+
+// describe 返回 ACL LIST 格式的用户描述
+func (u *aclUser) describe() string {
+	parts := []string{"user", u.name}
+	parts = append(parts, u.flags()...)
+	for _, hash := range u.sortedPasswords() {
+		parts = append(parts, "#"+hash)
+	}
+	if keys := u.keysDescription(); keys != "" {
+		parts = append(parts, keys)
+	} else {
+		parts = append(parts, "resetkeys")
+	}
+	parts = append(parts, u.commandsDescription())
+	return strings.Join(parts, " ")
+}
+
+// aclStore ACL 用户表
+type aclStore struct {
+	mu    sync.RWMutex
+	users map[string]*aclUser
+}
+
+// newACLStore 创建用户表，default 用户拥有全部权限且不需要密码
+func newACLStore() *aclStore {
+	user := newACLUser(defaultUser)
+	user.enabled = true
+	user.nopass = true
+	user.allKeys = true
+	user.commandRules = []string{"+@all"}
+
+	return &aclStore{
+		users: map[string]*aclUser{defaultUser: user},
+	}
+}
+
+// setDefaultPassword 设置 default 用户的密码，对应 requirepass
+func (s *aclStore) setDefaultPassword(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.users[defaultUser]
+	user.passwords = make(map[string]struct{})
+	user.nopass = password == ""
+	if password != "" {
+		user.passwords[hashPassword(password)] = struct{}{}
+	}
+}
+
+// defaultUserNeedsAuth 判断 default 用户是否需要密码
+func (s *aclStore) defaultUserNeedsAuth() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user := s.users[defaultUser]
+	return !user.enabled || !user.nopass
+}
+
+// authenticate 校验用户名和密码
+func (s *aclStore) authenticate(username, password string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[username]
+	return exists && user.enabled && user.checkPassword(password)
+}
+
+// canRun 判断用户能否执行命令，用户被删除后没有任何权限
+func (s *aclStore) canRun(username string, cmd *redisCommand) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[username]
+	return exists && user.canRun(cmd)
+}
+
+// canAccessKeys 判断用户能否访问键。目前只支持 ~* 规则，没有该规则的用户不能访问任何键
+func (s *aclStore) canAccessKeys(username string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[username]
+	return exists && user.allKeys
+}
+
+// categoryExists 判断命令表中是否有命令属于该分类
+func categoryExists(commands map[string]*redisCommand, category string) bool {
+	for _, cmd := range commands {
+		if containsString(cmd.Categories, category) {
+			return true
+		}
+	}
+	return false
+}
+
+// commandCategories 返回命令表中用到的全部 ACL 分类，不含 @ 前缀，按名称排序
+func commandCategories(commands map[string]*redisCommand) []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, cmd := range commands {
+		for _, category := range cmd.Categories {
+			if !seen[category] {
+				seen[category] = true
+				categories = append(categories, strings.TrimPrefix(category, "@"))
+			}
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// containsString 判断字符串切片中是否包含指定值
+func containsString(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// aclSubcommands ACL HELP 列出的子命令
+var aclSubcommands = []redisSubcommand{
+	{Syntax: "CAT [<category>]", Summary: "List all commands that belong to <category>, or all command categories\nwhen no category is specified."},
+	{Syntax: "DELUSER <username> [<username> ...]", Summary: "Delete a list of users."},
+	{Syntax: "GETUSER <username>", Summary: "Get the user's details."},
+	{Syntax: "LIST", Summary: "Show users details in config file format."},
+	{Syntax: "SETUSER <username> <attribute> [<attribute> ...]", Summary: "Create or modify a user with the specified attributes."},
+	{Syntax: "USERS", Summary: "List all the registered usernames."},
+	{Syntax: "WHOAMI", Summary: "Return the current connection username."},
+}
+
+// handleACL 处理 ACL 命令
+// ACL SETUSER username [rule...] | ACL GETUSER username | ACL DELUSER username...
+// ACL LIST | ACL USERS | ACL WHOAMI | ACL CAT [category]
+func (h *RedisHandler) handleACL(client *redisClient, command []string, writer *resp.RespWriter) error {
+	switch strings.ToUpper(command[1]) {
+	case "CAT":
+		if len(command) > 3 {
+			return writer.WriteWrongNumberOfArgumentsError("ACL|CAT")
+		}
+		return h.aclCat(command[2:], writer)
+
+	case "SETUSER":
+		if len(command) < 3 {
+			return writer.WriteWrongNumberOfArgumentsError("ACL|SETUSER")
+		}
+		return h.aclSetUser(command[2], command[3:], writer)
+
+	case "GETUSER":
+		if len(command) != 3 {
+			return writer.WriteWrongNumberOfArgumentsError("ACL|GETUSER")
+		}
+		return h.aclGetUser(client, command[2], writer)
+
+	case "DELUSER":
+		if len(command) < 3 {
+			return writer.WriteWrongNumberOfArgumentsError("ACL|DELUSER")
+		}
+		return h.aclDelUser(command[2:], writer)
+
+	case "LIST":
+		h.acl.mu.RLock()
+		names := h.acl.sortedNames()
+		values := make([]resp.Value, len(names))
+		for i, name := range names {
+			values[i] = resp.NewBulkStringString(h.acl.users[name].describe())
+		}
+		h.acl.mu.RUnlock()
+		return writer.WriteArray(values)
+
+	case "USERS":
+		h.acl.mu.RLock()
+		names := h.acl.sortedNames()
+		h.acl.mu.RUnlock()
+		values := make([]resp.Value, len(names))
+		for i, name := range names {
+			values[i] = resp.NewBulkStringString(name)
+		}
+		return writer.WriteArray(values)
+
+	case "WHOAMI":
+		return writer.WriteBulkStringString(client.user)
+
+	default:
+		return resp.NewCommandError("unknown subcommand '%s'. Try ACL HELP.", command[1])
+	}
+}
+
+// sortedNames 返回排序后的用户名，调用方需持有 s.mu
+func (s *aclStore) sortedNames() []string {
+	names := make([]string, 0, len(s.users))
+	for name := range s.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// aclCat 不带参数时返回全部分类，否则返回属于该分类的命令名
+func (h *RedisHandler) aclCat(args []string, writer *resp.RespWriter) error {
+	if len(args) == 0 {
+		categories := commandCategories(h.commands)
+		values := make([]resp.Value, len(categories))
+		for i, category := range categories {
+			values[i] = resp.NewBulkStringString(category)
+		}
+		return writer.WriteArray(values)
+	}
+
+	category := "@" + strings.ToLower(args[0])
+	if !categoryExists(h.commands, category) {
+		return resp.NewCommandError("Unknown category '%s'", args[0])
+	}
+	var values []resp.Value
+	for _, cmd := range h.sortedCommands() {
+		if containsString(cmd.Categories, category) {
+			values = append(values, resp.NewBulkStringString(cmd.Name))
+		}
+	}
+	return writer.WriteArray(values)
+}
+
+// aclSetUser 创建或修改用户，规则全部校验通过后才生效
+func (h *RedisHandler) aclSetUser(name string, rules []string, writer *resp.RespWriter) error {
+	h.acl.mu.Lock()
+	defer h.acl.mu.Unlock()
+
+	user := newACLUser(name)
+	if existing, exists := h.acl.users[name]; exists {
+		*user = *existing
+		user.passwords = make(map[string]struct{}, len(existing.passwords))
+		for hash := range existing.passwords {
+			user.passwords[hash] = struct{}{}
+		}
+		user.commandRules = append([]string(nil), existing.commandRules...)
+	}
+
+	for _, rule := range rules {
+		if err := user.applyRule(rule, h.commands); err != nil {
+			return err
+		}
+	}
+
+	h.acl.users[name] = user
+	return writer.WriteOK()
+}
+
+// aclGetUser 返回用户详情，用户不存在时返回 nil
+func (h *RedisHandler) aclGetUser(client *redisClient, name string, writer *resp.RespWriter) error {
+	h.acl.mu.RLock()
+	user, exists := h.acl.users[name]
+	if !exists {
+		h.acl.mu.RUnlock()
+		return writer.WriteNil()
+	}
+
+	passwords := user.sortedPasswords()
+	passwordValues := make([]resp.Value, len(passwords))
+	for i, hash := range passwords {
+		passwordValues[i] = resp.NewBulkStringString(hash)
+	}
+	items := []resp.MapItem{
+		{Key: resp.NewBulkStringString("flags"), Value: statusArray(user.flags())},
+		{Key: resp.NewBulkStringString("passwords"), Value: resp.NewArray(passwordValues)},
+		{Key: resp.NewBulkStringString("commands"), Value: resp.NewBulkStringString(user.commandsDescription())},
+		{Key: resp.NewBulkStringString("keys"), Value: resp.NewBulkStringString(user.keysDescription())},
+	}
+	h.acl.mu.RUnlock()
+
+	return writer.WriteValue(client.mapReply(items))
+}
+
+// aclDelUser 删除用户，返回实际删除的数量
+func (h *RedisHandler) aclDelUser(names []string, writer *resp.RespWriter) error {
+	h.acl.mu.Lock()
+	defer h.acl.mu.Unlock()
+
+	for _, name := range names {
+		if name == defaultUser {
+			return resp.NewCommandError("The 'default' user cannot be removed")
+		}
+	}
+
+	deleted := 0
+	for _, name := range names {
+		if _, exists := h.acl.users[name]; exists {
+			delete(h.acl.users, name)
+			deleted++
+		}
+	}
+	return writer.WriteInteger(int64(deleted))
+}
@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"spine-go/libspine/common/resp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AOF 刷盘策略，与 Redis appendfsync 的取值一致
+const (
+	AOFFsyncAlways   = "always"
+	AOFFsyncEverySec = "everysec"
+	AOFFsyncNo       = "no"
+)
+
+// aofWriter 追加写日志，按 RESP 数组格式记录修改数据的命令
+type aofWriter struct {
+	file   *os.File
+	writer *bufio.Writer
+	fsync  string
+	mu     sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// newAOFWriter 以追加模式打开 AOF 文件
+func newAOFWriter(path string, fsync string) (*aofWriter, error) {
+	switch fsync {
+	case AOFFsyncAlways, AOFFsyncEverySec, AOFFsyncNo:
+	default:
+		return nil, fmt.Errorf("invalid appendfsync policy: %s", fsync)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &aofWriter{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		fsync:  fsync,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.syncLoop()
+	return w, nil
+}
+
+// appendCommand 追加一条命令，调用方需持有 w.mu
+func (w *aofWriter) appendCommand(command []string) error {
+	data, err := resp.SerializeCommand(command[0], command[1:]...)
+	if err != nil {
+		return err
+	}
+	if _, err := w.writer.Write(data); err != nil {
+		return err
+	}
+
+	switch w.fsync {
+	case AOFFsyncAlways:
+		if err := w.writer.Flush(); err != nil {
+			return err
+		}
+		return w.file.Sync()
+	case AOFFsyncNo:
+		// 交给操作系统决定何时落盘
+		return w.writer.Flush()
+	}
+	return nil
+}
+
+// syncLoop 在 everysec 策略下每秒刷盘一次，策略可能被 CONFIG SET appendfsync 修改，每次都重新检查
+func (w *aofWriter) syncLoop() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			everysec := w.fsync == AOFFsyncEverySec
+			w.mu.Unlock()
+			if !everysec {
+				continue
+			}
+			if err := w.flush(); err != nil {
+				log.Printf("AOF fsync error: %v", err)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// setFsync 修改刷盘策略，切换前先把缓冲区落盘
+func (w *aofWriter) setFsync(fsync string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.fsync = fsync
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// flush 将缓冲区写入文件并同步到磁盘
+func (w *aofWriter) flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.writer.Flush(); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+// Close 刷盘并关闭 AOF 文件
+func (w *aofWriter) Close() error {
+	close(w.stop)
+	<-w.done
+
+	flushErr := w.flush()
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+// discardWriter 丢弃所有写入，用于回放 AOF 时忽略命令回复
+type discardWriter struct{}
+
+func (discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (discardWriter) Close() error                { return nil }
+
+// countingReader 统计已读取的字节数
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// EnableAOF 回放已有的 AOF 文件并开始记录修改数据的命令
+func (h *RedisHandler) EnableAOF(path string, fsync string) error {
+	if err := h.loadAOF(path); err != nil {
+		return err
+	}
+
+	aof, err := newAOFWriter(path, fsync)
+	if err != nil {
+		return err
+	}
+
+	h.configMu.Lock()
+	h.config.aofPath, h.config.aofFsync = path, fsync
+	h.configMu.Unlock()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.aof != nil {
+		h.aof.Close()
+	}
+	h.aof = aof
+	return nil
+}
+
+// startAOF 运行时开启 AOF（CONFIG SET appendonly yes）：先把当前数据写入 AOF 文件，再开始追加记录修改数据的命令。
+// 与 EnableAOF 不同，不回放已有的文件
+func (h *RedisHandler) startAOF() error {
+	h.configMu.RLock()
+	path, fsync := h.config.aofPath, h.config.aofFsync
+	h.configMu.RUnlock()
+
+	// 写文件期间不执行修改数据的命令，保证文件内容与开始记录时的数据一致
+	h.propagateMu.Lock()
+	defer h.propagateMu.Unlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.aof != nil {
+		return nil
+	}
+	if err := writeSnapshot(path, h.snapshotCommandsLocked()); err != nil {
+		return err
+	}
+	aof, err := newAOFWriter(path, fsync)
+	if err != nil {
+		return err
+	}
+	h.aof = aof
+	return nil
+}
+
+// DisableAOF 停止记录 AOF 并关闭文件
+func (h *RedisHandler) DisableAOF() error {
+	h.mu.Lock()
+	aof := h.aof
+	h.aof = nil
+	h.mu.Unlock()
+
+	if aof == nil {
+		return nil
+	}
+	return aof.Close()
+}
+
+// loadAOF 通过命令表回放 AOF 文件中的命令
+func (h *RedisHandler) loadAOF(path string) error {
+	loaded, err := h.replayFile(path)
+	if err != nil {
+		return err
+	}
+	if loaded > 0 {
+		log.Printf("Loaded %d commands from AOF file %s", loaded, path)
+	}
+	return nil
+}
+
+// replayFile 通过命令表回放文件中的 RESP 命令，文件不存在时不做任何事
+func (h *RedisHandler) replayFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer file.Close()
+
+	counter := &countingReader{reader: file}
+	parser := resp.NewParser(counter)
+	writer := resp.NewRespWriter(discardWriter{})
+	// 回放的命令来自本地文件，无需认证
+	client := &redisClient{authenticated: true}
+	loaded := 0
+	for {
+		// 当前命令在文件中的起始位置，用于报告格式错误
+		offset := counter.n - int64(parser.Buffered())
+		value, err := parser.Parse()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// 与 aof-load-truncated 一致，忽略末尾不完整的命令
+			if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, resp.ErrIncompleteMessage) {
+				log.Printf("File %s is truncated, loaded %d commands", path, loaded)
+				break
+			}
+			return loaded, fmt.Errorf("bad file format at offset %d: %v", offset, err)
+		}
+
+		command := make([]string, 0, len(value.Array))
+		for _, item := range value.Array {
+			command = append(command, string(item.Bulk))
+		}
+		if len(command) == 0 {
+			continue
+		}
+
+		redisCmd, exists := h.lookupCommand(command[0])
+		if !exists {
+			return loaded, fmt.Errorf("unknown command '%s' in %s", command[0], path)
+		}
+		if !redisCmd.checkArity(len(command)) {
+			return loaded, fmt.Errorf("bad file format at offset %d: wrong number of arguments for '%s' command",
+				offset, strings.ToLower(command[0]))
+		}
+		if err := redisCmd.handler(h, client, command, writer); err != nil {
+			if _, ok := resp.AsCommandError(err); !ok {
+				return loaded, err
+			}
+		}
+		loaded++
+	}
+	return loaded, nil
+}
+
+// currentAOF 返回当前的 AOF，未启用时为 nil
+func (h *RedisHandler) currentAOF() *aofWriter {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.aof
+}
+
+// executeAndPropagate 执行修改数据的命令，然后写入 AOF 并传播给副本，保证日志和命令流的顺序与执行顺序一致
+func (h *RedisHandler) executeAndPropagate(redisCmd *redisCommand, client *redisClient, command []string, writer *resp.RespWriter) error {
+	// 不需要传播时只持有读锁，修改数据的命令仍可并发执行
+	h.propagateMu.RLock()
+	if h.currentAOF() == nil && !h.primary.hasReplicas() {
+		defer h.propagateMu.RUnlock()
+		return redisCmd.handler(h, client, command, writer)
+	}
+	h.propagateMu.RUnlock()
+
+	h.propagateMu.Lock()
+	defer h.propagateMu.Unlock()
+	aof := h.currentAOF()
+	err := redisCmd.handler(h, client, command, writer)
+	// CommandError 表示命令未执行，其他错误只是回复写入失败
+	if _, rejected := resp.AsCommandError(err); rejected || client.unchanged {
+		return err
+	}
+	if logged := h.aofCommand(client, command); logged != nil {
+		h.propagateLocked(aof, logged)
+	}
+	return err
+}
+
+// propagateLocked 把命令写入 AOF 并传播给副本，调用方必须持有 propagateMu 的写锁
+func (h *RedisHandler) propagateLocked(aof *aofWriter, logged []string) {
+	if aof != nil {
+		aof.mu.Lock()
+		logErr := aof.appendCommand(logged)
+		aof.mu.Unlock()
+		if logErr != nil {
+			log.Printf("Error writing AOF: %v", logErr)
+		}
+	}
+	h.primary.propagate(logged)
+}
+
+// aofCommand 返回命令写入 AOF 和传播给副本的形式，返回 nil 表示不需要记录。
+// SET、RESTORE 等由处理函数给出改写后的命令，相对过期时间已换算为绝对时间
+func (h *RedisHandler) aofCommand(client *redisClient, command []string) []string {
+	if client.rewritten != nil {
+		return client.rewritten
+	}
+	return command
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"spine-go/libspine/common/resp"
+)
+
+// SetRequirePass 设置 default 用户的访问密码，为空表示不需要认证
+func (h *RedisHandler) SetRequirePass(password string) {
+	h.acl.setDefaultPassword(password)
+	h.configMu.Lock()
+	h.config.requirepass = password
+	h.configMu.Unlock()
+}
+
+// authRequired 判断连接是否还需要认证
+func (h *RedisHandler) authRequired(client *redisClient) bool {
+	return !client.authenticated && h.acl.defaultUserNeedsAuth()
+}
+
+// authenticate 校验并更新连接的认证状态，失败时返回 WRONGPASS
+func (h *RedisHandler) authenticate(client *redisClient, username, password string) error {
+	ok := h.acl.authenticate(username, password)
+
+	// user 会被 CLIENT LIST 并发读取
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if !ok {
+		client.authenticated = false
+		client.user = defaultUser
+		return resp.NewCommandErrorWithCode(resp.ErrCodeWrongPass, "invalid username-password pair or user is disabled.")
+	}
+	client.authenticated = true
+	client.user = username
+	return nil
+}
+
+// handleAUTH 处理 AUTH 命令
+// AUTH [username] password
+func (h *RedisHandler) handleAUTH(client *redisClient, command []string, writer *resp.RespWriter) error {
+	if len(command) != 2 && len(command) != 3 {
+		return resp.NewSyntaxError()
+	}
+
+	username, password := defaultUser, command[1]
+	if len(command) == 3 {
+		username, password = command[1], command[2]
+	} else if !h.acl.defaultUserNeedsAuth() {
+		return resp.NewCommandError("AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?")
+	}
+
+	if err := h.authenticate(client, username, password); err != nil {
+		return err
+	}
+	return writer.WriteOK()
+}
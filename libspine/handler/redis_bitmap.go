@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"math/bits"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+)
+
+// maxBitOffset 与 Redis 一致，位图最大 512MB
+const maxBitOffset = 4*1024*1024*1024 - 1
+
+// bitcountArguments BITCOUNT 的参数说明
+var bitcountArguments = []redisArg{
+	keyArg,
+	{Name: "range", Type: "block", Optional: true, Arguments: []redisArg{
+		{Name: "start", Type: "integer"},
+		{Name: "end", Type: "integer"},
+		{Name: "unit", Type: "oneof", Optional: true, Arguments: []redisArg{
+			{Name: "byte", Type: "pure-token", Token: "BYTE"},
+			{Name: "bit", Type: "pure-token", Token: "BIT"},
+		}},
+	}},
+}
+
+// parseBitOffset 解析 SETBIT/GETBIT 的位偏移量
+func parseBitOffset(s string) (int64, error) {
+	offset, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || offset < 0 || offset > maxBitOffset {
+		return 0, resp.NewCommandError("bit offset is not an integer or out of range")
+	}
+	return offset, nil
+}
+
+// bitAt 返回字符串中第 offset 位的值，位序与 Redis 一致（每个字节从最高位开始）
+func bitAt(value []byte, offset int64) int {
+	index := offset >> 3
+	if index >= int64(len(value)) {
+		return 0
+	}
+	return int(value[index]>>(7-uint(offset&7))) & 1
+}
+
+// handleSETBIT 处理 SETBIT key offset value，返回该位原来的值
+// 偏移量超出字符串长度时用零字节补齐
+func (h *RedisHandler) handleSETBIT(client *redisClient, command []string, writer *resp.RespWriter) error {
+	key := command[1]
+	offset, err := parseBitOffset(command[2])
+	if err != nil {
+		return err
+	}
+	if command[3] != "0" && command[3] != "1" {
+		return resp.NewCommandError("bit is not an integer or out of range")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	item := h.lookupLocked(key)
+	if item == nil {
+		item = &RedisItem{LastAccess: h.clock.Now()}
+		h.store[key] = item
+	}
+
+	value := []byte(item.Value)
+	index := offset >> 3
+	if index >= int64(len(value)) {
+		value = append(value, make([]byte, index+1-int64(len(value)))...)
+	}
+
+	old := bitAt(value, offset)
+	mask := byte(1) << (7 - uint(offset&7))
+	if command[3] == "1" {
+		value[index] |= mask
+	} else {
+		value[index] &^= mask
+	}
+
+	item.Value = string(value)
+	item.touch(h.clock.Now())
+	return writer.WriteInteger(int64(old))
+}
+
+// handleGETBIT 处理 GETBIT key offset，键不存在或偏移量超出长度时返回 0
+func (h *RedisHandler) handleGETBIT(client *redisClient, command []string, writer *resp.RespWriter) error {
+	offset, err := parseBitOffset(command[2])
+	if err != nil {
+		return err
+	}
+
+	value, err := h.get(command[1])
+	if err != nil {
+		return writer.WriteInteger(0)
+	}
+	return writer.WriteInteger(int64(bitAt([]byte(value), offset)))
+}
+
+// handleBITCOUNT 处理 BITCOUNT key [start end [BYTE|BIT]]
+// start 和 end 可以为负数，表示从末尾开始计数，默认按字节索引
+func (h *RedisHandler) handleBITCOUNT(client *redisClient, command []string, writer *resp.RespWriter) error {
+	var start, end int64
+	ranged, bitMode := false, false
+	switch len(command) {
+	case 2:
+	case 4, 5:
+		var err1, err2 error
+		start, err1 = strconv.ParseInt(command[2], 10, 64)
+		end, err2 = strconv.ParseInt(command[3], 10, 64)
+		if err1 != nil || err2 != nil {
+			return resp.NewCommandError("value is not an integer or out of range")
+		}
+		ranged = true
+		if len(command) == 5 {
+			switch strings.ToUpper(command[4]) {
+			case "BYTE":
+			case "BIT":
+				bitMode = true
+			default:
+				return resp.NewSyntaxError()
+			}
+		}
+	default:
+		return resp.NewSyntaxError()
+	}
+
+	value, err := h.get(command[1])
+	if err != nil {
+		return writer.WriteInteger(0)
+	}
+	data := []byte(value)
+	if !ranged {
+		return writer.WriteInteger(countBits(data, 0, int64(len(data))-1, false))
+	}
+	return writer.WriteInteger(countBits(data, start, end, bitMode))
+}
+
+// countBits 统计 [start, end] 范围内置位的数量，bitMode 为 true 时范围以位为单位
+func countBits(data []byte, start, end int64, bitMode bool) int64 {
+	total := int64(len(data))
+	if bitMode {
+		total *= 8
+	}
+	if start < 0 {
+		start += total
+	}
+	if end < 0 {
+		end += total
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end >= total {
+		end = total - 1
+	}
+	if total == 0 || start > end {
+		return 0
+	}
+
+	var count int64
+	if !bitMode {
+		for _, b := range data[start : end+1] {
+			count += int64(bits.OnesCount8(b))
+		}
+		return count
+	}
+	for offset := start; offset <= end; offset++ {
+		count += int64(bitAt(data, offset))
+	}
+	return count
+}
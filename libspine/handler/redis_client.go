@@ -0,0 +1,357 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisClient 连接级状态，每个连接一个。
+// 只有该连接的 Handle 协程会修改它；mu 之后的字段以及 user 会被 CLIENT 命令并发读取，修改时需持有 mu
+type redisClient struct {
+	id            int64
+	addr          string
+	laddr         string // 连接的本地地址，即客户端连上的服务器地址，未知时为空
+	createdAt     time.Time
+	closer        io.Closer    // 用于 CLIENT KILL 关闭连接，为 nil 表示无法关闭
+	authenticated bool         // 是否已通过 AUTH
+	user          string       // 当前用户，未认证时为 default
+	monitorCh     chan string  // MONITOR 模式下待发送的命令，不在该模式时为 nil
+	replica       *replicaConn // 连接是 PSYNC 之后的副本时的复制状态，否则为 nil
+	replicaPort   string       // 副本通过 REPLCONF listening-port 报告的端口
+	master        bool         // 是否是执行主节点命令流的连接，不受只读副本的限制
+	quitting      bool         // 是否已执行 QUIT，回复发出后关闭连接
+	protocol      int          // HELLO 协商的 RESP 协议版本，默认为 2
+	unchanged     bool         // 修改数据的命令实际没有修改数据，如条件不满足的 SET NX/XX，不写入 AOF、不传播也不计入 dirty
+	rewritten     []string     // 命令处理函数给出的写入 AOF 和传播给副本的形式，为 nil 时按 aofCommand 转换
+
+	mu              sync.Mutex
+	name            string    // CLIENT SETNAME / HELLO SETNAME 设置的名称
+	lastCommand     string    // 最近执行的命令名
+	lastInteraction time.Time // 最近一次执行命令的时间
+	killed          bool      // 是否已被 CLIENT KILL 关闭
+}
+
+// newRedisClient 创建新的连接状态
+func newRedisClient() *redisClient {
+	now := time.Now()
+	return &redisClient{
+		user:            defaultUser,
+		protocol:        2,
+		createdAt:       now,
+		lastInteraction: now,
+	}
+}
+
+// setName 设置连接名称
+func (c *redisClient) setName(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.name = name
+}
+
+// getName 获取连接名称
+func (c *redisClient) getName() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.name
+}
+
+// recordCommand 记录最近执行的命令
+func (c *redisClient) recordCommand(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastCommand = name
+	c.lastInteraction = time.Now()
+}
+
+// kill 关闭连接，返回是否是第一次关闭
+func (c *redisClient) kill() bool {
+	c.mu.Lock()
+	if c.killed {
+		c.mu.Unlock()
+		return false
+	}
+	c.killed = true
+	c.mu.Unlock()
+
+	if c.closer != nil {
+		c.closer.Close()
+	}
+	return true
+}
+
+// isKilled 连接是否已被关闭
+func (c *redisClient) isKilled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.killed
+}
+
+// info 返回 CLIENT LIST / CLIENT INFO 格式的连接描述
+func (c *redisClient) info(now time.Time) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cmd := c.lastCommand
+	if cmd == "" {
+		cmd = "NULL"
+	}
+	return fmt.Sprintf("id=%d addr=%s name=%s age=%d idle=%d flags=N db=0 cmd=%s user=%s",
+		c.id, c.addr, c.name,
+		int64(now.Sub(c.createdAt).Seconds()),
+		int64(now.Sub(c.lastInteraction).Seconds()),
+		cmd, c.user)
+}
+
+// handleRESET 处理 RESET 命令，把连接恢复到刚建立时的状态：
+// 退出监视模式、恢复 RESP2、清除连接名称并取消认证，设置了密码时需要重新 AUTH
+// RESET
+func (h *RedisHandler) handleRESET(client *redisClient, command []string, writer *resp.RespWriter) error {
+	h.stopMonitor(client)
+	client.protocol = 2
+
+	client.mu.Lock()
+	client.name = ""
+	client.authenticated = false
+	client.user = defaultUser
+	client.mu.Unlock()
+	return writer.WriteSimpleString("RESET")
+}
+
+// handleQUIT 处理 QUIT 命令，回复 OK 后由服务器关闭连接，之后流水线中的命令不再执行
+// QUIT
+func (h *RedisHandler) handleQUIT(client *redisClient, command []string, writer *resp.RespWriter) error {
+	client.quitting = true
+	return writer.WriteOK()
+}
+
+// clientRegistry 已连接客户端的注册表
+type clientRegistry struct {
+	mu      sync.RWMutex
+	nextID  int64
+	clients map[int64]*redisClient
+}
+
+// newClientRegistry 创建客户端注册表
+func newClientRegistry() *clientRegistry {
+	return &clientRegistry{
+		clients: make(map[int64]*redisClient),
+	}
+}
+
+// register 为连接分配唯一 ID 并加入注册表
+func (r *clientRegistry) register(client *redisClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	client.id = r.nextID
+	r.clients[client.id] = client
+}
+
+// unregister 从注册表移除连接
+func (r *clientRegistry) unregister(client *redisClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, client.id)
+}
+
+// count 返回当前连接数
+func (r *clientRegistry) count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.clients)
+}
+
+// list 返回按 ID 排序的全部连接
+func (r *clientRegistry) list() []*redisClient {
+	r.mu.RLock()
+	clients := make([]*redisClient, 0, len(r.clients))
+	for _, client := range r.clients {
+		clients = append(clients, client)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].id < clients[j].id
+	})
+	return clients
+}
+
+// validClientName 连接名称不能包含空格、换行等特殊字符
+func validClientName(name string) bool {
+	for _, ch := range name {
+		if ch < '!' || ch > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// clientSubcommands CLIENT HELP 列出的子命令
+var clientSubcommands = []redisSubcommand{
+	{Syntax: "ID", Summary: "Return the ID of the current connection."},
+	{Syntax: "GETNAME", Summary: "Return the name of the current connection."},
+	{Syntax: "SETNAME <name>", Summary: "Assign the name <name> to the current connection."},
+	{Syntax: "INFO", Summary: "Return information about the current client connection."},
+	{Syntax: "LIST [ID <id> [<id> ...]]", Summary: "Return information about client connections."},
+	{Syntax: "KILL <ip:port>", Summary: "Kill connection made from <ip:port>."},
+	{Syntax: "KILL <option> <value> [<option> <value> [...]]",
+		Summary: "Kill connections. Options are:\n* ADDR <ip:port>\n  Kill connection made from <ip:port>.\n* ID <client-id>\n  Kill connections by client id.\n* SKIPME (YES|NO)\n  Skip killing current connection (default: yes)."},
+}
+
+// handleCLIENT 处理 CLIENT 命令
+// CLIENT ID | CLIENT GETNAME | CLIENT SETNAME name | CLIENT LIST [ID id...] | CLIENT INFO
+// CLIENT KILL addr | CLIENT KILL [ID id] [ADDR addr] [SKIPME yes|no]
+func (h *RedisHandler) handleCLIENT(client *redisClient, command []string, writer *resp.RespWriter) error {
+	switch strings.ToUpper(command[1]) {
+	case "ID":
+		return writer.WriteInteger(client.id)
+
+	case "GETNAME":
+		name := client.getName()
+		if name == "" {
+			return writer.WriteNil()
+		}
+		return writer.WriteBulkStringString(name)
+
+	case "SETNAME":
+		if len(command) != 3 {
+			return writer.WriteWrongNumberOfArgumentsError("CLIENT|SETNAME")
+		}
+		if !validClientName(command[2]) {
+			return resp.NewCommandError("Client names cannot contain spaces, newlines or special characters.")
+		}
+		client.setName(command[2])
+		return writer.WriteOK()
+
+	case "INFO":
+		return writer.WriteBulkStringString(client.info(time.Now()) + "\n")
+
+	case "LIST":
+		return h.clientList(command[2:], writer)
+
+	case "KILL":
+		return h.clientKill(client, command[2:], writer)
+
+	default:
+		return resp.NewCommandError("unknown subcommand '%s'. Try CLIENT HELP.", command[1])
+	}
+}
+
+// clientList 返回连接列表，可按 ID 过滤
+func (h *RedisHandler) clientList(args []string, writer *resp.RespWriter) error {
+	var ids map[int64]bool
+	if len(args) > 0 {
+		if strings.ToUpper(args[0]) != "ID" || len(args) < 2 {
+			return resp.NewSyntaxError()
+		}
+		ids = make(map[int64]bool)
+		for _, arg := range args[1:] {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil || id <= 0 {
+				return resp.NewCommandError("Invalid client ID")
+			}
+			ids[id] = true
+		}
+	}
+
+	now := time.Now()
+	var builder strings.Builder
+	for _, c := range h.clients.list() {
+		if ids != nil && !ids[c.id] {
+			continue
+		}
+		builder.WriteString(c.info(now))
+		builder.WriteByte('\n')
+	}
+	return writer.WriteBulkStringString(builder.String())
+}
+
+// clientKill 关闭匹配的连接
+func (h *RedisHandler) clientKill(client *redisClient, args []string, writer *resp.RespWriter) error {
+	if len(args) == 0 {
+		return resp.NewSyntaxError()
+	}
+
+	// 旧格式：CLIENT KILL addr，可以关闭自己，没有匹配时报错
+	if len(args) == 1 {
+		for _, c := range h.clients.list() {
+			if c.addr == args[0] {
+				// 关闭自己时先回复再关闭
+				if c == client {
+					err := writer.WriteOK()
+					c.kill()
+					return err
+				}
+				c.kill()
+				return writer.WriteOK()
+			}
+		}
+		return resp.NewCommandError("No such client")
+	}
+
+	if len(args)%2 != 0 {
+		return resp.NewSyntaxError()
+	}
+	var id int64
+	addr := ""
+	skipMe := true
+	for i := 0; i < len(args); i += 2 {
+		value := args[i+1]
+		switch strings.ToUpper(args[i]) {
+		case "ID":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n <= 0 {
+				return resp.NewCommandError("client-id should be greater than 0")
+			}
+			id = n
+		case "ADDR":
+			addr = value
+		case "SKIPME":
+			switch strings.ToLower(value) {
+			case "yes":
+				skipMe = true
+			case "no":
+				skipMe = false
+			default:
+				return resp.NewSyntaxError()
+			}
+		default:
+			return resp.NewSyntaxError()
+		}
+	}
+
+	killed := 0
+	killSelf := false
+	for _, c := range h.clients.list() {
+		if id != 0 && c.id != id {
+			continue
+		}
+		if addr != "" && c.addr != addr {
+			continue
+		}
+		if c == client {
+			if !skipMe {
+				killSelf = true
+				killed++
+			}
+			continue
+		}
+		if c.kill() {
+			killed++
+		}
+	}
+
+	err := writer.WriteInteger(int64(killed))
+	if killSelf {
+		client.kill()
+	}
+	return err
+}
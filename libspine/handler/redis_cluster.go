@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"fmt"
+	"net"
+	"spine-go/libspine/common/cluster"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+)
+
+// clusterBusPortOffset 集群总线端口相对于服务端口的偏移，CLUSTER NODES 中按 Redis 的默认值报告
+const clusterBusPortOffset = 10000
+
+// clusterSubcommands CLUSTER HELP 列出的子命令
+var clusterSubcommands = []redisSubcommand{
+	{Syntax: "INFO", Summary: "Return information about the cluster."},
+	{Syntax: "KEYSLOT <key>", Summary: "Return the hash slot for <key>."},
+	{Syntax: "MYID", Summary: "Return the node id."},
+	{Syntax: "NODES", Summary: "Return cluster configuration seen by node. Output format:\n    <id> <ip:port@bus-port> <flags> <master> <pings> <pongs> <epoch> <link> <slot> ..."},
+	{Syntax: "SHARDS", Summary: "Return information about slot range mappings and the nodes associated with them."},
+	{Syntax: "SLOTS", Summary: "Return information about slots range mappings. Each range is made of:\n    start, end, master and replicas IP addresses, ports and ids"},
+}
+
+// handleCLUSTER 处理 CLUSTER 命令。spine 不支持集群，这里把自身报告为持有全部哈希槽的单个主节点，
+// 让集群模式的客户端在建立连接时能够正常拿到拓扑并把服务器当作单节点使用
+// CLUSTER INFO | CLUSTER KEYSLOT key | CLUSTER MYID | CLUSTER NODES | CLUSTER SHARDS | CLUSTER SLOTS
+func (h *RedisHandler) handleCLUSTER(client *redisClient, command []string, writer *resp.RespWriter) error {
+	subcommand := strings.ToUpper(command[1])
+	arity := 2
+	switch subcommand {
+	case "INFO", "MYID", "NODES", "SHARDS", "SLOTS":
+	case "KEYSLOT":
+		arity = 3
+	default:
+		return resp.NewCommandError("unknown subcommand '%s'. Try CLUSTER HELP.", command[1])
+	}
+	if len(command) != arity {
+		return writer.WriteWrongNumberOfArgumentsError("CLUSTER|" + subcommand)
+	}
+	if subcommand == "KEYSLOT" {
+		return writer.WriteInteger(int64(cluster.KeySlot(command[2])))
+	}
+
+	ip, port := h.clusterNodeAddress(client)
+	switch subcommand {
+	case "INFO":
+		var builder strings.Builder
+		for _, field := range [][2]interface{}{
+			{"cluster_enabled", 0},
+			{"cluster_state", "ok"},
+			{"cluster_slots_assigned", cluster.Slots},
+			{"cluster_slots_ok", cluster.Slots},
+			{"cluster_slots_pfail", 0},
+			{"cluster_slots_fail", 0},
+			{"cluster_known_nodes", 1},
+			{"cluster_size", 1},
+			{"cluster_current_epoch", 0},
+			{"cluster_my_epoch", 0},
+		} {
+			fmt.Fprintf(&builder, "%s:%v\r\n", field[0], field[1])
+		}
+		return writer.WriteBulkStringString(builder.String())
+
+	case "MYID":
+		return writer.WriteBulkStringString(h.nodeID)
+
+	case "NODES":
+		return writer.WriteBulkStringString(fmt.Sprintf("%s %s@%d myself,master - 0 0 0 connected 0-%d\n",
+			h.nodeID, net.JoinHostPort(ip, strconv.Itoa(port)), port+clusterBusPortOffset, cluster.Slots-1))
+
+	case "SHARDS":
+		node := client.mapReply([]resp.MapItem{
+			{Key: resp.NewBulkStringString("id"), Value: resp.NewBulkStringString(h.nodeID)},
+			{Key: resp.NewBulkStringString("port"), Value: resp.NewInteger(int64(port))},
+			{Key: resp.NewBulkStringString("ip"), Value: resp.NewBulkStringString(ip)},
+			{Key: resp.NewBulkStringString("endpoint"), Value: resp.NewBulkStringString(ip)},
+			{Key: resp.NewBulkStringString("role"), Value: resp.NewBulkStringString("master")},
+			{Key: resp.NewBulkStringString("replication-offset"), Value: resp.NewInteger(h.primary.offset())},
+			{Key: resp.NewBulkStringString("health"), Value: resp.NewBulkStringString("online")},
+		})
+		shard := client.mapReply([]resp.MapItem{
+			{Key: resp.NewBulkStringString("slots"), Value: resp.NewArray([]resp.Value{resp.NewInteger(0), resp.NewInteger(cluster.Slots - 1)})},
+			{Key: resp.NewBulkStringString("nodes"), Value: resp.NewArray([]resp.Value{node})},
+		})
+		return writer.WriteArray([]resp.Value{shard})
+
+	case "SLOTS":
+		return writer.WriteArray([]resp.Value{resp.NewArray([]resp.Value{
+			resp.NewInteger(0),
+			resp.NewInteger(cluster.Slots - 1),
+			resp.NewArray([]resp.Value{
+				resp.NewBulkStringString(ip),
+				resp.NewInteger(int64(port)),
+				resp.NewBulkStringString(h.nodeID),
+			}),
+		})})
+	}
+	return nil
+}
+
+// clusterNodeAddress 返回报告给客户端的节点地址。优先使用客户端连接的本地地址，
+// 即客户端实际连上的地址；监听在 0.0.0.0 等通配地址时监听地址对客户端没有意义
+func (h *RedisHandler) clusterNodeAddress(client *redisClient) (string, int) {
+	ip, port := "", h.tcpPort()
+	if host, localPort, err := net.SplitHostPort(client.laddr); err == nil {
+		ip = host
+		if port == 0 {
+			port, _ = strconv.Atoi(localPort)
+		}
+	}
+	if ip == "" {
+		if info := h.serverInfo.Load(); info != nil {
+			if host, _, err := net.SplitHostPort(info.Address); err == nil {
+				ip = host
+			}
+		}
+	}
+	if parsed := net.ParseIP(ip); parsed == nil || parsed.IsUnspecified() {
+		ip = "127.0.0.1"
+	}
+	return ip, port
+}
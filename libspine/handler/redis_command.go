@@ -0,0 +1,468 @@
+package handler
+
+import (
+	"sort"
+	"spine-go/libspine/common/resp"
+	"strings"
+	"sync/atomic"
+)
+
+// redisCommandFunc 命令处理函数
+type redisCommandFunc func(h *RedisHandler, client *redisClient, command []string, writer *resp.RespWriter) error
+
+// redisCommand 命令元数据，字段含义与 Redis COMMAND 的回复一致
+type redisCommand struct {
+	Name       string   // 小写命令名
+	Arity      int      // 参数个数（含命令名），负数表示至少 -Arity 个，由分发时统一检查
+	Flags      []string // 命令标志，如 write、readonly、fast
+	FirstKey   int      // 第一个键的位置，0 表示没有键
+	LastKey    int      // 最后一个键的位置，-1 表示直到最后一个参数
+	Step       int      // 键之间的步长
+	Categories []string // ACL 分类，如 @read、@string
+	Group      string   // 命令所属分组，用于 COMMAND DOCS
+	Summary    string   // 命令简介，用于 COMMAND DOCS
+	// Subcommands 容器命令的子命令说明，非空时 HELP 子命令的回复由它生成
+	Subcommands []redisSubcommand
+	// Arguments 参数说明，用于 COMMAND DOCS，为空时不输出 arguments 字段
+	Arguments []redisArg
+	handler   redisCommandFunc
+	calls     atomic.Int64 // 执行次数，用于 INFO commandstats 和 /metrics
+	infoReply resp.Value   // COMMAND INFO 的回复，注册时生成
+	// selfPropagating 修改数据的命令自己写入 AOF 和传播给副本，执行时不持有 propagateMu，
+	// 用于需要网络往返的 MIGRATE，避免等待目标服务器时阻塞其他写命令
+	selfPropagating bool
+}
+
+// redisSubcommand 容器命令的子命令说明
+type redisSubcommand struct {
+	Syntax  string // 子命令及参数，如 ENCODING <key>
+	Summary string // 说明，可以包含多行
+}
+
+// redisArg 命令参数说明，字段含义与 Redis COMMAND DOCS 的 arguments 一致
+type redisArg struct {
+	Name      string     // 参数名
+	Type      string     // 参数类型：key、string、integer、unix-time、pure-token、oneof 或 block
+	Token     string     // 参数前的关键字，如 EX，pure-token 类型只有关键字
+	Optional  bool       // 是否可以省略
+	Multiple  bool       // 是否可以重复多次
+	Arguments []redisArg // oneof 和 block 类型的子参数
+}
+
+// keyArg 单个键参数
+var keyArg = redisArg{Name: "key", Type: "key"}
+
+// newCommandTable 创建命令表，键为小写命令名
+func newCommandTable() map[string]*redisCommand {
+	commands := []*redisCommand{
+		{Name: "ping", Arity: -1, Flags: []string{"fast"}, Categories: []string{"@fast", "@connection"},
+			Group: "connection", Summary: "Returns the server's liveliness response.",
+			handler: (*RedisHandler).handlePING},
+		{Name: "echo", Arity: 2, Flags: []string{"fast"}, Categories: []string{"@fast", "@connection"},
+			Group: "connection", Summary: "Returns the given string.",
+			Arguments: []redisArg{{Name: "message", Type: "string"}},
+			handler: (*RedisHandler).handleECHO},
+		{Name: "hello", Arity: -1, Flags: []string{"noscript", "loading", "stale", "fast", "no_auth"}, Categories: []string{"@fast", "@connection"},
+			Group: "connection", Summary: "Handshakes with the Redis server.",
+			handler: (*RedisHandler).handleHELLO},
+		{Name: "auth", Arity: -2, Flags: []string{"noscript", "loading", "stale", "fast", "no_auth", "allow_busy"}, Categories: []string{"@fast", "@connection"},
+			Group: "connection", Summary: "Authenticates the connection.",
+			handler: (*RedisHandler).handleAUTH},
+		{Name: "client", Arity: -2, Flags: []string{"noscript", "loading", "stale"}, Categories: []string{"@slow", "@connection"},
+			Group: "connection", Summary: "A container for client connection commands.",
+			Subcommands: clientSubcommands,
+			handler:     (*RedisHandler).handleCLIENT},
+		{Name: "quit", Arity: -1, Flags: []string{"noscript", "loading", "stale", "fast", "no_auth", "allow_busy"}, Categories: []string{"@fast", "@connection"},
+			Group: "connection", Summary: "Closes the connection.",
+			handler: (*RedisHandler).handleQUIT},
+		{Name: "reset", Arity: 1, Flags: []string{"noscript", "loading", "stale", "fast", "no_auth", "allow_busy"}, Categories: []string{"@fast", "@connection"},
+			Group: "connection", Summary: "Resets the connection.",
+			handler: (*RedisHandler).handleRESET},
+		{Name: "set", Arity: -3, Flags: []string{"write", "denyoom"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@write", "@string", "@slow"},
+			Group: "string", Summary: "Sets the string value of a key, ignoring its type. The key is created if it doesn't exist.",
+			Arguments: setArguments,
+			handler:   (*RedisHandler).handleSET},
+		{Name: "get", Arity: 2, Flags: []string{"readonly", "fast"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@read", "@string", "@fast"},
+			Group: "string", Summary: "Returns the string value of a key.",
+			Arguments: []redisArg{keyArg},
+			handler:   (*RedisHandler).handleGET},
+		{Name: "append", Arity: 3, Flags: []string{"write", "denyoom", "fast"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@write", "@string", "@fast"},
+			Group: "string", Summary: "Appends a string to the value of a key. Creates the key if it doesn't exist.",
+			Arguments: []redisArg{keyArg, {Name: "value", Type: "string"}},
+			handler:   (*RedisHandler).handleAPPEND},
+		{Name: "strlen", Arity: 2, Flags: []string{"readonly", "fast"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@read", "@string", "@fast"},
+			Group: "string", Summary: "Returns the length of a string value.",
+			Arguments: []redisArg{keyArg},
+			handler:   (*RedisHandler).handleSTRLEN},
+		{Name: "getrange", Arity: 4, Flags: []string{"readonly"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@read", "@string", "@slow"},
+			Group: "string", Summary: "Returns a substring of the string stored at a key.",
+			Arguments: rangeArguments,
+			handler:   (*RedisHandler).handleGETRANGE},
+		{Name: "substr", Arity: 4, Flags: []string{"readonly"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@read", "@string", "@slow"},
+			Group: "string", Summary: "Returns a substring from a string value.",
+			Arguments: rangeArguments,
+			handler:   (*RedisHandler).handleGETRANGE},
+		{Name: "setrange", Arity: 4, Flags: []string{"write", "denyoom"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@write", "@string", "@slow"},
+			Group: "string", Summary: "Overwrites a part of a string value with another by an offset. Creates the key if it doesn't exist.",
+			Arguments: []redisArg{keyArg, {Name: "offset", Type: "integer"}, {Name: "value", Type: "string"}},
+			handler:   (*RedisHandler).handleSETRANGE},
+		{Name: "setbit", Arity: 4, Flags: []string{"write", "denyoom"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@write", "@bitmap", "@slow"},
+			Group: "bitmap", Summary: "Sets or clears the bit at offset of the string value. Creates the key if it doesn't exist.",
+			Arguments: []redisArg{keyArg, {Name: "offset", Type: "integer"}, {Name: "value", Type: "integer"}},
+			handler:   (*RedisHandler).handleSETBIT},
+		{Name: "getbit", Arity: 3, Flags: []string{"readonly", "fast"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@read", "@bitmap", "@fast"},
+			Group: "bitmap", Summary: "Returns a bit value by offset.",
+			Arguments: []redisArg{keyArg, {Name: "offset", Type: "integer"}},
+			handler:   (*RedisHandler).handleGETBIT},
+		{Name: "bitcount", Arity: -2, Flags: []string{"readonly"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@read", "@bitmap", "@slow"},
+			Group: "bitmap", Summary: "Counts the number of set bits (population counting) in a string.",
+			Arguments: bitcountArguments,
+			handler:   (*RedisHandler).handleBITCOUNT},
+		{Name: "del", Arity: -2, Flags: []string{"write"}, FirstKey: 1, LastKey: -1, Step: 1, Categories: []string{"@keyspace", "@write", "@slow"},
+			Group: "generic", Summary: "Deletes one or more keys.",
+			handler: (*RedisHandler).handleDEL},
+		{Name: "exists", Arity: -2, Flags: []string{"readonly", "fast"}, FirstKey: 1, LastKey: -1, Step: 1, Categories: []string{"@keyspace", "@read", "@fast"},
+			Group: "generic", Summary: "Determines whether one or more keys exist.",
+			handler: (*RedisHandler).handleEXISTS},
+		{Name: "ttl", Arity: 2, Flags: []string{"readonly", "fast"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@keyspace", "@read", "@fast"},
+			Group: "generic", Summary: "Returns the expiration time in seconds of a key.",
+			handler: (*RedisHandler).handleTTL},
+		{Name: "touch", Arity: -2, Flags: []string{"readonly", "fast"}, FirstKey: 1, LastKey: -1, Step: 1, Categories: []string{"@keyspace", "@read", "@fast"},
+			Group: "generic", Summary: "Returns the number of existing keys out of those specified after updating the time they were last accessed.",
+			handler: (*RedisHandler).handleTOUCH},
+		{Name: "randomkey", Arity: 1, Flags: []string{"readonly"}, Categories: []string{"@keyspace", "@read", "@slow"},
+			Group: "generic", Summary: "Returns a random key name from the database.",
+			handler: (*RedisHandler).handleRANDOMKEY},
+		{Name: "dump", Arity: 2, Flags: []string{"readonly"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@keyspace", "@read", "@slow"},
+			Group: "generic", Summary: "Returns a serialized representation of the value stored at a key.",
+			handler: (*RedisHandler).handleDUMP},
+		{Name: "restore", Arity: -4, Flags: []string{"write", "denyoom"}, FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"@keyspace", "@write", "@slow", "@dangerous"},
+			Group: "generic", Summary: "Creates a key from the serialized representation of a value.",
+			handler: (*RedisHandler).handleRESTORE},
+		{Name: "migrate", Arity: -6, Flags: []string{"write", "movablekeys"}, FirstKey: 3, LastKey: 3, Step: 1, Categories: []string{"@keyspace", "@write", "@slow", "@dangerous"},
+			Group: "generic", Summary: "Atomically transfers a key from one Redis instance to another.",
+			handler: (*RedisHandler).handleMIGRATE, selfPropagating: true},
+		{Name: "flushall", Arity: -1, Flags: []string{"write"}, Categories: []string{"@keyspace", "@write", "@slow", "@dangerous"},
+			Group: "server", Summary: "Removes all keys from all databases.",
+			handler: (*RedisHandler).handleFLUSHALL},
+		{Name: "object", Arity: -2, Flags: []string{"readonly"}, FirstKey: 2, LastKey: 2, Step: 1, Categories: []string{"@keyspace", "@read", "@slow"},
+			Group: "generic", Summary: "A container for object introspection commands.",
+			Subcommands: objectSubcommands,
+			handler:     (*RedisHandler).handleOBJECT},
+		{Name: "debug", Arity: -2, Flags: []string{"admin", "noscript", "loading", "stale"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "A container for debugging commands.",
+			Subcommands: debugSubcommands,
+			handler:     (*RedisHandler).handleDEBUG},
+		{Name: "command", Arity: -1, Flags: []string{"loading", "stale"}, Categories: []string{"@slow", "@connection"},
+			Group: "server", Summary: "Returns detailed information about all commands.",
+			Subcommands: commandSubcommands,
+			handler:     (*RedisHandler).handleCOMMAND},
+		{Name: "acl", Arity: -2, Flags: []string{"admin", "noscript", "loading", "stale"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "A container for Access List Control commands.",
+			Subcommands: aclSubcommands,
+			handler:     (*RedisHandler).handleACL},
+		{Name: "config", Arity: -2, Flags: []string{"admin", "noscript", "loading", "stale"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "A container for server configuration commands.",
+			Subcommands: configSubcommands,
+			handler:     (*RedisHandler).handleCONFIG},
+		{Name: "save", Arity: 1, Flags: []string{"admin", "noscript", "no_async_loading", "no_multi"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "Synchronously saves the database(s) to disk.",
+			handler: (*RedisHandler).handleSAVE},
+		{Name: "bgsave", Arity: -1, Flags: []string{"admin", "noscript", "no_async_loading"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "Asynchronously saves the database(s) to disk.",
+			handler: (*RedisHandler).handleBGSAVE},
+		{Name: "lastsave", Arity: 1, Flags: []string{"loading", "stale", "fast"}, Categories: []string{"@admin", "@fast", "@dangerous"},
+			Group: "server", Summary: "Returns the Unix timestamp of the last successful save to disk.",
+			handler: (*RedisHandler).handleLASTSAVE},
+		{Name: "info", Arity: -1, Flags: []string{"loading", "stale"}, Categories: []string{"@slow", "@dangerous"},
+			Group: "server", Summary: "Returns information and statistics about the server.",
+			handler: (*RedisHandler).handleINFO},
+		{Name: "lolwut", Arity: -1, Flags: []string{"readonly", "fast"}, Categories: []string{"@read", "@fast"},
+			Group: "server", Summary: "Displays computer art and the Redis version.",
+			Arguments: []redisArg{{Name: "version", Type: "integer", Token: "VERSION", Optional: true}},
+			handler: (*RedisHandler).handleLOLWUT},
+		{Name: "dbsize", Arity: 1, Flags: []string{"readonly", "fast"}, Categories: []string{"@keyspace", "@read", "@fast"},
+			Group: "server", Summary: "Returns the number of keys in the database.",
+			handler: (*RedisHandler).handleDBSIZE},
+		{Name: "slowlog", Arity: -2, Flags: []string{"admin", "loading", "stale"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "A container for slow log commands.",
+			Subcommands: slowlogSubcommands,
+			handler:     (*RedisHandler).handleSLOWLOG},
+		{Name: "monitor", Arity: 1, Flags: []string{"admin", "noscript", "loading", "stale"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "Listens for all requests received by the server in real-time.",
+			handler: (*RedisHandler).handleMONITOR},
+		{Name: "wait", Arity: 3, Flags: []string{"noscript"}, Categories: []string{"@slow", "@connection"},
+			Group: "generic", Summary: "Blocks until the asynchronous replication of all preceding write commands sent by the connection is completed.",
+			handler: (*RedisHandler).handleWAIT},
+		{Name: "replicaof", Arity: 3, Flags: []string{"admin", "noscript", "stale", "no_async_loading"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "Configures a server as replica of another, or promotes it to a master.",
+			handler: (*RedisHandler).handleREPLICAOF},
+		{Name: "replconf", Arity: -1, Flags: []string{"admin", "noscript", "loading", "stale", "allow_busy"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "An internal command for configuring the replication stream.",
+			handler: (*RedisHandler).handleREPLCONF},
+		{Name: "psync", Arity: -3, Flags: []string{"admin", "noscript", "no_async_loading", "no_multi"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "An internal command used in replication.",
+			handler: (*RedisHandler).handlePSYNC},
+		{Name: "sync", Arity: 1, Flags: []string{"admin", "noscript", "no_async_loading", "no_multi"}, Categories: []string{"@admin", "@slow", "@dangerous"},
+			Group: "server", Summary: "An internal command used in replication.",
+			handler: (*RedisHandler).handlePSYNC},
+		{Name: "role", Arity: 1, Flags: []string{"noscript", "loading", "stale", "fast"}, Categories: []string{"@admin", "@fast", "@dangerous"},
+			Group: "server", Summary: "Returns the replication role.",
+			handler: (*RedisHandler).handleROLE},
+		{Name: "cluster", Arity: -2, Flags: []string{"loading", "stale"}, Categories: []string{"@slow"},
+			Group: "cluster", Summary: "A container for Redis Cluster commands.",
+			Subcommands: clusterSubcommands,
+			handler:     (*RedisHandler).handleCLUSTER},
+	}
+
+	table := make(map[string]*redisCommand, len(commands))
+	for _, cmd := range commands {
+		cmd.Name = strings.ToLower(cmd.Name)
+		cmd.infoReply = cmd.info()
+		table[cmd.Name] = cmd
+	}
+	return table
+}
+
+// commandListReply 返回按名称排序的命令和 COMMAND 的回复
+func commandListReply(table map[string]*redisCommand) ([]*redisCommand, resp.Value) {
+	commands := make([]*redisCommand, 0, len(table))
+	for _, cmd := range table {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	values := make([]resp.Value, len(commands))
+	for i, cmd := range commands {
+		values[i] = cmd.infoReply
+	}
+	return commands, resp.NewArray(values)
+}
+
+// help 生成 HELP 子命令的回复行，格式与 Redis 一致
+func (c *redisCommand) help() []resp.Value {
+	name := strings.ToUpper(c.Name)
+	lines := []string{name + " <subcommand> [<arg> [value] [opt] ...]. Subcommands are:"}
+	for _, sub := range append(c.Subcommands, redisSubcommand{Syntax: "HELP", Summary: "Print this help."}) {
+		lines = append(lines, sub.Syntax)
+		for _, line := range strings.Split(sub.Summary, "\n") {
+			lines = append(lines, "    "+line)
+		}
+	}
+
+	values := make([]resp.Value, len(lines))
+	for i, line := range lines {
+		values[i] = resp.NewSimpleString(line)
+	}
+	return values
+}
+
+// isHelp 判断是否为容器命令的 HELP 子命令
+func (c *redisCommand) isHelp(command []string) bool {
+	return len(c.Subcommands) > 0 && len(command) == 2 && strings.EqualFold(command[1], "HELP")
+}
+
+// checkArity 判断参数个数（含命令名）是否符合 Arity
+func (c *redisCommand) checkArity(argc int) bool {
+	if c.Arity >= 0 {
+		return argc == c.Arity
+	}
+	return argc >= -c.Arity
+}
+
+// hasFlag 判断命令是否带有指定标志
+func (c *redisCommand) hasFlag(flag string) bool {
+	for _, f := range c.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// modifiesData 判断命令是否会修改数据，决定是否写入 AOF
+func (c *redisCommand) modifiesData() bool {
+	return c.hasFlag("write")
+}
+
+// keys 按 FirstKey、LastKey 和 Step 返回命令参数中的键名，没有键时返回 nil
+func (c *redisCommand) keys(command []string) []string {
+	if c.FirstKey <= 0 || c.FirstKey >= len(command) {
+		return nil
+	}
+	last := c.LastKey
+	if last < 0 {
+		last += len(command)
+	}
+	last = min(last, len(command)-1)
+	var keys []string
+	for i := c.FirstKey; i <= last; i += max(c.Step, 1) {
+		keys = append(keys, command[i])
+	}
+	return keys
+}
+
+// lookupCommand 按名称查找命令，大小写不敏感。分发时传入的命令名已经转为小写，
+// 先直接查表，避免再分配一次小写字符串
+func (h *RedisHandler) lookupCommand(name string) (*redisCommand, bool) {
+	if cmd, exists := h.commands[name]; exists {
+		return cmd, true
+	}
+	cmd, exists := h.commands[strings.ToLower(name)]
+	return cmd, exists
+}
+
+// sortedCommands 返回按名称排序的全部命令，调用方不能修改返回的切片
+func (h *RedisHandler) sortedCommands() []*redisCommand {
+	return h.commandList
+}
+
+// handlePING 处理 PING 命令
+func (h *RedisHandler) handlePING(client *redisClient, command []string, writer *resp.RespWriter) error {
+	return writer.WritePong()
+}
+
+// handleECHO 处理 ECHO 命令，原样返回参数
+// ECHO message
+func (h *RedisHandler) handleECHO(client *redisClient, command []string, writer *resp.RespWriter) error {
+	return writer.WriteBulkStringString(command[1])
+}
+
+// commandSubcommands COMMAND HELP 列出的子命令
+var commandSubcommands = []redisSubcommand{
+	{Syntax: "(no subcommand)", Summary: "Return details about all commands."},
+	{Syntax: "COUNT", Summary: "Return the total number of commands in this server."},
+	{Syntax: "INFO [<command-name> ...]", Summary: "Return details about multiple commands."},
+	{Syntax: "DOCS [<command-name> ...]", Summary: "Return documentation details about multiple commands.\nIf no command names are given, documentation details for all\ncommands are returned."},
+}
+
+// handleCOMMAND 处理 COMMAND 命令
+// COMMAND | COMMAND COUNT | COMMAND INFO name... | COMMAND DOCS [name...]
+func (h *RedisHandler) handleCOMMAND(client *redisClient, command []string, writer *resp.RespWriter) error {
+	if len(command) == 1 {
+		return writer.WriteValue(h.commandReply)
+	}
+
+	switch strings.ToUpper(command[1]) {
+	case "COUNT":
+		if len(command) != 2 {
+			return writer.WriteWrongNumberOfArgumentsError("COMMAND|COUNT")
+		}
+		return writer.WriteInteger(int64(len(h.commands)))
+
+	case "INFO":
+		values := make([]resp.Value, 0, len(command)-2)
+		for _, name := range command[2:] {
+			if cmd, exists := h.lookupCommand(name); exists {
+				values = append(values, cmd.infoReply)
+			} else {
+				values = append(values, resp.NewArray(nil))
+			}
+		}
+		return writer.WriteArray(values)
+
+	case "DOCS":
+		commands := h.sortedCommands()
+		if len(command) > 2 {
+			commands = make([]*redisCommand, 0, len(command)-2)
+			for _, name := range command[2:] {
+				if cmd, exists := h.lookupCommand(name); exists {
+					commands = append(commands, cmd)
+				}
+			}
+		}
+		items := make([]resp.MapItem, 0, len(commands))
+		for _, cmd := range commands {
+			items = append(items, resp.MapItem{
+				Key:   resp.NewBulkStringString(cmd.Name),
+				Value: client.mapReply(cmd.docs(client.mapReply)),
+			})
+		}
+		return writer.WriteValue(client.mapReply(items))
+
+	default:
+		return resp.NewCommandError("unknown subcommand '%s'. Try COMMAND HELP.", command[1])
+	}
+}
+
+// info 返回 COMMAND INFO 格式的命令描述
+func (c *redisCommand) info() resp.Value {
+	return resp.NewArray([]resp.Value{
+		resp.NewBulkStringString(c.Name),
+		resp.NewInteger(int64(c.Arity)),
+		statusArray(c.Flags),
+		resp.NewInteger(int64(c.FirstKey)),
+		resp.NewInteger(int64(c.LastKey)),
+		resp.NewInteger(int64(c.Step)),
+		statusArray(c.Categories),
+		resp.NewArray([]resp.Value{}), // tips
+		resp.NewArray([]resp.Value{}), // key specifications
+		resp.NewArray([]resp.Value{}), // subcommands
+	})
+}
+
+// docs 返回 COMMAND DOCS 格式的命令文档，mapReply 按连接的协议版本生成嵌套的 map
+func (c *redisCommand) docs(mapReply func([]resp.MapItem) resp.Value) []resp.MapItem {
+	items := []resp.MapItem{
+		{Key: resp.NewBulkStringString("summary"), Value: resp.NewBulkStringString(c.Summary)},
+		{Key: resp.NewBulkStringString("group"), Value: resp.NewBulkStringString(c.Group)},
+	}
+	if len(c.Arguments) > 0 {
+		items = append(items, resp.MapItem{Key: resp.NewBulkStringString("arguments"), Value: argumentDocs(c.Arguments, mapReply)})
+	}
+	return items
+}
+
+// argumentDocs 返回参数说明的数组，每个参数是一个 map
+func argumentDocs(args []redisArg, mapReply func([]resp.MapItem) resp.Value) resp.Value {
+	values := make([]resp.Value, len(args))
+	for i, arg := range args {
+		items := []resp.MapItem{
+			{Key: resp.NewBulkStringString("name"), Value: resp.NewBulkStringString(arg.Name)},
+			{Key: resp.NewBulkStringString("type"), Value: resp.NewBulkStringString(arg.Type)},
+		}
+		if arg.Type != "oneof" && arg.Type != "block" {
+			items = append(items, resp.MapItem{Key: resp.NewBulkStringString("display_text"), Value: resp.NewBulkStringString(arg.Name)})
+		}
+		if arg.Token != "" {
+			items = append(items, resp.MapItem{Key: resp.NewBulkStringString("token"), Value: resp.NewBulkStringString(arg.Token)})
+		}
+		var flags []string
+		if arg.Optional {
+			flags = append(flags, "optional")
+		}
+		if arg.Multiple {
+			flags = append(flags, "multiple")
+		}
+		if len(flags) > 0 {
+			items = append(items, resp.MapItem{Key: resp.NewBulkStringString("flags"), Value: statusArray(flags)})
+		}
+		if len(arg.Arguments) > 0 {
+			items = append(items, resp.MapItem{Key: resp.NewBulkStringString("arguments"), Value: argumentDocs(arg.Arguments, mapReply)})
+		}
+		values[i] = mapReply(items)
+	}
+	return resp.NewArray(values)
+}
+
+// mapReply 根据连接的协议版本生成 map 回复，RESP2 下展开为键值交替的数组
+func (c *redisClient) mapReply(items []resp.MapItem) resp.Value {
+	if c.protocol == 3 {
+		return resp.NewMap(items)
+	}
+	values := make([]resp.Value, 0, len(items)*2)
+	for _, item := range items {
+		values = append(values, item.Key, item.Value)
+	}
+	return resp.NewArray(values)
+}
+
+// statusArray 将字符串列表转换为 simple string 数组
+func statusArray(items []string) resp.Value {
+	values := make([]resp.Value, len(items))
+	for i, item := range items {
+		values[i] = resp.NewSimpleString(item)
+	}
+	return resp.NewArray(values)
+}
@@ -0,0 +1,526 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxmemoryPolicies maxmemory-policy 的可选值，与 Redis 一致
+var maxmemoryPolicies = []string{
+	"volatile-lru", "allkeys-lru", "volatile-lfu", "allkeys-lfu",
+	"volatile-random", "allkeys-random", "volatile-ttl", "noeviction",
+}
+
+// defaultProtoMaxMultiBulkLen 请求数组默认最多包含的元素个数
+const defaultProtoMaxMultiBulkLen = 1024 * 1024
+
+// minProtoMaxBulkLen proto-max-bulk-len 的最小值，与 Redis 一致
+const minProtoMaxBulkLen = 1024 * 1024
+
+// runtimeConfig 可通过 CONFIG SET 修改的运行时配置，由 configMu 保护
+type runtimeConfig struct {
+	maxmemory       int64
+	maxmemoryPolicy string
+	requirepass     string
+	masterauth      string        // 作为副本时连接主节点使用的密码
+	timeout         time.Duration // 空闲连接超时，0 表示不超时
+	savePoints      []savePoint   // 自动保存条件，为空表示不自动保存
+	aofPath         string        // appendonly yes 时使用的 AOF 文件路径
+	aofFsync        string
+	file            string // CONFIG REWRITE 写回的配置文件，为空表示没有配置文件
+	// protoMaxBulkLen 请求中批量字符串的最大长度，protoMaxMultiBulkLen 请求数组的最大元素个数，
+	// 超过时回复协议错误并关闭连接，修改后对新连接生效
+	protoMaxBulkLen      int64
+	protoMaxMultiBulkLen int64
+	// replicaReadOnly 作为副本时是否拒绝客户端的写命令
+	replicaReadOnly bool
+}
+
+// savePoint 自动保存条件：距上次保存超过 seconds 秒且至少有 changes 次修改
+type savePoint struct {
+	seconds int64
+	changes int64
+}
+
+// defaultRuntimeConfig 返回默认配置：不限制内存、不自动保存、不超时，副本只读
+func defaultRuntimeConfig() runtimeConfig {
+	return runtimeConfig{
+		maxmemoryPolicy: "noeviction",
+		aofPath:         "appendonly.aof",
+		aofFsync:        AOFFsyncEverySec,
+		// 与 Redis 的默认值一致
+		protoMaxBulkLen:      maxStringLength,
+		protoMaxMultiBulkLen: defaultProtoMaxMultiBulkLen,
+		replicaReadOnly:      true,
+	}
+}
+
+// configParam 一个配置参数，set 校验并立即生效
+type configParam struct {
+	name      string
+	numeric   bool // 值为整数，写入配置文件时使用整数类型
+	immutable bool // 只能在启动时设置，CONFIG SET 拒绝修改
+	get       func(h *RedisHandler) string
+	set       func(h *RedisHandler, value string) error
+}
+
+// configParams 支持的配置参数，按名称排序
+var configParams = []*configParam{
+	{
+		name:      "appendfilename",
+		immutable: true,
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return h.config.aofPath
+		},
+	},
+	{
+		name: "appendfsync",
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return h.config.aofFsync
+		},
+		set: func(h *RedisHandler, value string) error {
+			value = strings.ToLower(value)
+			switch value {
+			case AOFFsyncAlways, AOFFsyncEverySec, AOFFsyncNo:
+			default:
+				return fmt.Errorf("argument(s) must be one of the following: always, everysec, no")
+			}
+			h.configMu.Lock()
+			h.config.aofFsync = value
+			h.configMu.Unlock()
+
+			h.mu.RLock()
+			aof := h.aof
+			h.mu.RUnlock()
+			if aof != nil {
+				return aof.setFsync(value)
+			}
+			return nil
+		},
+	},
+	{
+		name: "appendonly",
+		get: func(h *RedisHandler) string {
+			h.mu.RLock()
+			defer h.mu.RUnlock()
+			return formatYesNo(h.aof != nil)
+		},
+		set: func(h *RedisHandler, value string) error {
+			enabled, err := parseYesNo(value)
+			if err != nil {
+				return err
+			}
+			if !enabled {
+				return h.DisableAOF()
+			}
+			return h.startAOF()
+		},
+	},
+	{
+		name: "masterauth",
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return h.config.masterauth
+		},
+		set: func(h *RedisHandler, value string) error {
+			h.configMu.Lock()
+			defer h.configMu.Unlock()
+			h.config.masterauth = value
+			return nil
+		},
+	},
+	{
+		name:    "maxmemory",
+		numeric: true,
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return strconv.FormatInt(h.config.maxmemory, 10)
+		},
+		set: func(h *RedisHandler, value string) error {
+			n, err := parseMemory(value)
+			if err != nil {
+				return err
+			}
+			h.configMu.Lock()
+			defer h.configMu.Unlock()
+			h.config.maxmemory = n
+			return nil
+		},
+	},
+	{
+		name: "maxmemory-policy",
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return h.config.maxmemoryPolicy
+		},
+		set: func(h *RedisHandler, value string) error {
+			value = strings.ToLower(value)
+			for _, policy := range maxmemoryPolicies {
+				if value == policy {
+					h.configMu.Lock()
+					defer h.configMu.Unlock()
+					h.config.maxmemoryPolicy = value
+					return nil
+				}
+			}
+			return fmt.Errorf("argument(s) must be one of the following: %s", strings.Join(maxmemoryPolicies, ", "))
+		},
+	},
+	{
+		name:    "proto-max-bulk-len",
+		numeric: true,
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return strconv.FormatInt(h.config.protoMaxBulkLen, 10)
+		},
+		set: func(h *RedisHandler, value string) error {
+			n, err := parseMemory(value)
+			if err != nil {
+				return err
+			}
+			if n < minProtoMaxBulkLen {
+				return fmt.Errorf("argument must be between %d and %d inclusive", minProtoMaxBulkLen, int64(1<<63-1))
+			}
+			h.configMu.Lock()
+			defer h.configMu.Unlock()
+			h.config.protoMaxBulkLen = n
+			return nil
+		},
+	},
+	{
+		name:    "proto-max-multibulk-len",
+		numeric: true,
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return strconv.FormatInt(h.config.protoMaxMultiBulkLen, 10)
+		},
+		set: func(h *RedisHandler, value string) error {
+			n, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || n < 1 {
+				return fmt.Errorf("argument must be between 1 and 2147483647 inclusive")
+			}
+			h.configMu.Lock()
+			defer h.configMu.Unlock()
+			h.config.protoMaxMultiBulkLen = n
+			return nil
+		},
+	},
+	{
+		name: "replica-read-only",
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return formatYesNo(h.config.replicaReadOnly)
+		},
+		set: func(h *RedisHandler, value string) error {
+			readOnly, err := parseYesNo(value)
+			if err != nil {
+				return err
+			}
+			h.configMu.Lock()
+			defer h.configMu.Unlock()
+			h.config.replicaReadOnly = readOnly
+			return nil
+		},
+	},
+	{
+		name: "requirepass",
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return h.config.requirepass
+		},
+		set: func(h *RedisHandler, value string) error {
+			h.SetRequirePass(value)
+			return nil
+		},
+	},
+	{
+		name: "save",
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			parts := make([]string, 0, len(h.config.savePoints)*2)
+			for _, point := range h.config.savePoints {
+				parts = append(parts, strconv.FormatInt(point.seconds, 10), strconv.FormatInt(point.changes, 10))
+			}
+			return strings.Join(parts, " ")
+		},
+		set: func(h *RedisHandler, value string) error {
+			points, err := parseSavePoints(value)
+			if err != nil {
+				return err
+			}
+			h.configMu.Lock()
+			h.config.savePoints = points
+			h.configMu.Unlock()
+			if len(points) > 0 {
+				h.startCron()
+			}
+			return nil
+		},
+	},
+	{
+		name:    "slowlog-log-slower-than",
+		numeric: true,
+		get: func(h *RedisHandler) string {
+			h.slowlog.mu.Lock()
+			defer h.slowlog.mu.Unlock()
+			return strconv.FormatInt(h.slowlog.logSlowerThan, 10)
+		},
+		set: func(h *RedisHandler, value string) error {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("argument couldn't be parsed into an integer")
+			}
+			h.SetSlowlogLogSlowerThan(n)
+			return nil
+		},
+	},
+	{
+		name:    "slowlog-max-len",
+		numeric: true,
+		get: func(h *RedisHandler) string {
+			h.slowlog.mu.Lock()
+			defer h.slowlog.mu.Unlock()
+			return strconv.Itoa(h.slowlog.maxLen)
+		},
+		set: func(h *RedisHandler, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("argument must be between 0 and %d inclusive", int64(^uint(0)>>1))
+			}
+			h.SetSlowlogMaxLen(n)
+			return nil
+		},
+	},
+	{
+		name:    "timeout",
+		numeric: true,
+		get: func(h *RedisHandler) string {
+			h.configMu.RLock()
+			defer h.configMu.RUnlock()
+			return strconv.FormatInt(int64(h.config.timeout/time.Second), 10)
+		},
+		set: func(h *RedisHandler, value string) error {
+			n, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || n < 0 {
+				return fmt.Errorf("argument must be between 0 and 2147483647 inclusive")
+			}
+			h.SetClientTimeout(time.Duration(n) * time.Second)
+			return nil
+		},
+	},
+}
+
+// lookupConfigParam 按名称查找配置参数，大小写不敏感
+func lookupConfigParam(name string) (*configParam, bool) {
+	name = strings.ToLower(name)
+	for _, param := range configParams {
+		if param.name == name {
+			return param, true
+		}
+	}
+	return nil, false
+}
+
+// SetConfig 按 CONFIG SET 的规则设置一个配置参数
+func (h *RedisHandler) SetConfig(name, value string) error {
+	param, exists := lookupConfigParam(name)
+	if !exists {
+		return fmt.Errorf("unknown config parameter '%s'", name)
+	}
+	if param.immutable {
+		return fmt.Errorf("can't set immutable config '%s'", name)
+	}
+	return param.set(h, value)
+}
+
+// GetConfig 返回配置参数当前的值
+func (h *RedisHandler) GetConfig(name string) (string, bool) {
+	param, exists := lookupConfigParam(name)
+	if !exists {
+		return "", false
+	}
+	return param.get(h), true
+}
+
+// protoLimits 返回请求大小的限制：批量字符串的最大长度和请求数组的最大元素个数
+func (h *RedisHandler) protoLimits() (maxBulkLen, maxMultiBulkLen int64) {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.config.protoMaxBulkLen, h.config.protoMaxMultiBulkLen
+}
+
+// SetClientTimeout 设置空闲连接超时，超过该时间没有执行命令的连接会被关闭，0 表示不超时
+func (h *RedisHandler) SetClientTimeout(timeout time.Duration) {
+	h.configMu.Lock()
+	h.config.timeout = timeout
+	h.configMu.Unlock()
+	if timeout > 0 {
+		h.startCron()
+	}
+}
+
+// configSubcommands CONFIG HELP 列出的子命令
+var configSubcommands = []redisSubcommand{
+	{Syntax: "GET <pattern>", Summary: "Return parameters matching the glob-like <pattern> and their values."},
+	{Syntax: "SET <directive> <value>", Summary: "Set the configuration <directive> to <value>."},
+	{Syntax: "RESETSTAT", Summary: "Reset statistics reported by the INFO command."},
+	{Syntax: "REWRITE", Summary: "Rewrite the configuration file."},
+}
+
+// handleCONFIG 处理 CONFIG 命令
+// CONFIG GET pattern [pattern ...] | CONFIG SET parameter value [parameter value ...] | CONFIG RESETSTAT | CONFIG REWRITE
+func (h *RedisHandler) handleCONFIG(client *redisClient, command []string, writer *resp.RespWriter) error {
+	switch strings.ToUpper(command[1]) {
+	case "GET":
+		if len(command) < 3 {
+			return writer.WriteWrongNumberOfArgumentsError("CONFIG|GET")
+		}
+		var items []resp.MapItem
+		for _, param := range configParams {
+			for _, pattern := range command[2:] {
+				if matched, _ := path.Match(strings.ToLower(pattern), param.name); matched {
+					items = append(items, resp.MapItem{
+						Key:   resp.NewBulkStringString(param.name),
+						Value: resp.NewBulkStringString(param.get(h)),
+					})
+					break
+				}
+			}
+		}
+		return writer.WriteValue(client.mapReply(items))
+
+	case "SET":
+		if len(command) < 4 || len(command)%2 != 0 {
+			return writer.WriteWrongNumberOfArgumentsError("CONFIG|SET")
+		}
+		// 先检查参数名，避免只应用了一部分参数
+		for i := 2; i < len(command); i += 2 {
+			param, exists := lookupConfigParam(command[i])
+			if !exists {
+				return resp.NewCommandError("Unknown option or number of arguments for CONFIG SET - '%s'", command[i])
+			}
+			if param.immutable {
+				return resp.NewCommandError("CONFIG SET failed (possibly related to argument '%s') - can't set immutable config", command[i])
+			}
+		}
+		// 记录旧值，某个参数设置失败时按相反的顺序恢复已经应用的参数
+		previous := make([]string, 0, len(command)/2-1)
+		for i := 2; i < len(command); i += 2 {
+			value, _ := h.GetConfig(command[i])
+			previous = append(previous, value)
+		}
+		for i := 2; i < len(command); i += 2 {
+			if err := h.SetConfig(command[i], command[i+1]); err != nil {
+				for j := i - 2; j >= 2; j -= 2 {
+					if restoreErr := h.SetConfig(command[j], previous[j/2-1]); restoreErr != nil {
+						log.Printf("CONFIG SET failed to restore '%s': %v", command[j], restoreErr)
+					}
+				}
+				return resp.NewCommandError("CONFIG SET failed (possibly related to argument '%s') - %s", command[i], err)
+			}
+		}
+		return writer.WriteOK()
+
+	case "RESETSTAT":
+		if len(command) != 2 {
+			return writer.WriteWrongNumberOfArgumentsError("CONFIG|RESETSTAT")
+		}
+		h.resetStats()
+		return writer.WriteOK()
+
+	case "REWRITE":
+		if len(command) != 2 {
+			return writer.WriteWrongNumberOfArgumentsError("CONFIG|REWRITE")
+		}
+		if err := h.RewriteConfig(); err != nil {
+			if errors.Is(err, errNoConfigFile) {
+				return resp.NewCommandError("The server is running without a config file")
+			}
+			log.Printf("CONFIG REWRITE failed: %v", err)
+			return resp.NewCommandError("Rewriting config file: %s", err)
+		}
+		return writer.WriteOK()
+
+	default:
+		return resp.NewCommandError("unknown subcommand '%s'. Try CONFIG HELP.", command[1])
+	}
+}
+
+// parseYesNo 解析 yes/no 取值
+func parseYesNo(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "yes":
+		return true, nil
+	case "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("argument must be 'yes' or 'no'")
+}
+
+// formatYesNo 把布尔值格式化为 yes/no
+func formatYesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
+
+// parseMemory 解析内存大小，支持 Redis 的单位：k/m/g 为 1000 的倍数，kb/mb/gb 为 1024 的倍数
+func parseMemory(value string) (int64, error) {
+	lower := strings.ToLower(value)
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{
+		{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30},
+		{"k", 1000}, {"m", 1000 * 1000}, {"g", 1000 * 1000 * 1000}, {"b", 1},
+	} {
+		if strings.HasSuffix(lower, unit.suffix) {
+			lower = strings.TrimSuffix(lower, unit.suffix)
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(lower, 10, 64)
+	if err != nil || n < 0 || n > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("argument must be a memory value")
+	}
+	return n * multiplier, nil
+}
+
+// parseSavePoints 解析 save 参数，格式为 "seconds changes [seconds changes ...]"，空字符串表示关闭自动保存
+func parseSavePoints(value string) ([]savePoint, error) {
+	fields := strings.Fields(value)
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("Invalid save parameters")
+	}
+	points := make([]savePoint, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		seconds, err1 := strconv.ParseInt(fields[i], 10, 64)
+		changes, err2 := strconv.ParseInt(fields[i+1], 10, 64)
+		if err1 != nil || err2 != nil || seconds < 1 || changes < 0 {
+			return nil, fmt.Errorf("Invalid save parameters")
+		}
+		points = append(points, savePoint{seconds: seconds, changes: changes})
+	}
+	return points, nil
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// errNoConfigFile 没有通过 SetConfigFile 指定配置文件
+var errNoConfigFile = errors.New("no config file")
+
+// SetConfigFile 设置 CONFIG REWRITE 写回的配置文件
+func (h *RedisHandler) SetConfigFile(path string) {
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+	h.config.file = path
+}
+
+// RewriteConfig 把 CONFIG 参数的当前值写回配置文件。
+// 配置文件为 TOML 格式，键名与 CONFIG GET 的参数名相同；文件中的其他键（如监听地址）原样保留，注释不会保留
+func (h *RedisHandler) RewriteConfig() error {
+	h.configMu.RLock()
+	path := h.config.file
+	h.configMu.RUnlock()
+	if path == "" {
+		return errNoConfigFile
+	}
+
+	values := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := toml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("parsing %s: %v", path, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	for _, param := range configParams {
+		value := param.get(h)
+		if param.numeric {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return err
+			}
+			values[param.name] = n
+		} else {
+			values[param.name] = value
+		}
+	}
+
+	data, err = toml.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免写到一半时破坏原文件
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := fmt.Sprintf("%s.tmp-%d", path, os.Getpid())
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// resetStats 清零 INFO stats 和 commandstats 的计数，用于 CONFIG RESETSTAT
+func (h *RedisHandler) resetStats() {
+	h.stats.reset()
+	for _, cmd := range h.commands {
+		cmd.calls.Store(0)
+	}
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"log"
+	"time"
+)
+
+// cronInterval 后台任务的检查间隔
+const cronInterval = 100 * time.Millisecond
+
+// serverCron 后台定时任务：关闭空闲超时的连接，满足 save 条件时自动保存快照
+type serverCron struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startCron 启动后台定时任务，已在运行时不做任何事
+func (h *RedisHandler) startCron() {
+	h.lifecycleMu.Lock()
+	defer h.lifecycleMu.Unlock()
+	if h.cron != nil || h.shuttingDown {
+		return
+	}
+
+	cron := &serverCron{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	h.cron = cron
+
+	go func() {
+		defer close(cron.done)
+		ticker := time.NewTicker(cronInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				h.cronTick(now)
+			case <-cron.stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopCron 停止后台定时任务，未启动时不做任何事
+func (h *RedisHandler) stopCron() {
+	h.lifecycleMu.Lock()
+	cron := h.cron
+	h.cron = nil
+	h.lifecycleMu.Unlock()
+
+	if cron != nil {
+		close(cron.stop)
+		<-cron.done
+	}
+}
+
+// cronTick 执行一轮后台任务
+func (h *RedisHandler) cronTick(now time.Time) {
+	h.configMu.RLock()
+	timeout := h.config.timeout
+	savePoints := h.config.savePoints
+	h.configMu.RUnlock()
+
+	if timeout > 0 {
+		h.closeIdleClients(now, timeout)
+	}
+	if len(savePoints) > 0 {
+		h.autoSave(now, savePoints)
+	}
+}
+
+// closeIdleClients 关闭超过 timeout 没有执行命令的连接，与 Redis 一样不关闭 MONITOR 连接
+func (h *RedisHandler) closeIdleClients(now time.Time, timeout time.Duration) {
+	for _, client := range h.clients.list() {
+		h.monitorMu.RLock()
+		_, monitoring := h.monitors[client]
+		h.monitorMu.RUnlock()
+		if monitoring {
+			continue
+		}
+
+		client.mu.Lock()
+		idle := now.Sub(client.lastInteraction)
+		client.mu.Unlock()
+		if idle > timeout {
+			client.kill()
+		}
+	}
+}
+
+// autoSave 满足任一 save 条件且没有保存在进行时开始后台保存
+func (h *RedisHandler) autoSave(now time.Time, savePoints []savePoint) {
+	h.mu.RLock()
+	configured := h.snapshot.path != "" && !h.snapshot.inProgress
+	elapsed := now.Sub(h.snapshot.lastSave)
+	h.mu.RUnlock()
+	if !configured {
+		return
+	}
+
+	dirty := h.snapshot.dirty.Load()
+	for _, point := range savePoints {
+		if dirty >= point.changes && dirty > 0 && elapsed >= time.Duration(point.seconds)*time.Second {
+			log.Printf("%d changes in %d seconds. Saving...", point.changes, point.seconds)
+			if err := h.bgsave(); err != nil {
+				log.Printf("Error starting background save: %v", err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SetDebugCommandEnabled 设置是否允许执行 DEBUG 命令，默认关闭
+func (h *RedisHandler) SetDebugCommandEnabled(enabled bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.debugEnabled = enabled
+}
+
+// debugSubcommands DEBUG HELP 列出的子命令
+var debugSubcommands = []redisSubcommand{
+	{Syntax: "CHANGE-REPL-ID", Summary: "Change the replication IDs of the instance.\nDangerous: should be used only for testing the replication subsystem."},
+	{Syntax: "OBJECT <key>", Summary: "Show low level info about the <key> and associated value."},
+	{Syntax: "RELOAD [NOSAVE] [NOFLUSH]", Summary: "Save the snapshot on disk and reload it back to memory. Options:\n* NOSAVE: the existing snapshot is loaded without saving first.\n* NOFLUSH: the existing keys are not removed before loading, loaded keys\n  overwrite existing ones."},
+	{Syntax: "SET-ACTIVE-EXPIRE <0|1>", Summary: "Setting it to 0 disables expiring keys in background when they are not\naccessed (otherwise the Redis behavior). Setting it to 1 reenables back the\ndefault."},
+	{Syntax: "SLEEP <seconds>", Summary: "Stop the server for <seconds>. Decimals allowed."},
+}
+
+// handleDEBUG 处理 DEBUG 命令
+// DEBUG SLEEP seconds | DEBUG OBJECT key | DEBUG SET-ACTIVE-EXPIRE 0|1 | DEBUG CHANGE-REPL-ID | DEBUG RELOAD [NOSAVE] [NOFLUSH]
+func (h *RedisHandler) handleDEBUG(client *redisClient, command []string, writer *resp.RespWriter) error {
+	h.mu.RLock()
+	enabled := h.debugEnabled
+	h.mu.RUnlock()
+	if !enabled {
+		return resp.NewCommandError("DEBUG command not allowed. Set enable-debug-command in the server configuration and restart the server.")
+	}
+
+	switch strings.ToUpper(command[1]) {
+	case "SLEEP":
+		if len(command) != 3 {
+			return writer.WriteWrongNumberOfArgumentsError("DEBUG|SLEEP")
+		}
+		seconds, err := strconv.ParseFloat(command[2], 64)
+		if err != nil || seconds < 0 {
+			return resp.NewCommandError("value is not a valid float")
+		}
+		// 阻塞当前连接，用于模拟慢命令
+		time.Sleep(time.Duration(seconds * float64(time.Second)))
+		return writer.WriteOK()
+
+	case "OBJECT":
+		if len(command) != 3 {
+			return writer.WriteWrongNumberOfArgumentsError("DEBUG|OBJECT")
+		}
+		item, exists := h.lookupItem(command[2])
+		if !exists {
+			return resp.NewCommandError("no such key")
+		}
+		h.mu.RLock()
+		info := fmt.Sprintf("Value at:%p refcount:1 encoding:%s serializedlength:%d lru_seconds_idle:%d freq:%d",
+			item, item.encoding(), len(item.Value), int64(h.clock.Now().Sub(item.LastAccess).Seconds()), item.Freq)
+		h.mu.RUnlock()
+		return writer.WriteSimpleString(info)
+
+	case "SET-ACTIVE-EXPIRE":
+		if len(command) != 3 {
+			return writer.WriteWrongNumberOfArgumentsError("DEBUG|SET-ACTIVE-EXPIRE")
+		}
+		switch command[2] {
+		case "0":
+			h.activeExpirePaused.Store(true)
+		case "1":
+			h.activeExpirePaused.Store(false)
+		default:
+			return resp.NewSyntaxError()
+		}
+		return writer.WriteOK()
+
+	case "CHANGE-REPL-ID":
+		if len(command) != 2 {
+			return writer.WriteWrongNumberOfArgumentsError("DEBUG|CHANGE-REPL-ID")
+		}
+		// 开始新的复制历史，INFO replication 的 master_replid 随之改变
+		h.primary.resetID()
+		return writer.WriteOK()
+
+	case "RELOAD":
+		save, flush := true, true
+		for _, option := range command[2:] {
+			switch strings.ToUpper(option) {
+			case "NOSAVE":
+				save = false
+			case "NOFLUSH":
+				flush = false
+			default:
+				return resp.NewSyntaxError()
+			}
+		}
+		if err := h.debugReload(save, flush); err != nil {
+			return err
+		}
+		return writer.WriteOK()
+
+	default:
+		return resp.NewCommandError("unknown subcommand '%s'", command[1])
+	}
+}
+
+// debugReload 保存快照后清空数据并重新加载，用于检验快照的保存和加载是否一致。
+// 期间持有 propagateMu 的写锁，阻止其他修改数据的命令在清空和加载之间执行
+func (h *RedisHandler) debugReload(save, flush bool) error {
+	h.propagateMu.Lock()
+	defer h.propagateMu.Unlock()
+
+	var path string
+	if save {
+		var err error
+		if path, err = h.beginSave(); err != nil {
+			return err
+		}
+		if err := h.save(path); err != nil {
+			log.Printf("Error saving snapshot: %v", err)
+			return resp.NewCommandError("Error trying to save the snapshot: %s", err.Error())
+		}
+	} else {
+		h.mu.RLock()
+		path = h.snapshot.path
+		h.mu.RUnlock()
+		if path == "" {
+			return resp.NewCommandError("no snapshot path configured")
+		}
+	}
+
+	if flush {
+		h.mu.Lock()
+		h.store = make(map[string]*RedisItem)
+		h.mu.Unlock()
+	}
+	if _, err := h.replayFile(path); err != nil {
+		log.Printf("Error loading snapshot: %v", err)
+		return resp.NewCommandError("Error trying to load the snapshot: %s", err.Error())
+	}
+	return nil
+}
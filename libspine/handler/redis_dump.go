@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc64"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DUMP 的序列化格式：
+//
+//	类型(1 字节) | 值长度(uvarint) | 值 | 版本(2 字节小端) | CRC64(8 字节小端)
+//
+// 布局与 Redis 的 DUMP 相同（值在前，版本和校验和在末尾），但值的编码是 spine 自己的，
+// 与 RDB 不兼容，只能由 spine 的 RESTORE 读取
+const (
+	dumpTypeString = 0
+	dumpVersion    = 1
+
+	dumpFooterSize = 2 + 8
+)
+
+var dumpCRCTable = crc64.MakeTable(crc64.ECMA)
+
+// dumpValue 序列化字符串值
+func dumpValue(value string) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(dumpTypeString)
+	var length [binary.MaxVarintLen64]byte
+	buf.Write(length[:binary.PutUvarint(length[:], uint64(len(value)))])
+	buf.WriteString(value)
+
+	var footer [dumpFooterSize]byte
+	binary.LittleEndian.PutUint16(footer[:2], dumpVersion)
+	buf.Write(footer[:2])
+	binary.LittleEndian.PutUint64(footer[2:], crc64.Checksum(buf.Bytes(), dumpCRCTable))
+	buf.Write(footer[2:])
+	return buf.Bytes()
+}
+
+// loadDumpValue 校验版本和校验和并还原值
+func loadDumpValue(payload []byte) (string, bool) {
+	if len(payload) < 1+dumpFooterSize {
+		return "", false
+	}
+	body := payload[:len(payload)-8]
+	if binary.LittleEndian.Uint64(payload[len(payload)-8:]) != crc64.Checksum(body, dumpCRCTable) {
+		return "", false
+	}
+	if binary.LittleEndian.Uint16(body[len(body)-2:]) != dumpVersion {
+		return "", false
+	}
+
+	data := body[:len(body)-2]
+	if data[0] != dumpTypeString {
+		return "", false
+	}
+	length, n := binary.Uvarint(data[1:])
+	if n <= 0 || uint64(len(data)-1-n) != length {
+		return "", false
+	}
+	return string(data[1+n:]), true
+}
+
+// handleDUMP 处理 DUMP 命令，返回键的值的序列化结果，键不存在时返回 nil
+func (h *RedisHandler) handleDUMP(client *redisClient, command []string, writer *resp.RespWriter) error {
+	item, exists := h.lookupItem(command[1])
+	if !exists {
+		return writer.WriteNil()
+	}
+	return writer.WriteBulkString(dumpValue(item.Value))
+}
+
+// handleRESTORE 处理 RESTORE 命令
+// RESTORE key ttl serialized-value [REPLACE] [ABSTTL] [IDLETIME seconds] [FREQ frequency]
+func (h *RedisHandler) handleRESTORE(client *redisClient, command []string, writer *resp.RespWriter) error {
+	key := command[1]
+	replace, absTTL := false, false
+	idle, freq := int64(-1), int64(-1)
+	for i := 4; i < len(command); i++ {
+		option := strings.ToUpper(command[i])
+		switch {
+		case option == "REPLACE":
+			replace = true
+		case option == "ABSTTL":
+			absTTL = true
+		case option == "IDLETIME" && i+1 < len(command) && freq < 0:
+			n, err := strconv.ParseInt(command[i+1], 10, 64)
+			if err != nil {
+				return resp.NewCommandError("value is not an integer or out of range")
+			}
+			if n < 0 {
+				return resp.NewCommandError("Invalid IDLETIME value, must be >= 0")
+			}
+			idle = n
+			i++
+		case option == "FREQ" && i+1 < len(command) && idle < 0:
+			n, err := strconv.ParseInt(command[i+1], 10, 64)
+			if err != nil {
+				return resp.NewCommandError("value is not an integer or out of range")
+			}
+			if n < 0 || n > 255 {
+				return resp.NewCommandError("Invalid FREQ value, must be >= 0 and <= 255")
+			}
+			freq = n
+			i++
+		default:
+			return resp.NewSyntaxError()
+		}
+	}
+
+	ttl, err := strconv.ParseInt(command[2], 10, 64)
+	if err != nil {
+		return resp.NewCommandError("value is not an integer or out of range")
+	}
+	if ttl < 0 {
+		return resp.NewCommandError("Invalid TTL value, must be >= 0")
+	}
+
+	value, ok := loadDumpValue([]byte(command[3]))
+	if !ok {
+		return resp.NewCommandError("DUMP payload version or checksum are wrong")
+	}
+
+	now := h.clock.Now()
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := now.Add(time.Duration(ttl) * time.Millisecond)
+		if absTTL {
+			t = time.UnixMilli(ttl)
+		}
+		expiresAt = &t
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.lookupLocked(key) != nil {
+		if !replace {
+			return resp.NewCommandErrorWithCode(resp.ErrCodeBusyKey, "Target key name already exists.")
+		}
+		delete(h.store, key)
+	}
+
+	// 与 Redis 一致，绝对过期时间已过时只删除旧值，不创建键
+	if expiresAt != nil && !now.Before(*expiresAt) {
+		client.rewritten = []string{"DEL", key}
+		return writer.WriteOK()
+	}
+	client.rewritten = setCommand(key, value, expiresAt, now)
+
+	item := &RedisItem{
+		Value:      value,
+		ExpiresAt:  expiresAt,
+		LastAccess: now,
+	}
+	if idle >= 0 {
+		item.LastAccess = now.Add(-time.Duration(idle) * time.Second)
+	}
+	if freq >= 0 {
+		item.Freq = uint8(freq)
+	}
+	h.store[key] = item
+	return writer.WriteOK()
+}
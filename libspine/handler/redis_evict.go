@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"runtime/metrics"
+	"spine-go/libspine/common/resp"
+	"strings"
+)
+
+const (
+	// evictionSamples 每淘汰一个键抽样比较的键数，与 Redis 默认的 maxmemory-samples 一致
+	evictionSamples = 5
+	// itemOverhead 估算键占用内存时，在键和值的长度之外加上的 map 条目和 RedisItem 的开销
+	itemOverhead = 64
+)
+
+// heapObjectsMetric 堆上存活对象占用的字节数，与 INFO 的 used_memory 相同
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// heapMemory 返回堆上存活对象占用的字节数。runtime/metrics 不需要像 ReadMemStats 那样暂停程序，
+// 可以在每个写命令之前调用
+func heapMemory() uint64 {
+	sample := []metrics.Sample{{Name: heapObjectsMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// evictIfNeeded 在执行可能增加内存的命令之前检查 maxmemory：超过时按 maxmemory-policy 淘汰键，
+// 淘汰的键以 DEL 写入 AOF 并传播给副本；策略为 noeviction 或没有可淘汰的键时拒绝命令
+func (h *RedisHandler) evictIfNeeded() error {
+	h.configMu.RLock()
+	limit, policy := h.config.maxmemory, h.config.maxmemoryPolicy
+	h.configMu.RUnlock()
+	if limit <= 0 {
+		return nil
+	}
+	used := int64(h.usedMemory())
+	if used <= limit {
+		return nil
+	}
+
+	if policy != "noeviction" {
+		h.propagateMu.Lock()
+		defer h.propagateMu.Unlock()
+		h.mu.Lock()
+		evicted, freed := h.evictLocked(policy, used-limit)
+		h.mu.Unlock()
+		if len(evicted) > 0 {
+			h.propagateLocked(h.currentAOF(), append([]string{"DEL"}, evicted...))
+		}
+		// 释放的内存要等到下次 GC 才会反映在堆大小上，按估算的键大小判断是否已足够
+		if freed >= used-limit {
+			return nil
+		}
+	}
+	return resp.NewCommandErrorWithCode(resp.ErrCodeOOM, "command not allowed when used memory > 'maxmemory'.")
+}
+
+// evictLocked 按策略淘汰键，直到估算释放的内存达到 target 或没有可淘汰的键，
+// 返回淘汰的键和估算释放的字节数。调用方必须持有写锁
+func (h *RedisHandler) evictLocked(policy string, target int64) ([]string, int64) {
+	var evicted []string
+	var freed int64
+	volatile := strings.HasPrefix(policy, "volatile-")
+	for freed < target {
+		key, item := h.evictionCandidateLocked(policy, volatile)
+		if item == nil {
+			break
+		}
+		delete(h.store, key)
+		h.stats.evictedKeys.Add(1)
+		evicted = append(evicted, key)
+		freed += int64(len(key)+len(item.Value)) + itemOverhead
+	}
+	return evicted, freed
+}
+
+// evictionCandidateLocked 与 Redis 的近似 LRU 相同，抽样 evictionSamples 个键并返回其中最应该淘汰的一个，
+// volatile 为 true 时只考虑带过期时间的键，没有可淘汰的键时返回 nil
+func (h *RedisHandler) evictionCandidateLocked(policy string, volatile bool) (string, *RedisItem) {
+	var bestKey string
+	var best *RedisItem
+	sampled := 0
+	// map 的遍历从随机位置开始，相当于随机抽样
+	for key, item := range h.store {
+		if sampled >= evictionSamples {
+			break
+		}
+		if volatile && item.ExpiresAt == nil {
+			continue
+		}
+		sampled++
+		if best == nil || evictBefore(policy, item, best) {
+			bestKey, best = key, item
+		}
+	}
+	return bestKey, best
+}
+
+// evictBefore 判断按 policy 是否应该先淘汰 a 而不是 b：LRU 比较最近访问时间，LFU 比较访问频率，
+// volatile-ttl 比较过期时间，random 策略直接使用第一个抽样的键
+func evictBefore(policy string, a, b *RedisItem) bool {
+	switch policy {
+	case "allkeys-lru", "volatile-lru":
+		return a.LastAccess.Before(b.LastAccess)
+	case "allkeys-lfu", "volatile-lfu":
+		return a.Freq < b.Freq || a.Freq == b.Freq && a.LastAccess.Before(b.LastAccess)
+	case "volatile-ttl":
+		return a.ExpiresAt.Before(*b.ExpiresAt)
+	}
+	return false
+}
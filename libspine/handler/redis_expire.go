@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"time"
+)
+
+const (
+	// DefaultActiveExpireInterval 默认的主动过期扫描间隔，与 Redis 默认的 hz 10 一致
+	DefaultActiveExpireInterval = 100 * time.Millisecond
+	// DefaultActiveExpireSamples 每轮最多检查的带过期时间的键数
+	DefaultActiveExpireSamples = 20
+
+	// activeExpireScanFactor 每轮最多遍历 samples 倍数的键，避免在没有过期时间的键很多时长时间持锁
+	activeExpireScanFactor = 10
+)
+
+// Clock 判断键是否过期使用的时间来源，测试中可以替换为手动推进的时钟，不必真正等待键过期
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock 使用系统时间的默认时钟
+type systemClock struct{}
+
+// Now 返回当前系统时间
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SetClock 设置判断过期使用的时钟，为 nil 时恢复使用系统时间。只能在处理请求之前调用
+func (h *RedisHandler) SetClock(clock Clock) {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	h.clock = clock
+}
+
+// activeExpirer 后台主动过期任务
+type activeExpirer struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartActiveExpire 启动后台主动过期：每隔 interval 抽样检查 samples 个带过期时间的键并删除已过期的键，
+// 已在运行时按新的参数重新启动
+func (h *RedisHandler) StartActiveExpire(interval time.Duration, samples int) {
+	if interval <= 0 {
+		interval = DefaultActiveExpireInterval
+	}
+	if samples <= 0 {
+		samples = DefaultActiveExpireSamples
+	}
+
+	h.StopActiveExpire()
+
+	expirer := &activeExpirer{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	h.lifecycleMu.Lock()
+	h.expirer = expirer
+	h.lifecycleMu.Unlock()
+
+	go func() {
+		defer close(expirer.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if !h.activeExpirePaused.Load() {
+					h.activeExpireCycle(samples)
+				}
+			case <-expirer.stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopActiveExpire 停止后台主动过期，未启动时不做任何事
+func (h *RedisHandler) StopActiveExpire() {
+	h.lifecycleMu.Lock()
+	expirer := h.expirer
+	h.expirer = nil
+	h.lifecycleMu.Unlock()
+
+	if expirer != nil {
+		close(expirer.stop)
+		<-expirer.done
+	}
+}
+
+// activeExpireCycle 执行一轮主动过期，返回删除的键数。
+// 与 Redis 相同，抽样中超过 1/4 的键已过期时说明过期键较多，继续下一次抽样
+func (h *RedisHandler) activeExpireCycle(samples int) int {
+	removed := 0
+	for {
+		sampled, expired := 0, 0
+
+		h.mu.Lock()
+		now := h.clock.Now()
+		scanned := 0
+		// map 的遍历从随机位置开始，相当于随机抽样
+		for key, item := range h.store {
+			if sampled >= samples || scanned >= samples*activeExpireScanFactor {
+				break
+			}
+			scanned++
+			if item.ExpiresAt == nil {
+				continue
+			}
+			sampled++
+			if item.expired(now) {
+				h.deleteExpiredLocked(key)
+				expired++
+			}
+		}
+		h.mu.Unlock()
+
+		removed += expired
+		if sampled == 0 || expired*4 <= sampled {
+			return removed
+		}
+	}
+}
+
+// expired 键在 now 时是否已过期。与 Redis 一致，晚于过期时间才算过期，恰好在过期时间时键仍然存在
+func (item *RedisItem) expired(now time.Time) bool {
+	return item.ExpiresAt != nil && now.After(*item.ExpiresAt)
+}
+
+// deleteExpiredLocked 删除已过期的键并计入 expired_keys，调用方必须持有写锁
+func (h *RedisHandler) deleteExpiredLocked(key string) {
+	delete(h.store, key)
+	h.stats.expiredKeys.Add(1)
+}
@@ -1,37 +1,112 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
+	"net"
 	"spine-go/libspine/common/resp"
 	"spine-go/libspine/transport"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // RedisItem 存储项结构
 type RedisItem struct {
-	Value     string
-	ExpiresAt *time.Time
+	Value      string
+	ExpiresAt  *time.Time
+	LastAccess time.Time // 最近一次访问时间，用于 OBJECT IDLETIME
+	Freq       uint8     // 访问频率计数，用于 OBJECT FREQ
 }
 
 // RedisHandler Redis 处理器 - 使用内存数据库和 RESP 协议
 type RedisHandler struct {
 	store map[string]*RedisItem
 	mu    sync.RWMutex
-	// Protocol version (2 or 3)
-	protocolVersion int
+	// 是否允许 DEBUG 命令
+	debugEnabled bool
+	// 命令表，键为小写命令名，创建后不再修改
+	commands map[string]*redisCommand
+	// 按名称排序的全部命令和 COMMAND 的回复，随命令表一起生成
+	commandList  []*redisCommand
+	commandReply resp.Value
+	// AOF 持久化，未启用时为 nil
+	aof *aofWriter
+	// SAVE/BGSAVE 快照状态
+	snapshot snapshotState
+	// ACL 用户表，requirepass 即 default 用户的密码
+	acl *aclStore
+	// 已连接的客户端
+	clients *clientRegistry
+	// 直接调用 handleCommand 时使用的连接状态
+	localClient *redisClient
+	// 优雅关闭：正在执行的命令计数与关闭标志
+	lifecycleMu  sync.Mutex
+	inflight     sync.WaitGroup
+	shuttingDown bool
+	// INFO 使用的服务器信息与运行统计
+	serverInfo atomic.Pointer[transport.ServerInfo]
+	stats      serverStats
+	// 慢查询日志
+	slowlog *slowlog
+	// 处于 MONITOR 模式的连接及其待发送的命令
+	monitorMu sync.RWMutex
+	monitors  map[*redisClient]chan string
+	// 后台主动过期任务，未启动时为 nil，由 lifecycleMu 保护
+	expirer *activeExpirer
+	// DEBUG SET-ACTIVE-EXPIRE 0 暂停主动过期，键只在被访问时惰性删除
+	activeExpirePaused atomic.Bool
+	// CONFIG GET/SET 管理的运行时配置
+	configMu sync.RWMutex
+	config   runtimeConfig
+	// 处理空闲超时和自动保存的后台任务，未启动时为 nil，由 lifecycleMu 保护
+	cron *serverCron
+	// 复制后端，WAIT 通过它统计副本的确认情况
+	replication replicationBackend
+	// 作为主节点时的副本和命令流
+	primary *primaryReplication
+	// 需要写入 AOF 或传播给副本时，修改数据的命令持有该锁执行，保证传播顺序与执行顺序一致
+	propagateMu sync.RWMutex
+	// 作为副本时与主节点的连接，为 nil 表示是主节点；replicaofMu 串行化 REPLICAOF
+	master      atomic.Pointer[masterLink]
+	replicaofMu sync.Mutex
+	// 执行主节点传播的命令时使用的连接状态
+	masterClient *redisClient
+	// CLUSTER MYID 等报告的节点 ID，启动时随机生成
+	nodeID string
+	// 判断过期使用的时钟，默认为系统时间
+	clock Clock
+	// 与 maxmemory 比较的已用内存，默认为堆上存活对象的大小
+	usedMemory func() uint64
 }
 
 // NewRedisHandler 创建新的 Redis 处理器
 func NewRedisHandler() *RedisHandler {
-	return &RedisHandler{
+	h := &RedisHandler{
 		store: make(map[string]*RedisItem),
-		protocolVersion: 2, // Default to RESP v2
+		commands: newCommandTable(),
+		snapshot: snapshotState{lastSave: time.Now()},
+		localClient: newRedisClient(),
+		acl: newACLStore(),
+		clients: newClientRegistry(),
+		stats: serverStats{startTime: time.Now()},
+		slowlog: newSlowlog(),
+		monitors: make(map[*redisClient]chan string),
+		config: defaultRuntimeConfig(),
+		clock: systemClock{},
+		usedMemory: heapMemory,
 	}
+	h.commandList, h.commandReply = commandListReply(h.commands)
+	h.nodeID = newReplicationID()
+	h.primary = newPrimaryReplication()
+	h.replication = h.primary
+	h.masterClient = &redisClient{authenticated: true, user: defaultUser, addr: "master", master: true, protocol: 2}
+	return h
 }
 
 // Handle 处理 Redis 请求 - 使用 RESP 协议
@@ -48,17 +123,55 @@ func (h *RedisHandler) Handle(ctx *transport.Context, req transport.Reader, res
 
 	// 创建 RESP 解析器和序列化器
 	respReader := resp.NewRespReader(req)
+	maxBulkLen, maxMultiBulkLen := h.protoLimits()
+	respReader.SetMaxBulkLength(maxBulkLen)
+	respReader.SetMaxMultiBulkLength(maxMultiBulkLen)
 	respWriter := resp.NewRespWriter(res)
+	// 流水线请求的回复先缓存，读完已到达的命令后一次写出
+	respWriter.SetDeferFlush(true)
+	defer respWriter.Flush()
+	// 注册连接，CLIENT KILL 通过关闭读取端断开连接
+	client := newRedisClient()
+	client.closer = req
+	if ctx.ConnInfo != nil && ctx.ConnInfo.Remote != nil {
+		client.addr = ctx.ConnInfo.Remote.String()
+	}
+	if conn, ok := res.(interface{ LocalAddr() net.Addr }); ok {
+		client.laddr = conn.LocalAddr().String()
+	}
+	h.clients.register(client)
+	defer h.clients.unregister(client)
+	defer h.stopMonitor(client)
+	h.stats.connectionsReceived.Add(1)
+	h.setServerInfo(ctx.ServerInfo)
 
 	// 持续处理消息直到连接关闭
 	for {
-		// 解析 RESP 命令
-		value, err := respReader.ReadValue()
+		// 缓冲区中没有待处理的命令时，在阻塞读取之前把回复发出去
+		if respReader.Buffered() == 0 {
+			if err := respWriter.Flush(); err != nil {
+				return nil
+			}
+		}
+
+		// 解析 RESP 命令，不以 * 开头的是 telnet 等方式直接输入的内联命令
+		value, err := respReader.ReadRequest()
 		if err != nil {
 			// 连接关闭或读取错误
 			if err == io.EOF {
 				return nil
 			}
+			// 连接已被关闭（例如服务器关闭或 CLIENT KILL），继续读取只会不断出错
+			var netErr net.Error
+			if client.isKilled() || errors.Is(err, net.ErrClosed) || errors.Is(err, io.ErrClosedPipe) || errors.As(err, &netErr) {
+				return nil
+			}
+			// 与 Redis 一致，声明的长度超过限制时回复协议错误并关闭连接，之后的数据已无法按协议解析
+			if errors.Is(err, resp.ErrLengthLimit) || errors.Is(err, resp.ErrUnbalancedQuotes) {
+				log.Printf("Closing connection from %s: %v", client.addr, err)
+				respWriter.WriteErrorString("ERR", "Protocol error: "+protocolErrorMessage(err))
+				return nil
+			}
 			log.Printf("Error parsing RESP command: %v", err)
 			respWriter.WriteErrorString("ERR", err.Error())
 			continue
@@ -86,77 +199,217 @@ func (h *RedisHandler) Handle(ctx *transport.Context, req transport.Reader, res
 			continue
 		}
 
-		log.Printf("Received Redis command: %v", command)
+		log.Printf("Received Redis command: %v", redactedArgs(command))
+
+		// 服务器关闭中不再执行新命令，连接随后由传输层关闭
+		if !h.beginCommand() {
+			return nil
+		}
 
 		// 处理命令
-		if err := h.handleCommand(command, respWriter); err != nil {
+		if err := h.processCommand(client, command, respWriter); err != nil {
 			log.Printf("Error handling Redis command: %v", err)
 		}
+		h.endCommand()
+
+		// QUIT 的回复由退出时的 Flush 发出，随后传输层关闭连接
+		if client.quitting {
+			return nil
+		}
+		// MONITOR 之后由 serveMonitor 接管连接，PSYNC 之后由 serveReplica 接管连接
+		if client.monitorCh != nil {
+			return h.serveMonitor(client, respReader, respWriter)
+		}
+		if client.replica != nil {
+			return h.serveReplica(client, respReader, respWriter)
+		}
 	}
 }
 
+// protocolErrorMessage 返回协议错误回复中的说明，与 Redis 一致
+func protocolErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, resp.ErrUnbalancedQuotes):
+		return "unbalanced quotes in request"
+	case errors.Is(err, resp.ErrInvalidBulkLength):
+		return "invalid bulk length"
+	case errors.Is(err, resp.ErrInvalidSyntax):
+		return "too big inline request"
+	}
+	return "invalid multibulk length"
+}
+
 // 不再需要 parseRESPCommand 方法，使用 resp.Parser 代替
 
-// handleCommand 处理 Redis 命令
+// handleCommand 使用处理器自身的连接状态处理 Redis 命令
 func (h *RedisHandler) handleCommand(command []string, writer *resp.RespWriter) error {
+	return h.processCommand(h.localClient, command, writer)
+}
+
+// processCommand 处理来自某个连接的 Redis 命令
+func (h *RedisHandler) processCommand(client *redisClient, command []string, writer *resp.RespWriter) error {
 	if len(command) == 0 {
 		return writer.WriteErrorString("ERR", "empty command")
 	}
 
-	cmd := strings.ToUpper(command[0])
-
-	switch cmd {
-	case "PING":
-		return writer.WritePong()
-	case "HELLO":
-		return h.handleHELLO(command, writer)
-	case "SET":
-		return h.handleSET(command, writer)
-	case "GET":
-		return h.handleGET(command, writer)
-	case "DEL":
-		return h.handleDEL(command, writer)
-	case "EXISTS":
-		return h.handleEXISTS(command, writer)
-	case "TTL":
-		return h.handleTTL(command, writer)
-	default:
-		return writer.WriteCommandError(fmt.Sprintf("unknown command '%s'", cmd))
+	// 命令名在这里统一转为小写，之后按小写名称查表
+	name := strings.ToLower(command[0])
+	client.recordCommand(name)
+	start := time.Now()
+	err := h.executeCommand(client, name, command, writer)
+	h.slowlog.record(client, command, time.Since(start))
+	h.stats.commandsProcessed.Add(1)
+	// 命令返回的 CommandError 统一序列化为 RESP 错误回复
+	if cmdErr, ok := resp.AsCommandError(err); ok {
+		return writer.WriteCommandErr(cmdErr)
 	}
+	return err
 }
 
-// handleSET 处理 SET 命令
-func (h *RedisHandler) handleSET(command []string, writer *resp.RespWriter) error {
-	if len(command) < 3 {
-		return writer.WriteWrongNumberOfArgumentsError("SET")
+// executeCommand 根据命令表分发到具体的处理函数，cmd 为小写命令名
+func (h *RedisHandler) executeCommand(client *redisClient, cmd string, command []string, writer *resp.RespWriter) error {
+	redisCmd, exists := h.lookupCommand(cmd)
+	if !exists {
+		return writer.WriteCommandError(fmt.Sprintf("unknown command '%s'", strings.ToUpper(cmd)))
+	}
+	// 与 Redis 一致，参数个数在认证之前检查，处理函数只需检查子命令和可选参数
+	if !redisCmd.checkArity(len(command)) {
+		return resp.NewWrongNumberOfArgumentsError(redisCmd.Name)
+	}
+
+	// 未认证的连接只能执行带 no_auth 标志的命令
+	if !redisCmd.hasFlag("no_auth") && h.authRequired(client) {
+		return resp.NewCommandErrorWithCode(resp.ErrCodeNoAuth, "Authentication required.")
+	}
+	if !h.acl.canRun(client.user, redisCmd) {
+		return resp.NewCommandErrorWithCode(resp.ErrCodeNoPerm, "User %s has no permissions to run the '%s' command", client.user, redisCmd.Name)
+	}
+	if len(redisCmd.keys(command)) > 0 && !h.acl.canAccessKeys(client.user) {
+		return resp.NewCommandErrorWithCode(resp.ErrCodeNoPerm, "No permissions to access a key")
+	}
+
+	// 只读副本只执行主节点传播的写命令
+	if redisCmd.modifiesData() && !client.master && h.master.Load() != nil && h.replicaReadOnly() {
+		return resp.NewCommandErrorWithCode(resp.ErrCodeReadOnly, "You can't write against a read only replica.")
+	}
+
+	// 主节点传播的命令不受 maxmemory 限制，副本的数据由主节点决定
+	if redisCmd.hasFlag("denyoom") && !client.master {
+		if err := h.evictIfNeeded(); err != nil {
+			return err
+		}
 	}
 
+	h.feedMonitors(client, command)
+
+	var err error
+	if redisCmd.isHelp(command) {
+		err = writer.WriteArray(redisCmd.help())
+	} else if redisCmd.modifiesData() {
+		client.unchanged, client.rewritten = false, nil
+		if redisCmd.selfPropagating {
+			err = redisCmd.handler(h, client, command, writer)
+		} else {
+			err = h.executeAndPropagate(redisCmd, client, command, writer)
+		}
+	} else {
+		err = redisCmd.handler(h, client, command, writer)
+	}
+	// 与 Redis 一致，命令执行完成后才计数，INFO 的输出不包含它自己
+	redisCmd.calls.Add(1)
+	if _, rejected := resp.AsCommandError(err); !rejected && redisCmd.modifiesData() && !client.unchanged {
+		h.snapshot.dirty.Add(1)
+	}
+	return err
+}
+
+// handleFLUSHALL 处理 FLUSHALL 命令
+// FLUSHALL [ASYNC | SYNC]
+func (h *RedisHandler) handleFLUSHALL(client *redisClient, command []string, writer *resp.RespWriter) error {
+	if len(command) == 2 {
+		if mode := strings.ToUpper(command[1]); mode != "ASYNC" && mode != "SYNC" {
+			return resp.NewSyntaxError()
+		}
+	} else if len(command) > 2 {
+		return resp.NewSyntaxError()
+	}
+
+	h.mu.Lock()
+	h.store = make(map[string]*RedisItem)
+	h.mu.Unlock()
+	return writer.WriteOK()
+}
+
+// handleSET 处理 SET 命令
+// SET key value [NX | XX] [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds]
+func (h *RedisHandler) handleSET(client *redisClient, command []string, writer *resp.RespWriter) error {
 	key := command[1]
 	value := command[2]
-	var ttl int64 = 0
-
-	// 解析可选的 TTL 参数
-	if len(command) >= 5 && strings.ToUpper(command[3]) == "EX" {
-		var err error
-		ttl, err = strconv.ParseInt(command[4], 10, 64)
-		if err != nil {
-			return writer.WriteErrorString("ERR", "invalid expire time")
+	var expiresAt *time.Time
+	nx, xx := false, false
+
+	// 解析可选的条件和过期时间参数
+	for i := 3; i < len(command); i++ {
+		option := strings.ToUpper(command[i])
+		if option == "NX" || option == "XX" {
+			if nx || xx {
+				return resp.NewSyntaxError()
+			}
+			nx, xx = option == "NX", option == "XX"
+			continue
+		}
+		if expiresAt != nil || i+1 >= len(command) {
+			return resp.NewSyntaxError()
+		}
+		n, err := strconv.ParseInt(command[i+1], 10, 64)
+		if err != nil || n <= 0 {
+			return resp.NewCommandError("invalid expire time")
+		}
+		var t time.Time
+		switch option {
+		case "EX":
+			t = h.clock.Now().Add(time.Duration(n) * time.Second)
+		case "PX":
+			t = h.clock.Now().Add(time.Duration(n) * time.Millisecond)
+		case "EXAT":
+			t = time.Unix(n, 0)
+		case "PXAT":
+			t = time.UnixMilli(n)
+		default:
+			return resp.NewSyntaxError()
 		}
+		expiresAt = &t
+		i++
 	}
 
-	if err := h.set(key, value, ttl); err != nil {
-		return writer.WriteErrorString("ERR", err.Error())
+	ok, err := h.set(key, value, expiresAt, nx, xx)
+	if err != nil {
+		return resp.NewCommandError("%s", err.Error())
+	}
+	// NX/XX 的条件不满足时不设置，返回 nil，也不写入 AOF 和传播给副本
+	if !ok {
+		client.unchanged = true
+		return writer.WriteNil()
 	}
+	client.rewritten = setCommand(key, value, expiresAt, h.clock.Now())
 
 	return writer.WriteOK()
 }
 
-// handleGET 处理 GET 命令
-func (h *RedisHandler) handleGET(command []string, writer *resp.RespWriter) error {
-	if len(command) != 2 {
-		return writer.WriteWrongNumberOfArgumentsError("GET")
+// setCommand 返回无条件设置 key 的 SET 命令，相对过期时间改写为绝对时间，
+// 重放和副本执行时的结果与原命令相同；在 now 时已过期的键改写为 DEL
+func setCommand(key, value string, expiresAt *time.Time, now time.Time) []string {
+	if expiresAt == nil {
+		return []string{"SET", key, value}
+	}
+	if now.After(*expiresAt) {
+		return []string{"DEL", key}
 	}
+	return []string{"SET", key, value, "PXAT", strconv.FormatInt(expiresAt.UnixMilli(), 10)}
+}
 
+// handleGET 处理 GET 命令
+func (h *RedisHandler) handleGET(client *redisClient, command []string, writer *resp.RespWriter) error {
 	key := command[1]
 	value, err := h.get(key)
 	if err != nil {
@@ -167,11 +420,7 @@ func (h *RedisHandler) handleGET(command []string, writer *resp.RespWriter) erro
 }
 
 // handleDEL 处理 DEL 命令
-func (h *RedisHandler) handleDEL(command []string, writer *resp.RespWriter) error {
-	if len(command) < 2 {
-		return writer.WriteWrongNumberOfArgumentsError("DEL")
-	}
-
+func (h *RedisHandler) handleDEL(client *redisClient, command []string, writer *resp.RespWriter) error {
 	deleted := 0
 	for i := 1; i < len(command); i++ {
 		if count, _ := h.delete(command[i]); count > 0 {
@@ -183,11 +432,7 @@ func (h *RedisHandler) handleDEL(command []string, writer *resp.RespWriter) erro
 }
 
 // handleEXISTS 处理 EXISTS 命令
-func (h *RedisHandler) handleEXISTS(command []string, writer *resp.RespWriter) error {
-	if len(command) < 2 {
-		return writer.WriteWrongNumberOfArgumentsError("EXISTS")
-	}
-
+func (h *RedisHandler) handleEXISTS(client *redisClient, command []string, writer *resp.RespWriter) error {
 	exists := 0
 	for i := 1; i < len(command); i++ {
 		if count, _ := h.exists(command[i]); count > 0 {
@@ -199,51 +444,98 @@ func (h *RedisHandler) handleEXISTS(command []string, writer *resp.RespWriter) e
 }
 
 // handleTTL 处理 TTL 命令
-func (h *RedisHandler) handleTTL(command []string, writer *resp.RespWriter) error {
-	if len(command) != 2 {
-		return writer.WriteWrongNumberOfArgumentsError("TTL")
-	}
-
+func (h *RedisHandler) handleTTL(client *redisClient, command []string, writer *resp.RespWriter) error {
 	key := command[1]
 	ttl, _ := h.ttl(key)
 	return writer.WriteInteger(ttl)
 }
 
-// get 获取键值
-func (h *RedisHandler) get(key string) (string, error) {
+// handleRANDOMKEY 处理 RANDOMKEY 命令，从未过期的键中等概率地随机返回一个，没有键时返回 nil
+func (h *RedisHandler) handleRANDOMKEY(client *redisClient, command []string, writer *resp.RespWriter) error {
+	key, ok := h.randomKey()
+	if !ok {
+		return writer.WriteNil()
+	}
+	return writer.WriteBulkStringString(key)
+}
+
+// randomKey 随机选择一个未过期的键。
+// map 的遍历顺序并不均匀，这里用蓄水池抽样：第 n 个键以 1/n 的概率替换已选中的键
+func (h *RedisHandler) randomKey() (string, bool) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
+	now := h.clock.Now()
+	chosen, seen := "", 0
+	for key, item := range h.store {
+		if item.expired(now) {
+			continue
+		}
+		seen++
+		if rand.Intn(seen) == 0 {
+			chosen = key
+		}
+	}
+	return chosen, seen > 0
+}
+
+// get 获取键值
+func (h *RedisHandler) get(key string) (string, error) {
+	// 读取会更新访问元数据，因此需要写锁
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	item, exists := h.store[key]
 	if !exists {
+		h.stats.recordLookup(false)
 		return "", fmt.Errorf("key not found")
 	}
 
 	// 检查是否过期
-	if item.ExpiresAt != nil && time.Now().After(*item.ExpiresAt) {
-		delete(h.store, key)
+	if item.expired(h.clock.Now()) {
+		h.deleteExpiredLocked(key)
+		h.stats.recordLookup(false)
 		return "", fmt.Errorf("key not found")
 	}
 
+	h.stats.recordLookup(true)
+	item.touch(h.clock.Now())
 	return item.Value, nil
 }
 
-// set 设置键值
-func (h *RedisHandler) set(key string, value string, ttl int64) error {
+// lookupLocked 查找未过期的键，已过期的键会被删除，调用方必须持有写锁
+func (h *RedisHandler) lookupLocked(key string) *RedisItem {
+	item, exists := h.store[key]
+	if !exists {
+		return nil
+	}
+	if item.expired(h.clock.Now()) {
+		h.deleteExpiredLocked(key)
+		return nil
+	}
+	return item
+}
+
+// set 设置键值，nx 为 true 时只在键不存在时设置，xx 为 true 时只在键存在时设置，返回是否已设置
+func (h *RedisHandler) set(key string, value string, expiresAt *time.Time, nx, xx bool) (bool, error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	item := &RedisItem{
-		Value: value,
+	if nx || xx {
+		exists := h.lookupLocked(key) != nil
+		if nx && exists || xx && !exists {
+			return false, nil
+		}
 	}
 
-	if ttl > 0 {
-		expiresAt := time.Now().Add(time.Duration(ttl) * time.Second)
-		item.ExpiresAt = &expiresAt
+	item := &RedisItem{
+		Value:      value,
+		LastAccess: h.clock.Now(),
+		ExpiresAt:  expiresAt,
 	}
 
 	h.store[key] = item
-	return nil
+	return true, nil
 }
 
 // delete 删除键
@@ -251,40 +543,33 @@ func (h *RedisHandler) delete(key string) (int64, error) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	_, exists := h.store[key]
-	if exists {
-		delete(h.store, key)
-		return 1, nil
+	// 已过期的键按不存在处理，并计入 expired_keys
+	if h.lookupLocked(key) == nil {
+		return 0, nil
 	}
-	return 0, nil
+	delete(h.store, key)
+	return 1, nil
 }
 
 // exists 检查键是否存在
 func (h *RedisHandler) exists(key string) (int64, error) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-
-	item, exists := h.store[key]
-	if !exists {
-		return 0, nil
-	}
+	// 已过期的键会被删除，因此需要写锁
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	// 检查是否过期
-	if item.ExpiresAt != nil && time.Now().After(*item.ExpiresAt) {
-		delete(h.store, key)
+	if h.lookupLocked(key) == nil {
 		return 0, nil
 	}
-
 	return 1, nil
 }
 
 // ttl 获取键的过期时间
 func (h *RedisHandler) ttl(key string) (int64, error) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	item, exists := h.store[key]
-	if !exists {
+	item := h.lookupLocked(key)
+	if item == nil {
 		return -2, nil // key does not exist
 	}
 
@@ -292,47 +577,81 @@ func (h *RedisHandler) ttl(key string) (int64, error) {
 		return -1, nil // key exists but has no expiration
 	}
 
-	ttl := time.Until(*item.ExpiresAt).Seconds()
-	if ttl <= 0 {
-		delete(h.store, key)
-		return -2, nil
-	}
-
-	return int64(ttl), nil
+	// lookupLocked 已删除过期的键，恰好在过期时间时剩余 0 秒
+	return int64(item.ExpiresAt.Sub(h.clock.Now()).Seconds()), nil
 }
 
 // handleHELLO handles the HELLO command for protocol version negotiation
 // HELLO [protover [AUTH username password] [SETNAME clientname]]
-func (h *RedisHandler) handleHELLO(command []string, writer *resp.RespWriter) error {
-	// Default to current protocol version if not specified
-	protocolVersion := h.protocolVersion
+func (h *RedisHandler) handleHELLO(client *redisClient, command []string, writer *resp.RespWriter) error {
+	// Default to the connection's current protocol version if not specified
+	protocolVersion := client.protocol
 	
 	// Parse protocol version if provided
 	if len(command) >= 2 {
 		ver, err := strconv.Atoi(command[1])
 		if err != nil {
-			return writer.WriteErrorString("ERR", "Protocol version is not an integer or out of range")
+			return resp.NewCommandError("Protocol version is not an integer or out of range")
 		}
 		
 		// Only support versions 2 and 3
 		if ver != 2 && ver != 3 {
-			return writer.WriteErrorString("ERR", "HELLO only supports RESP protocol versions 2 and 3")
+			return resp.NewCommandError("HELLO only supports RESP protocol versions 2 and 3")
 		}
 		
 		protocolVersion = ver
 	}
+
+	// Parse optional AUTH and SETNAME arguments
+	var auth []string
+	name, setName := "", false
+	for i := 2; i < len(command); i++ {
+		switch strings.ToUpper(command[i]) {
+		case "AUTH":
+			if i+2 >= len(command) {
+				return resp.NewSyntaxError()
+			}
+			auth = command[i+1 : i+3]
+			i += 2
+		case "SETNAME":
+			if i+1 >= len(command) {
+				return resp.NewSyntaxError()
+			}
+			name, setName = command[i+1], true
+			i++
+		default:
+			return resp.NewSyntaxError()
+		}
+	}
+
+	if auth != nil {
+		if err := h.authenticate(client, auth[0], auth[1]); err != nil {
+			return err
+		}
+	} else if h.authRequired(client) {
+		return resp.NewCommandErrorWithCode(resp.ErrCodeNoAuth, "HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+	}
+	if setName {
+		if !validClientName(name) {
+			return resp.NewCommandError("Client names cannot contain spaces, newlines or special characters.")
+		}
+		client.setName(name)
+	}
 	
-	// Update handler's protocol version
-	h.protocolVersion = protocolVersion
+	// Update the connection's protocol version
+	client.protocol = protocolVersion
 	
 	// Create response map
 	responseMap := make(map[string]interface{})
 	responseMap["server"] = "spine-go"
-	responseMap["version"] = "1.0.0"
+	responseMap["version"] = serverVersion
 	responseMap["proto"] = protocolVersion
-	responseMap["id"] = 0 // Server ID
+	responseMap["id"] = int(client.id)
 	responseMap["mode"] = "standalone"
 	responseMap["role"] = "master"
+	if h.master.Load() != nil {
+		responseMap["role"] = "replica"
+	}
 	responseMap["modules"] = []interface{}{}
 	
 	// If using RESP v3, return as a map
@@ -401,6 +720,14 @@ func (h *RedisHandler) handleHELLO(command []string, writer *resp.RespWriter) er
 
 // Close 关闭内存数据库连接
 func (h *RedisHandler) Close() error {
+	h.stopCron()
+	h.stopMasterLink()
+
+	// 先关闭 AOF，确保缓冲的命令落盘
+	if err := h.DisableAOF(); err != nil {
+		log.Printf("Error closing AOF: %v", err)
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
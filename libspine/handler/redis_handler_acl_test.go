@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestACLRestrictedUser(t *testing.T) {
+	handler := NewRedisHandler()
+	admin := newRedisClient()
+
+	response := runClientCommand(t, handler, admin, "ACL", "SETUSER", "reader", "on", ">pass", "~*", "+@read", "-@dangerous")
+	require.Equal(t, "OK", response.String)
+	runClientCommand(t, handler, admin, "SET", "key", "value")
+
+	client := newRedisClient()
+	response = runClientCommand(t, handler, client, "AUTH", "reader", "pass")
+	require.Equal(t, "OK", response.String)
+
+	assert.Equal(t, "reader", client.user)
+
+	response = runClientCommand(t, handler, admin, "ACL", "WHOAMI")
+	assert.Equal(t, "default", string(response.Bulk))
+
+	response = runClientCommand(t, handler, client, "GET", "key")
+	assert.Equal(t, "value", string(response.Bulk))
+
+	response = runClientCommand(t, handler, client, "FLUSHALL")
+	assert.Equal(t, resp.DataType(resp.TypeError), response.Type)
+	assert.Equal(t, "NOPERM User reader has no permissions to run the 'flushall' command", response.String)
+
+	response = runClientCommand(t, handler, client, "SET", "key", "other")
+	assert.Contains(t, response.String, "NOPERM")
+
+	// 拒绝执行的命令不会修改数据
+	response = runClientCommand(t, handler, admin, "GET", "key")
+	assert.Equal(t, "value", string(response.Bulk))
+}
+
+func TestACLKeyPermissions(t *testing.T) {
+	handler := NewRedisHandler()
+	admin := newRedisClient()
+	runClientCommand(t, handler, admin, "SET", "key", "value")
+
+	// 没有 ~* 的用户可以执行命令，但不能访问任何键
+	runClientCommand(t, handler, admin, "ACL", "SETUSER", "nokeys", "on", ">pass", "+@all")
+	client := newRedisClient()
+	require.Equal(t, "OK", runClientCommand(t, handler, client, "AUTH", "nokeys", "pass").String)
+	for _, command := range [][]string{
+		{"GET", "key"},
+		{"SET", "key", "other"},
+		{"DEL", "key"},
+		{"OBJECT", "ENCODING", "key"},
+	} {
+		response := runClientCommand(t, handler, client, command...)
+		assert.Equal(t, "NOPERM No permissions to access a key", response.String, command)
+	}
+	assert.Equal(t, "PONG", runClientCommand(t, handler, client, "PING").String)
+	assert.Equal(t, int64(1), runClientCommand(t, handler, client, "DBSIZE").Int)
+	assert.Equal(t, "value", string(runClientCommand(t, handler, admin, "GET", "key").Bulk))
+
+	// allkeys 授权后可以访问，resetkeys 再次收回
+	runClientCommand(t, handler, admin, "ACL", "SETUSER", "nokeys", "allkeys")
+	assert.Equal(t, "value", string(runClientCommand(t, handler, client, "GET", "key").Bulk))
+	runClientCommand(t, handler, admin, "ACL", "SETUSER", "nokeys", "resetkeys")
+	assert.Contains(t, runClientCommand(t, handler, client, "GET", "key").String, "NOPERM")
+}
+
+func TestCommandKeys(t *testing.T) {
+	handler := NewRedisHandler()
+	keys := func(command ...string) []string {
+		cmd, exists := handler.lookupCommand(strings.ToLower(command[0]))
+		require.True(t, exists)
+		return cmd.keys(command)
+	}
+
+	assert.Equal(t, []string{"a"}, keys("SET", "a", "1", "EX", "10"))
+	assert.Equal(t, []string{"a", "b", "c"}, keys("DEL", "a", "b", "c"))
+	assert.Equal(t, []string{"a"}, keys("OBJECT", "ENCODING", "a"))
+	assert.Nil(t, keys("OBJECT", "HELP"))
+	assert.Nil(t, keys("PING"))
+}
+
+func TestACLCommandRulesApplyInOrder(t *testing.T) {
+	handler := NewRedisHandler()
+	admin := newRedisClient()
+
+	runClientCommand(t, handler, admin, "ACL", "SETUSER", "ops", "on", "nopass", "+@all", "-@dangerous", "+flushall")
+
+	client := newRedisClient()
+	require.Equal(t, "OK", runClientCommand(t, handler, client, "AUTH", "ops", "anything").String)
+
+	assert.Equal(t, "OK", runClientCommand(t, handler, client, "FLUSHALL").String)
+	assert.Contains(t, runClientCommand(t, handler, client, "DEBUG", "SLEEP", "0").String, "NOPERM")
+}
+
+func TestACLGetUserListAndDelUser(t *testing.T) {
+	handler := NewRedisHandler()
+	admin := newRedisClient()
+
+	runClientCommand(t, handler, admin, "ACL", "SETUSER", "alice", "on", ">secret", "~*", "+get")
+
+	response := runClientCommand(t, handler, admin, "ACL", "GETUSER", "alice")
+	require.Len(t, response.Array, 8)
+	assert.Equal(t, "flags", string(response.Array[0].Bulk))
+	assert.Equal(t, "on", response.Array[1].Array[0].String)
+	assert.Len(t, response.Array[3].Array, 1)
+	assert.Equal(t, hashPassword("secret"), string(response.Array[3].Array[0].Bulk))
+	assert.Equal(t, "+get", string(response.Array[5].Bulk))
+
+	response = runClientCommand(t, handler, admin, "ACL", "LIST")
+	require.Len(t, response.Array, 2)
+	assert.Equal(t, "user alice on #"+hashPassword("secret")+" ~* +get", string(response.Array[0].Bulk))
+	assert.Equal(t, "user default on nopass ~* +@all", string(response.Array[1].Bulk))
+
+	response = runClientCommand(t, handler, admin, "ACL", "DELUSER", "alice", "missing")
+	assert.Equal(t, int64(1), response.Int)
+
+	response = runClientCommand(t, handler, admin, "ACL", "GETUSER", "alice")
+	assert.True(t, response.IsNil())
+
+	response = runClientCommand(t, handler, admin, "ACL", "DELUSER", "default")
+	assert.Contains(t, response.String, "cannot be removed")
+}
+
+func TestACLSetUserRejectsUnknownRules(t *testing.T) {
+	handler := NewRedisHandler()
+	admin := newRedisClient()
+
+	response := runClientCommand(t, handler, admin, "ACL", "SETUSER", "bob", "on", "+nosuchcommand")
+	assert.Contains(t, response.String, "Unknown command or category name in ACL")
+
+	// 规则有误时用户不会被创建
+	response = runClientCommand(t, handler, admin, "ACL", "GETUSER", "bob")
+	assert.True(t, response.IsNil())
+}
+
+func TestACLPasswordHashRule(t *testing.T) {
+	handler := NewRedisHandler()
+	admin := newRedisClient()
+
+	hash := hashPassword("secret")
+	response := runClientCommand(t, handler, admin, "ACL", "SETUSER", "dave", "on", "#"+strings.ToUpper(hash), "+@all")
+	assert.Contains(t, response.String, "only lowercase hexadecimal characters")
+	response = runClientCommand(t, handler, admin, "ACL", "GETUSER", "dave")
+	assert.True(t, response.IsNil())
+
+	response = runClientCommand(t, handler, admin, "ACL", "SETUSER", "dave", "on", "#"+hash, "+@all")
+	assert.Equal(t, "OK", response.String)
+	response = runClientCommand(t, handler, newRedisClient(), "AUTH", "dave", "secret")
+	assert.Equal(t, "OK", response.String)
+}
+
+func TestACLDisabledUserCannotAuth(t *testing.T) {
+	handler := NewRedisHandler()
+	admin := newRedisClient()
+
+	runClientCommand(t, handler, admin, "ACL", "SETUSER", "carol", "off", ">pass", "+@all")
+
+	client := newRedisClient()
+	response := runClientCommand(t, handler, client, "AUTH", "carol", "pass")
+	assert.Contains(t, response.String, "WRONGPASS")
+}
+
+// bulkStrings 把数组回复转换为字符串切片
+func bulkStrings(value resp.Value) []string {
+	items := make([]string, len(value.Array))
+	for i, item := range value.Array {
+		items[i] = string(item.Bulk)
+	}
+	return items
+}
+
+func TestACLCat(t *testing.T) {
+	handler := NewRedisHandler()
+
+	categories := bulkStrings(runCommand(t, handler, "ACL", "CAT"))
+	for _, category := range []string{"string", "bitmap", "keyspace", "read", "write", "admin", "dangerous", "connection", "fast", "slow"} {
+		assert.Contains(t, categories, category)
+	}
+	assert.IsIncreasing(t, categories)
+
+	stringCommands := bulkStrings(runCommand(t, handler, "ACL", "CAT", "string"))
+	assert.Contains(t, stringCommands, "set")
+	assert.Contains(t, stringCommands, "append")
+	assert.NotContains(t, stringCommands, "setbit")
+	assert.Equal(t, stringCommands, bulkStrings(runCommand(t, handler, "ACL", "CAT", "STRING")))
+
+	assert.Equal(t, "ERR Unknown category 'nosuch'", runCommand(t, handler, "ACL", "CAT", "nosuch").String)
+}
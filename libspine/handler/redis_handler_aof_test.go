@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestAOFRestoresData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+
+	handler := NewRedisHandler()
+	require.NoError(t, handler.EnableAOF(path, AOFFsyncAlways))
+	runCommand(t, handler, "SET", "a", "1")
+	runCommand(t, handler, "SET", "b", "2", "EX", "100")
+	runCommand(t, handler, "SET", "c", "3")
+	runCommand(t, handler, "DEL", "c")
+	// 只读命令不写入 AOF
+	runCommand(t, handler, "GET", "a")
+	require.NoError(t, handler.Close())
+
+	// 模拟重启：新的处理器从 AOF 中恢复数据
+	restarted := NewRedisHandler()
+	require.NoError(t, restarted.EnableAOF(path, AOFFsyncAlways))
+	defer restarted.Close()
+
+	value := runCommand(t, restarted, "GET", "a")
+	assert.Equal(t, "1", string(value.Bulk))
+	value = runCommand(t, restarted, "GET", "b")
+	assert.Equal(t, "2", string(value.Bulk))
+	value = runCommand(t, restarted, "GET", "c")
+	assert.True(t, value.IsNil())
+
+	ttl := runCommand(t, restarted, "TTL", "b")
+	assert.Greater(t, ttl.Int, int64(90))
+	assert.LessOrEqual(t, ttl.Int, int64(100))
+}
+
+func TestAOFSkipsUnsetConditionalSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+
+	handler := NewRedisHandler()
+	require.NoError(t, handler.EnableAOF(path, AOFFsyncAlways))
+	runCommand(t, handler, "SET", "a", "old")
+	runCommand(t, handler, "SET", "b", "old")
+	dirty := handler.snapshot.dirty.Load()
+	// 条件不满足的 SET 不修改数据，不写入 AOF，也不计入 dirty
+	assert.True(t, runCommand(t, handler, "SET", "a", "new", "NX").IsNull)
+	assert.True(t, runCommand(t, handler, "SET", "missing", "value", "XX").IsNull)
+	assert.Equal(t, dirty, handler.snapshot.dirty.Load())
+	// 条件满足的 SET 正常记录
+	assert.Equal(t, "OK", runCommand(t, handler, "SET", "b", "new", "XX").String)
+	assert.Equal(t, "OK", runCommand(t, handler, "SET", "c", "value", "NX", "EX", "100").String)
+	require.NoError(t, handler.Close())
+
+	restarted := NewRedisHandler()
+	require.NoError(t, restarted.EnableAOF(path, AOFFsyncAlways))
+	defer restarted.Close()
+
+	assert.Equal(t, "old", string(runCommand(t, restarted, "GET", "a").Bulk))
+	assert.True(t, runCommand(t, restarted, "GET", "missing").IsNull)
+	assert.Equal(t, "new", string(runCommand(t, restarted, "GET", "b").Bulk))
+	assert.Equal(t, "value", string(runCommand(t, restarted, "GET", "c").Bulk))
+	assert.Greater(t, runCommand(t, restarted, "TTL", "c").Int, int64(90))
+}
+
+func TestAOFLogsOnlyWriteCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+
+	handler := NewRedisHandler()
+	require.NoError(t, handler.EnableAOF(path, AOFFsyncAlways))
+	runCommand(t, handler, "SET", "key", "value", "EX", "10")
+	runCommand(t, handler, "GET", "key")
+	runCommand(t, handler, "EXISTS", "key")
+	// 参数错误的命令不会被执行，也不写入 AOF
+	runCommand(t, handler, "SET", "key", "value", "EX", "abc")
+	require.NoError(t, handler.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	parser := resp.NewParser(bytes.NewReader(data))
+	value, err := parser.Parse()
+	require.NoError(t, err)
+	require.Len(t, value.Array, 5)
+	// 相对过期时间被改写为绝对时间
+	assert.Equal(t, "PXAT", string(value.Array[3].Bulk))
+
+	_, err = parser.Parse()
+	assert.Error(t, err, "expected exactly one command in the AOF file")
+}
+
+// aofCommands 读取 AOF 文件中的全部命令
+func aofCommands(t *testing.T, path string) [][]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	parser := resp.NewParser(bytes.NewReader(data))
+	var commands [][]string
+	for {
+		value, err := parser.Parse()
+		if err != nil {
+			break
+		}
+		command := make([]string, len(value.Array))
+		for i, arg := range value.Array {
+			command[i] = string(arg.Bulk)
+		}
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+func TestAOFLogsExpirationFromArguments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+	clock := newFakeClock()
+	deadline := func(d time.Duration) string {
+		return strconv.FormatInt(clock.Now().Add(d).UnixMilli(), 10)
+	}
+
+	handler := NewRedisHandler()
+	handler.SetClock(clock)
+	runCommand(t, handler, "SET", "src", "value")
+	payload := string(runCommand(t, handler, "DUMP", "src").Bulk)
+	require.NoError(t, handler.EnableAOF(path, AOFFsyncAlways))
+
+	runCommand(t, handler, "SET", "ex", "value", "EX", "100")
+	// 已经过去的绝对过期时间记录为 DEL，不会在重放时变成永久的键
+	runCommand(t, handler, "SET", "past", "value", "EXAT", "1")
+	runCommand(t, handler, "RESTORE", "restored", "5000", payload)
+	runCommand(t, handler, "RESTORE", "gone", "1", payload, "ABSTTL")
+	require.NoError(t, handler.Close())
+
+	assert.Equal(t, [][]string{
+		{"SET", "ex", "value", "PXAT", deadline(100 * time.Second)},
+		{"DEL", "past"},
+		{"SET", "restored", "value", "PXAT", deadline(5 * time.Second)},
+		{"DEL", "gone"},
+	}, aofCommands(t, path))
+
+	restarted := NewRedisHandler()
+	restarted.SetClock(clock)
+	require.NoError(t, restarted.EnableAOF(path, AOFFsyncAlways))
+	defer restarted.Close()
+	assert.Equal(t, int64(100), runCommand(t, restarted, "TTL", "ex").Int)
+	assert.Equal(t, int64(5), runCommand(t, restarted, "TTL", "restored").Int)
+	assert.Equal(t, int64(2), runCommand(t, restarted, "DBSIZE").Int)
+}
+
+func TestAOFTruncatedTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+
+	set, _ := resp.SerializeCommand("SET", "key", "value")
+	data := append(set, []byte("*3\r\n$3\r\nSET\r\n$3\r\nfoo")...)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	handler := NewRedisHandler()
+	require.NoError(t, handler.EnableAOF(path, AOFFsyncNo))
+	defer handler.Close()
+
+	value := runCommand(t, handler, "GET", "key")
+	assert.Equal(t, "value", string(value.Bulk))
+}
+
+func TestReplayRejectsWrongArity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corrupt.aof")
+	set, _ := resp.SerializeCommand("SET", "key", "value")
+	data := append(set, []byte("*2\r\n$3\r\nSET\r\n$1\r\nk\r\n")...)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	message := fmt.Sprintf("bad file format at offset %d: wrong number of arguments for 'set' command", len(set))
+
+	handler := NewRedisHandler()
+	err := handler.EnableAOF(path, AOFFsyncNo)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), message)
+
+	handler = NewRedisHandler()
+	handler.SetSnapshotPath(path)
+	err = handler.LoadSnapshot()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), message)
+
+	handler.SetDebugCommandEnabled(true)
+	reply := runCommand(t, handler, "DEBUG", "RELOAD", "NOSAVE")
+	assert.Equal(t, "ERR Error trying to load the snapshot: "+message, reply.String)
+}
+
+func TestAOFInvalidFsyncPolicy(t *testing.T) {
+	handler := NewRedisHandler()
+	err := handler.EnableAOF(filepath.Join(t.TempDir(), "appendonly.aof"), "sometimes")
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+// runClientCommand 以指定连接的身份执行命令并返回解析后的回复
+func runClientCommand(t *testing.T, handler *RedisHandler, client *redisClient, command ...string) resp.Value {
+	t.Helper()
+	transport := newMockTransport()
+	require.NoError(t, handler.processCommand(client, command, resp.NewRespWriter(transport)))
+	response, err := transport.readResponse()
+	require.NoError(t, err)
+	return response
+}
+
+func TestAuthRequiredBeforeCommands(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetRequirePass("secret")
+	client := newRedisClient()
+
+	response := runClientCommand(t, handler, client, "SET", "key", "value")
+	assert.Equal(t, resp.DataType(resp.TypeError), response.Type)
+	assert.Equal(t, "NOAUTH Authentication required.", response.String)
+
+	response = runClientCommand(t, handler, client, "AUTH", "secret")
+	assert.Equal(t, "OK", response.String)
+
+	response = runClientCommand(t, handler, client, "SET", "key", "value")
+	assert.Equal(t, "OK", response.String)
+
+	// 认证状态只属于当前连接
+	other := newRedisClient()
+	response = runClientCommand(t, handler, other, "GET", "key")
+	assert.Equal(t, "NOAUTH Authentication required.", response.String)
+}
+
+func TestAuthWrongPassword(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetRequirePass("secret")
+	client := newRedisClient()
+
+	response := runClientCommand(t, handler, client, "AUTH", "wrong")
+	assert.Equal(t, "WRONGPASS invalid username-password pair or user is disabled.", response.String)
+
+	response = runClientCommand(t, handler, client, "GET", "key")
+	assert.Equal(t, "NOAUTH Authentication required.", response.String)
+
+	response = runClientCommand(t, handler, client, "AUTH", "default", "secret")
+	assert.Equal(t, "OK", response.String)
+
+	// 认证后再用错误密码 AUTH 会撤销认证
+	response = runClientCommand(t, handler, client, "AUTH", "wrong")
+	assert.Contains(t, response.String, "WRONGPASS")
+	response = runClientCommand(t, handler, client, "GET", "key")
+	assert.Equal(t, "NOAUTH Authentication required.", response.String)
+}
+
+func TestAuthWithoutPasswordConfigured(t *testing.T) {
+	handler := NewRedisHandler()
+	client := newRedisClient()
+
+	response := runClientCommand(t, handler, client, "AUTH", "secret")
+	assert.Equal(t, resp.DataType(resp.TypeError), response.Type)
+	assert.Contains(t, response.String, "without any password configured")
+
+	response = runClientCommand(t, handler, client, "SET", "key", "value")
+	assert.Equal(t, "OK", response.String)
+}
+
+func TestHelloAuth(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetRequirePass("secret")
+	client := newRedisClient()
+
+	response := runClientCommand(t, handler, client, "HELLO", "3")
+	assert.Equal(t, resp.DataType(resp.TypeError), response.Type)
+	assert.Contains(t, response.String, "NOAUTH")
+
+	response = runClientCommand(t, handler, client, "HELLO", "3", "AUTH", "default", "wrong")
+	assert.Contains(t, response.String, "WRONGPASS")
+
+	response = runClientCommand(t, handler, client, "HELLO", "3", "AUTH", "default", "secret", "SETNAME", "app")
+	assert.Equal(t, resp.DataType(resp.TypeMap), response.Type)
+	assert.Equal(t, "app", client.getName())
+
+	response = runClientCommand(t, handler, client, "PING")
+	assert.Equal(t, "PONG", response.String)
+}
+
+func TestCommandLogHidesPasswords(t *testing.T) {
+	var logs bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(output)
+
+	handler := NewRedisHandler()
+	handler.SetRequirePass("secret")
+	conn := dialRedis(t, serveRedis(t, handler))
+	assert.Equal(t, "OK", conn.do(t, "AUTH", "secret").String)
+	assert.Equal(t, resp.DataType(resp.TypeArray), conn.do(t, "HELLO", "2", "AUTH", "default", "secret").Type)
+
+	// 恢复输出时获取 log 的锁，之后读取 logs 不会与服务器协程竞争
+	log.SetOutput(output)
+	assert.Contains(t, logs.String(), "[AUTH (redacted)]")
+	assert.NotContains(t, logs.String(), "secret")
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"testing"
+
+	"spine-go/libspine/common/resp"
+)
+
+// 运行全部基准测试：make bench，或 go test -run '^$' -bench . -benchmem ./libspine/handler
+// 除 ns/op 外，每个基准测试还以 ops/s 报告吞吐量，便于与 redis-benchmark 的结果对照
+
+// benchCommand 第 i 次请求要执行的命令
+type benchCommand func(i int) []string
+
+// discardLogs 处理器为每条命令写日志，基准测试期间丢弃日志
+func discardLogs(b *testing.B) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(output) })
+}
+
+// benchmarkServer 通过本地 TCP 连接向进程内的服务器发送命令，每批 pipeline 条命令，
+// 读完这一批的回复后再发送下一批，与 redis-benchmark -P 相同
+func benchmarkServer(b *testing.B, handler *RedisHandler, pipeline int, command benchCommand) {
+	discardLogs(b)
+	conn, err := net.Dial("tcp", serveRedis(b, handler))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer conn.Close()
+	writer := bufio.NewWriter(conn)
+	parser := resp.NewParser(conn)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for sent := 0; sent < b.N; {
+		batch := min(pipeline, b.N-sent)
+		for i := 0; i < batch; i++ {
+			args := command(sent + i)
+			data, err := resp.SerializeCommand(args[0], args[1:]...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			writer.Write(data)
+		}
+		if err := writer.Flush(); err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < batch; i++ {
+			reply, err := parser.Parse()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if reply.Type == resp.DataType(resp.TypeError) {
+				b.Fatalf("%v: %s", command(sent+i), reply.String)
+			}
+		}
+		sent += batch
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+}
+
+// benchmarkDispatch 不经过网络和协议解析，直接执行命令，衡量命令本身的开销
+func benchmarkDispatch(b *testing.B, handler *RedisHandler, command benchCommand) {
+	discardLogs(b)
+	writer := resp.NewRespWriter(discardWriter{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := handler.handleCommand(command(i), writer); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "ops/s")
+}
+
+// benchKeys 基准测试使用的键数量，与 redis-benchmark -r 类似，键在这个范围内循环
+const benchKeys = 10000
+
+// benchKey 第 i 次请求使用的键
+func benchKey(i int) string {
+	return "key:" + strconv.Itoa(i%benchKeys)
+}
+
+// benchValue redis-benchmark 默认的 3 字节值
+const benchValue = "xxx"
+
+// populate 预先写入 benchKeys 个键，供读命令使用
+func populate(b *testing.B, handler *RedisHandler) {
+	b.Helper()
+	writer := resp.NewRespWriter(discardWriter{})
+	for i := 0; i < benchKeys; i++ {
+		if err := handler.handleCommand([]string{"SET", benchKey(i), benchValue}, writer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPing(b *testing.B) {
+	benchmarkServer(b, NewRedisHandler(), 1, func(int) []string { return []string{"PING"} })
+}
+
+func BenchmarkSet(b *testing.B) {
+	benchmarkServer(b, NewRedisHandler(), 1, func(i int) []string { return []string{"SET", benchKey(i), benchValue} })
+}
+
+func BenchmarkGet(b *testing.B) {
+	handler := NewRedisHandler()
+	populate(b, handler)
+	benchmarkServer(b, handler, 1, func(i int) []string { return []string{"GET", benchKey(i)} })
+}
+
+func BenchmarkAppend(b *testing.B) {
+	benchmarkServer(b, NewRedisHandler(), 1, func(i int) []string { return []string{"APPEND", benchKey(i), "x"} })
+}
+
+func BenchmarkSetex(b *testing.B) {
+	benchmarkServer(b, NewRedisHandler(), 1, func(i int) []string { return []string{"SET", benchKey(i), benchValue, "EX", "100"} })
+}
+
+func BenchmarkPipeline(b *testing.B) {
+	for _, pipeline := range []int{16, 64} {
+		b.Run(fmt.Sprintf("SET/P=%d", pipeline), func(b *testing.B) {
+			benchmarkServer(b, NewRedisHandler(), pipeline, func(i int) []string { return []string{"SET", benchKey(i), benchValue} })
+		})
+		b.Run(fmt.Sprintf("GET/P=%d", pipeline), func(b *testing.B) {
+			handler := NewRedisHandler()
+			populate(b, handler)
+			benchmarkServer(b, handler, pipeline, func(i int) []string { return []string{"GET", benchKey(i)} })
+		})
+	}
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	b.Run("SET", func(b *testing.B) {
+		benchmarkDispatch(b, NewRedisHandler(), func(i int) []string { return []string{"SET", benchKey(i), benchValue} })
+	})
+	b.Run("GET", func(b *testing.B) {
+		handler := NewRedisHandler()
+		populate(b, handler)
+		benchmarkDispatch(b, handler, func(i int) []string { return []string{"GET", benchKey(i)} })
+	})
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestSetBitGrowsString(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "SETBIT", "bits", "100", "1")
+	assert.Equal(t, int64(0), response.Int)
+
+	// 第 100 位位于第 13 个字节，前面用零字节补齐
+	response = runCommand(t, handler, "GET", "bits")
+	assert.Len(t, response.Bulk, 13)
+	assert.Equal(t, byte(0x08), response.Bulk[12])
+
+	assert.Equal(t, int64(1), runCommand(t, handler, "GETBIT", "bits", "100").Int)
+	assert.Equal(t, int64(0), runCommand(t, handler, "GETBIT", "bits", "99").Int)
+	// 超出长度的位读取为 0
+	assert.Equal(t, int64(0), runCommand(t, handler, "GETBIT", "bits", "100000").Int)
+	assert.Equal(t, int64(0), runCommand(t, handler, "GETBIT", "missing", "0").Int)
+
+	// SETBIT 返回原来的值，清零不会缩短字符串
+	assert.Equal(t, int64(1), runCommand(t, handler, "SETBIT", "bits", "100", "0").Int)
+	assert.Len(t, runCommand(t, handler, "GET", "bits").Bulk, 13)
+}
+
+func TestSetBitOnExistingString(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "key", "@") // 0x40
+
+	assert.Equal(t, int64(1), runCommand(t, handler, "GETBIT", "key", "1").Int)
+	runCommand(t, handler, "SETBIT", "key", "2", "1")
+	assert.Equal(t, "`", string(runCommand(t, handler, "GET", "key").Bulk)) // 0x60
+}
+
+func TestSetBitInvalidArguments(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "SETBIT", "key", "-1", "1")
+	assert.Equal(t, resp.DataType(resp.TypeError), response.Type)
+	assert.Equal(t, "ERR bit offset is not an integer or out of range", response.String)
+
+	response = runCommand(t, handler, "SETBIT", "key", "4294967296", "1")
+	assert.Equal(t, "ERR bit offset is not an integer or out of range", response.String)
+
+	response = runCommand(t, handler, "SETBIT", "key", "0", "2")
+	assert.Equal(t, "ERR bit is not an integer or out of range", response.String)
+}
+
+func TestBitCount(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "key", "foobar")
+
+	assert.Equal(t, int64(26), runCommand(t, handler, "BITCOUNT", "key").Int)
+	assert.Equal(t, int64(4), runCommand(t, handler, "BITCOUNT", "key", "0", "0").Int)
+	assert.Equal(t, int64(6), runCommand(t, handler, "BITCOUNT", "key", "1", "1").Int)
+	assert.Equal(t, int64(6), runCommand(t, handler, "BITCOUNT", "key", "1", "1", "BYTE").Int)
+	assert.Equal(t, int64(18), runCommand(t, handler, "BITCOUNT", "key", "1", "-2").Int)
+	assert.Equal(t, int64(0), runCommand(t, handler, "BITCOUNT", "key", "3", "1").Int)
+	assert.Equal(t, int64(0), runCommand(t, handler, "BITCOUNT", "missing").Int)
+}
+
+func TestBitCountBitIndex(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "key", "foobar")
+
+	// 'f' = 0x66 = 01100110，位 5..7 为 110
+	assert.Equal(t, int64(2), runCommand(t, handler, "BITCOUNT", "key", "5", "7", "BIT").Int)
+	assert.Equal(t, int64(4), runCommand(t, handler, "BITCOUNT", "key", "0", "7", "BIT").Int)
+	// 'r' = 0x72 = 01110010，最后 3 位为 010
+	assert.Equal(t, int64(1), runCommand(t, handler, "BITCOUNT", "key", "-3", "-1", "BIT").Int)
+
+	response := runCommand(t, handler, "BITCOUNT", "key", "0")
+	assert.Equal(t, "ERR syntax error", response.String)
+	response = runCommand(t, handler, "BITCOUNT", "key", "0", "1", "WORD")
+	assert.Equal(t, "ERR syntax error", response.String)
+}
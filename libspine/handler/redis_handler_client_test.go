@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+	"spine-go/libspine/transport"
+)
+
+// testConn 通过本地 TCP 连接到 RedisHandler 的测试客户端
+type testConn struct {
+	conn   net.Conn
+	parser *resp.Parser
+}
+
+// serveRedis 在本地端口上用 handler 处理连接，返回监听地址
+func serveRedis(t testing.TB, handler *RedisHandler) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			ctx := &transport.Context{
+				ConnInfo: &transport.ConnInfo{
+					Remote: conn.RemoteAddr(),
+					Reader: conn,
+					Writer: conn,
+				},
+			}
+			go func() {
+				defer conn.Close()
+				handler.Handle(ctx, conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// dialRedis 建立测试连接
+func dialRedis(t *testing.T, address string) *testConn {
+	t.Helper()
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &testConn{conn: conn, parser: resp.NewParser(conn)}
+}
+
+// do 发送命令并读取回复
+func (c *testConn) do(t *testing.T, command ...string) resp.Value {
+	t.Helper()
+	data, err := resp.SerializeCommand(command[0], command[1:]...)
+	require.NoError(t, err)
+	_, err = c.conn.Write(data)
+	require.NoError(t, err)
+
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	value, err := c.parser.Parse()
+	require.NoError(t, err)
+	return value
+}
+
+func TestClientListAndKill(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+
+	first := dialRedis(t, address)
+	second := dialRedis(t, address)
+
+	firstID := first.do(t, "CLIENT", "ID").Int
+	secondID := second.do(t, "CLIENT", "ID").Int
+	assert.NotEqual(t, firstID, secondID)
+
+	assert.Equal(t, "OK", second.do(t, "CLIENT", "SETNAME", "worker").String)
+	assert.Equal(t, "worker", string(second.do(t, "CLIENT", "GETNAME").Bulk))
+
+	list := string(first.do(t, "CLIENT", "LIST").Bulk)
+	lines := strings.Split(strings.TrimSuffix(list, "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "addr="+first.conn.LocalAddr().String())
+	assert.Contains(t, lines[0], "cmd=client")
+	assert.Contains(t, lines[1], "addr="+second.conn.LocalAddr().String())
+	assert.Contains(t, lines[1], "name=worker")
+
+	response := first.do(t, "CLIENT", "KILL", "ID", strconv.FormatInt(secondID, 10))
+	assert.Equal(t, int64(1), response.Int)
+
+	// 被关闭的连接读到 EOF
+	second.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err := second.parser.Parse()
+	assert.Error(t, err)
+
+	require.Eventually(t, func() bool {
+		list := string(first.do(t, "CLIENT", "LIST").Bulk)
+		return strings.Count(list, "\n") == 1
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestClientKillByAddr(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+
+	first := dialRedis(t, address)
+	second := dialRedis(t, address)
+	second.do(t, "PING")
+
+	response := first.do(t, "CLIENT", "KILL", second.conn.LocalAddr().String())
+	assert.Equal(t, "OK", response.String)
+
+	response = first.do(t, "CLIENT", "KILL", "127.0.0.1:1")
+	assert.Equal(t, resp.DataType(resp.TypeError), response.Type)
+	assert.Equal(t, "ERR No such client", response.String)
+
+	// SKIPME 默认跳过自己
+	response = first.do(t, "CLIENT", "KILL", "ADDR", first.conn.LocalAddr().String())
+	assert.Equal(t, int64(0), response.Int)
+}
+
+func TestClientSetNameRejectsSpaces(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "CLIENT", "SETNAME", "bad name")
+	assert.Equal(t, resp.DataType(resp.TypeError), response.Type)
+
+	response = runCommand(t, handler, "CLIENT", "GETNAME")
+	assert.True(t, response.IsNil())
+}
+
+// expectClosed 断言服务器已关闭连接
+func expectClosed(t *testing.T, conn *testConn) {
+	t.Helper()
+	conn.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err := conn.parser.Parse()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestQuitClosesConnectionAfterReply(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetRequirePass("secret")
+	address := serveRedis(t, handler)
+
+	// 未认证也可以 QUIT，流水线中 QUIT 之后的命令不执行
+	conn := dialRedis(t, address)
+	var pipeline []byte
+	for _, command := range [][]string{{"AUTH", "secret"}, {"SET", "before", "1"}, {"QUIT"}, {"SET", "after", "1"}} {
+		data, err := resp.SerializeCommand(command[0], command[1:]...)
+		require.NoError(t, err)
+		pipeline = append(pipeline, data...)
+	}
+	_, err := conn.conn.Write(pipeline)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		value, err := conn.parser.Parse()
+		require.NoError(t, err)
+		assert.Equal(t, "OK", value.String)
+	}
+	expectClosed(t, conn)
+
+	other := dialRedis(t, address)
+	assert.Equal(t, "OK", other.do(t, "QUIT").String)
+	expectClosed(t, other)
+
+	client := newRedisClient()
+	runClientCommand(t, handler, client, "AUTH", "secret")
+	assert.Equal(t, "1", string(runClientCommand(t, handler, client, "GET", "before").Bulk))
+	assert.True(t, runClientCommand(t, handler, client, "GET", "after").IsNil())
+	require.Eventually(t, func() bool { return handler.clients.count() == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestQuitInMonitorMode(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+
+	monitor := dialRedis(t, address)
+	monitor.do(t, "MONITOR")
+	assert.Equal(t, "OK", monitor.do(t, "QUIT").String)
+	expectClosed(t, monitor)
+	require.Eventually(t, func() bool {
+		handler.monitorMu.RLock()
+		defer handler.monitorMu.RUnlock()
+		return len(handler.monitors) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
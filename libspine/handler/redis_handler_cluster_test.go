@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterInfoReportsDisabled(t *testing.T) {
+	handler := NewRedisHandler()
+
+	info := infoFields(string(runCommand(t, handler, "CLUSTER", "INFO").Bulk))
+	assert.Equal(t, "0", info["cluster_enabled"])
+	assert.Equal(t, "ok", info["cluster_state"])
+	assert.Equal(t, "16384", info["cluster_slots_assigned"])
+	assert.Equal(t, "1", info["cluster_known_nodes"])
+
+	assert.Equal(t, "0", infoFields(runInfo(t, handler, "cluster"))["cluster_enabled"])
+}
+
+func TestClusterSlotsCoversAllSlots(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+	conn := dialRedis(t, address)
+	host, port, err := net.SplitHostPort(address)
+	require.NoError(t, err)
+
+	slots := conn.do(t, "CLUSTER", "SLOTS")
+	require.Len(t, slots.Array, 1)
+	slotRange := slots.Array[0].Array
+	require.Len(t, slotRange, 3)
+	assert.Equal(t, int64(0), slotRange[0].Int)
+	assert.Equal(t, int64(16383), slotRange[1].Int)
+	node := slotRange[2].Array
+	require.Len(t, node, 3)
+	assert.Equal(t, host, string(node[0].Bulk))
+	assert.Equal(t, port, strconv.FormatInt(node[1].Int, 10))
+
+	myID := string(conn.do(t, "CLUSTER", "MYID").Bulk)
+	assert.Len(t, myID, 40)
+	assert.Equal(t, myID, string(node[2].Bulk))
+
+	nodes := string(conn.do(t, "CLUSTER", "NODES").Bulk)
+	busPort := strconv.FormatInt(node[1].Int+10000, 10)
+	assert.Equal(t, myID+" "+address+"@"+busPort+" myself,master - 0 0 0 connected 0-16383\n", nodes)
+}
+
+func TestClusterShards(t *testing.T) {
+	handler := NewRedisHandler()
+
+	shards := runCommand(t, handler, "CLUSTER", "SHARDS")
+	require.Len(t, shards.Array, 1)
+	shard := shards.Array[0].Array
+	require.Len(t, shard, 4)
+	assert.Equal(t, "slots", string(shard[0].Bulk))
+	require.Len(t, shard[1].Array, 2)
+	assert.Equal(t, int64(16383), shard[1].Array[1].Int)
+	assert.Equal(t, "nodes", string(shard[2].Bulk))
+	require.Len(t, shard[3].Array, 1)
+
+	fields := make(map[string]string)
+	node := shard[3].Array[0].Array
+	for i := 0; i+1 < len(node); i += 2 {
+		fields[string(node[i].Bulk)] = string(node[i+1].Bulk)
+	}
+	assert.Equal(t, handler.nodeID, fields["id"])
+	assert.Equal(t, "master", fields["role"])
+	assert.Equal(t, "online", fields["health"])
+}
+
+func TestClusterKeySlot(t *testing.T) {
+	handler := NewRedisHandler()
+
+	assert.Equal(t, int64(12182), runCommand(t, handler, "CLUSTER", "KEYSLOT", "foo").Int)
+	following := runCommand(t, handler, "CLUSTER", "KEYSLOT", "{user1000}.following")
+	followers := runCommand(t, handler, "CLUSTER", "KEYSLOT", "{user1000}.followers")
+	assert.Equal(t, following.Int, followers.Int)
+	assert.Equal(t, int64(3443), following.Int)
+
+	response := runCommand(t, handler, "CLUSTER", "KEYSLOT")
+	assert.True(t, strings.HasPrefix(response.String, "ERR wrong number of arguments for 'cluster|keyslot'"), response.String)
+}
+
+func TestClusterErrors(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "CLUSTER", "SLOTS", "extra")
+	assert.True(t, strings.HasPrefix(response.String, "ERR wrong number of arguments for 'cluster|slots'"), response.String)
+	response = runCommand(t, handler, "CLUSTER", "MEET", "127.0.0.1", "7000")
+	assert.Equal(t, "ERR unknown subcommand 'MEET'. Try CLUSTER HELP.", response.String)
+}
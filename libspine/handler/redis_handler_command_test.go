@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+// runCommand 执行命令并解析单个回复
+func runCommand(t *testing.T, handler *RedisHandler, command ...string) resp.Value {
+	t.Helper()
+	transport := newMockTransport()
+	require.NoError(t, handler.handleCommand(command, resp.NewRespWriter(transport)))
+	response, err := transport.readResponse()
+	require.NoError(t, err)
+	return response
+}
+
+func TestCommandCount(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "COMMAND", "COUNT")
+	assert.Equal(t, int64(len(handler.commands)), response.Int)
+
+	response = runCommand(t, handler, "COMMAND")
+	assert.Len(t, response.Array, len(handler.commands))
+}
+
+func TestCommandInfo(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "COMMAND", "INFO", "get", "SET", "nosuchcommand")
+	require.Len(t, response.Array, 3)
+
+	get := response.Array[0]
+	require.GreaterOrEqual(t, len(get.Array), 7)
+	name, _ := get.Array[0].StringValue()
+	assert.Equal(t, "get", name)
+	// GET key：arity 包含命令名本身
+	assert.Equal(t, int64(2), get.Array[1].Int)
+	assert.Equal(t, int64(1), get.Array[3].Int)
+	assert.Equal(t, int64(1), get.Array[4].Int)
+	assert.Equal(t, int64(1), get.Array[5].Int)
+
+	set := response.Array[1]
+	assert.Equal(t, int64(-3), set.Array[1].Int)
+
+	assert.True(t, response.Array[2].IsNil())
+}
+
+func TestCommandDocs(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "COMMAND", "DOCS", "get")
+	require.Len(t, response.Array, 2)
+	name, _ := response.Array[0].StringValue()
+	assert.Equal(t, "get", name)
+
+	docs := response.Array[1].Array
+	require.Len(t, docs, 6)
+	key, _ := docs[0].StringValue()
+	summary, _ := docs[1].StringValue()
+	assert.Equal(t, "summary", key)
+	assert.NotEmpty(t, summary)
+}
+
+func TestCommandLookupIsCaseInsensitive(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "ping")
+	assert.Equal(t, "PONG", response.String)
+
+	response = runCommand(t, handler, "PiNg")
+	assert.Equal(t, "PONG", response.String)
+}
+
+// docsMap 把 RESP3 的 map 回复转换为以键名索引的 map
+func docsMap(t *testing.T, value resp.Value) map[string]resp.Value {
+	t.Helper()
+	require.Equal(t, resp.DataType(resp.TypeMap), value.Type)
+	fields := make(map[string]resp.Value, len(value.Map))
+	for _, item := range value.Map {
+		fields[string(item.Key.Bulk)] = item.Value
+	}
+	return fields
+}
+
+func TestCommandDocsArguments(t *testing.T) {
+	handler := NewRedisHandler()
+	client := newRedisClient()
+	runClientCommand(t, handler, client, "HELLO", "3")
+
+	response := runClientCommand(t, handler, client, "COMMAND", "DOCS", "set")
+	set := docsMap(t, docsMap(t, response)["set"])
+	args := set["arguments"].Array
+	require.Len(t, args, 4)
+
+	key := docsMap(t, args[0])
+	assert.Equal(t, "key", string(key["name"].Bulk))
+	assert.Equal(t, "key", string(key["type"].Bulk))
+
+	// NX/XX 和过期时间都是可选的 oneof，子参数是带关键字的参数
+	tokens := func(arg resp.Value) []string {
+		fields := docsMap(t, arg)
+		assert.Equal(t, "oneof", string(fields["type"].Bulk))
+		require.Len(t, fields["flags"].Array, 1)
+		assert.Equal(t, "optional", fields["flags"].Array[0].String)
+		var tokens []string
+		for _, sub := range fields["arguments"].Array {
+			tokens = append(tokens, string(docsMap(t, sub)["token"].Bulk))
+		}
+		return tokens
+	}
+	assert.Equal(t, []string{"NX", "XX"}, tokens(args[2]))
+	assert.Equal(t, []string{"EX", "PX", "EXAT", "PXAT"}, tokens(args[3]))
+
+	// 没有参数说明的命令不输出 arguments
+	response = runClientCommand(t, handler, client, "COMMAND", "DOCS", "ping")
+	_, exists := docsMap(t, docsMap(t, response)["ping"])["arguments"]
+	assert.False(t, exists)
+}
+
+func TestSetConditions(t *testing.T) {
+	handler := NewRedisHandler()
+
+	assert.Equal(t, "OK", runCommand(t, handler, "SET", "key", "1", "NX").String)
+	assert.True(t, runCommand(t, handler, "SET", "key", "2", "NX").IsNil())
+	assert.Equal(t, "OK", runCommand(t, handler, "SET", "key", "3", "XX", "EX", "100").String)
+	assert.Equal(t, "3", string(runCommand(t, handler, "GET", "key").Bulk))
+	assert.True(t, runCommand(t, handler, "SET", "missing", "1", "XX").IsNil())
+	assert.Equal(t, int64(0), runCommand(t, handler, "EXISTS", "missing").Int)
+	assert.Equal(t, "ERR syntax error", runCommand(t, handler, "SET", "key", "1", "NX", "XX").String)
+}
+
+func TestArityCheckedByDispatcher(t *testing.T) {
+	handler := NewRedisHandler()
+	// 参数个数在认证之前检查
+	handler.SetRequirePass("secret")
+
+	for _, cmd := range handler.sortedCommands() {
+		want := "ERR wrong number of arguments for '" + cmd.Name + "' command"
+		min := cmd.Arity
+		if min < 0 {
+			min = -min
+		}
+		if min > 1 {
+			command := make([]string, min-1)
+			command[0] = cmd.Name
+			for i := 1; i < len(command); i++ {
+				command[i] = "x"
+			}
+			assert.Equal(t, want, runCommand(t, handler, command...).String, "too few arguments")
+		}
+		if cmd.Arity > 0 {
+			command := []string{strings.ToUpper(cmd.Name)}
+			for len(command) <= cmd.Arity {
+				command = append(command, "x")
+			}
+			assert.Equal(t, want, runCommand(t, handler, command...).String, "too many arguments")
+		}
+	}
+
+	// 子命令的参数个数由处理函数检查
+	client := newRedisClient()
+	runClientCommand(t, handler, client, "AUTH", "secret")
+	assert.Equal(t, "ERR wrong number of arguments for 'client|setname' command", runClientCommand(t, handler, client, "CLIENT", "SETNAME").String)
+}
+
+func TestEcho(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "ECHO", "hello world")
+	assert.Equal(t, resp.DataType(resp.TypeBulkString), response.Type)
+	assert.Equal(t, "hello world", string(response.Bulk))
+
+	response = runCommand(t, handler, "ECHO")
+	assert.Equal(t, "ERR wrong number of arguments for 'echo' command", response.String)
+}
+
+func TestLookupCommandResolvesAnyCase(t *testing.T) {
+	handler := NewRedisHandler()
+
+	lower, ok := handler.lookupCommand("get")
+	require.True(t, ok)
+	for _, name := range []string{"GET", "Get", "gEt"} {
+		cmd, ok := handler.lookupCommand(name)
+		require.True(t, ok, name)
+		assert.Same(t, lower, cmd, name)
+	}
+	_, ok = handler.lookupCommand("nosuchcommand")
+	assert.False(t, ok)
+
+	runCommand(t, handler, "SET", "k", "v")
+	for _, name := range []string{"get", "GET", "gEt"} {
+		assert.Equal(t, "v", string(runCommand(t, handler, name, "k").Bulk), name)
+	}
+	assert.Equal(t, int64(3), lower.calls.Load())
+}
+
+func TestCommandReplyIsCached(t *testing.T) {
+	handler := NewRedisHandler()
+
+	first := runCommand(t, handler, "COMMAND")
+	second := runCommand(t, handler, "COMMAND")
+	assert.Equal(t, first, second)
+	assert.Equal(t, "acl", string(first.Array[0].Array[0].Bulk))
+
+	// COMMAND DOCS 按名称筛选时不能修改缓存的命令列表
+	runCommand(t, handler, "COMMAND", "DOCS", "set")
+	assert.Equal(t, first, runCommand(t, handler, "COMMAND"))
+}
+
+// BenchmarkLookupCommand 查找命令的耗时与命令表的大小无关
+func BenchmarkLookupCommand(b *testing.B) {
+	for _, size := range []int{10, 1000, 100000} {
+		b.Run(fmt.Sprintf("commands=%d", size), func(b *testing.B) {
+			handler := NewRedisHandler()
+			for i := len(handler.commands); i < size; i++ {
+				name := fmt.Sprintf("cmd%d", i)
+				handler.commands[name] = &redisCommand{Name: name, Arity: 1}
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, ok := handler.lookupCommand("get"); !ok {
+					b.Fatal("get not found")
+				}
+				if _, ok := handler.lookupCommand("GET"); !ok {
+					b.Fatal("GET not found")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+// configGet 执行 CONFIG GET 并返回 parameter -> value
+func configGet(t *testing.T, handler *RedisHandler, patterns ...string) map[string]string {
+	t.Helper()
+	reply := runCommand(t, handler, append([]string{"CONFIG", "GET"}, patterns...)...)
+	require.Equal(t, resp.DataType(resp.TypeArray), reply.Type, reply.String)
+	require.Zero(t, len(reply.Array)%2)
+	values := make(map[string]string)
+	for i := 0; i < len(reply.Array); i += 2 {
+		values[string(reply.Array[i].Bulk)] = string(reply.Array[i+1].Bulk)
+	}
+	return values
+}
+
+// sortedKeys 返回排序后的参数名
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestConfigSetAndGetMaxmemory(t *testing.T) {
+	handler := NewRedisHandler()
+	assert.Equal(t, map[string]string{"maxmemory": "0"}, configGet(t, handler, "maxmemory"))
+
+	assert.Equal(t, "OK", runCommand(t, handler, "CONFIG", "SET", "maxmemory", "100mb").String)
+	assert.Equal(t, map[string]string{"maxmemory": "104857600"}, configGet(t, handler, "maxmemory"))
+	assert.Equal(t, "104857600", infoFields(runInfo(t, handler, "memory"))["maxmemory"])
+
+	runCommand(t, handler, "CONFIG", "SET", "maxmemory", "2k", "maxmemory-policy", "ALLKEYS-LRU")
+	assert.Equal(t, map[string]string{"maxmemory": "2000", "maxmemory-policy": "allkeys-lru"},
+		configGet(t, handler, "maxmemory*"))
+
+	reply := runCommand(t, handler, "CONFIG", "SET", "maxmemory", "lots")
+	assert.Equal(t, "ERR CONFIG SET failed (possibly related to argument 'maxmemory') - argument must be a memory value", reply.String)
+	reply = runCommand(t, handler, "CONFIG", "SET", "maxmemory", "1", "nosuchparam", "1")
+	assert.Equal(t, "ERR Unknown option or number of arguments for CONFIG SET - 'nosuchparam'", reply.String)
+	// 参数名有误时不应用任何参数
+	assert.Equal(t, "2000", configGet(t, handler, "maxmemory")["maxmemory"])
+}
+
+func TestConfigSetRollsBackOnFailure(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "CONFIG", "SET", "maxmemory", "1mb", "timeout", "30")
+
+	reply := runCommand(t, handler, "CONFIG", "SET", "maxmemory", "2mb", "timeout", "60", "maxmemory-policy", "sometimes")
+	assert.Equal(t, "ERR CONFIG SET failed (possibly related to argument 'maxmemory-policy') - argument(s) must be one of the following: "+
+		"volatile-lru, allkeys-lru, volatile-lfu, allkeys-lfu, volatile-random, allkeys-random, volatile-ttl, noeviction", reply.String)
+	// 已经应用的参数恢复为原来的值
+	assert.Equal(t, map[string]string{"maxmemory": "1048576", "maxmemory-policy": "noeviction", "timeout": "30"},
+		configGet(t, handler, "maxmemory*", "timeout"))
+	handler.stopCron()
+}
+
+func TestConfigGetPatterns(t *testing.T) {
+	handler := NewRedisHandler()
+
+	all := configGet(t, handler, "*")
+	for _, param := range configParams {
+		assert.Contains(t, all, param.name)
+	}
+	assert.Equal(t, []string{"slowlog-log-slower-than", "slowlog-max-len"}, sortedKeys(configGet(t, handler, "slowlog-*")))
+	assert.Equal(t, []string{"save", "timeout"}, sortedKeys(configGet(t, handler, "TIMEOUT", "save")))
+	assert.Empty(t, configGet(t, handler, "nosuchparam"))
+}
+
+func TestConfigSetAppliesLive(t *testing.T) {
+	handler := NewRedisHandler()
+
+	runCommand(t, handler, "CONFIG", "SET", "slowlog-max-len", "5")
+	assert.Equal(t, 5, handler.slowlog.maxLen)
+
+	runCommand(t, handler, "CONFIG", "SET", "requirepass", "secret")
+	value, _ := handler.GetConfig("requirepass")
+	assert.Equal(t, "secret", value)
+	client := newRedisClient()
+	assert.Equal(t, "NOAUTH Authentication required.", runClientCommand(t, handler, client, "GET", "key").String)
+	runClientCommand(t, handler, client, "AUTH", "secret")
+	runClientCommand(t, handler, client, "CONFIG", "SET", "requirepass", "")
+	assert.False(t, handler.authRequired(newRedisClient()))
+	assert.Equal(t, "", configGet(t, handler, "requirepass")["requirepass"])
+
+	reply := runCommand(t, handler, "CONFIG", "SET", "save", "60")
+	assert.Equal(t, "ERR CONFIG SET failed (possibly related to argument 'save') - Invalid save parameters", reply.String)
+	runCommand(t, handler, "CONFIG", "SET", "save", "3600 1 300 100")
+	assert.Equal(t, "3600 1 300 100", configGet(t, handler, "save")["save"])
+	handler.stopCron()
+}
+
+func TestConfigSetAppendonly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+	handler := NewRedisHandler()
+	handler.config.aofPath = path
+	defer handler.Close()
+
+	runCommand(t, handler, "SET", "before", "1")
+	assert.Equal(t, "OK", runCommand(t, handler, "CONFIG", "SET", "appendonly", "yes").String)
+	assert.Equal(t, "yes", configGet(t, handler, "appendonly")["appendonly"])
+	runCommand(t, handler, "SET", "after", "2")
+	runCommand(t, handler, "CONFIG", "SET", "appendonly", "no")
+	runCommand(t, handler, "SET", "notlogged", "3")
+
+	// 开启时写入的已有数据和之后的命令都在 AOF 中
+	restarted := NewRedisHandler()
+	require.NoError(t, restarted.EnableAOF(path, AOFFsyncAlways))
+	defer restarted.Close()
+	assert.Equal(t, "1", string(runCommand(t, restarted, "GET", "before").Bulk))
+	assert.Equal(t, "2", string(runCommand(t, restarted, "GET", "after").Bulk))
+	assert.True(t, runCommand(t, restarted, "GET", "notlogged").IsNil())
+}
+
+func TestConfigTimeoutClosesIdleClients(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+	idle := dialRedis(t, address)
+	monitor := dialRedis(t, address)
+	idle.do(t, "PING")
+	monitor.do(t, "MONITOR")
+
+	handler.SetClientTimeout(time.Second)
+	defer handler.stopCron()
+	assert.Equal(t, "1", configGet(t, handler, "timeout")["timeout"])
+
+	handler.cronTick(time.Now().Add(2 * time.Second))
+	idle.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err := idle.parser.Parse()
+	assert.Error(t, err, "idle client should be closed")
+
+	// MONITOR 连接不受超时影响
+	assert.Equal(t, 1, handler.clients.count())
+}
+
+func TestSavePointsTriggerBackgroundSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.spine")
+	handler := NewRedisHandler()
+	handler.SetSnapshotPath(path)
+	runCommand(t, handler, "CONFIG", "SET", "save", "60 2")
+	handler.stopCron()
+
+	runCommand(t, handler, "SET", "a", "1")
+	later := time.Now().Add(time.Minute)
+	handler.cronTick(later)
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "one change is below the save point")
+
+	runCommand(t, handler, "SET", "b", "2")
+	runCommand(t, handler, "GET", "b") // 只读命令不计入修改次数
+	assert.Equal(t, int64(2), handler.snapshot.dirty.Load())
+	handler.cronTick(later)
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil && handler.snapshot.dirty.Load() == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestConfigResetStat(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "key", "value")
+	runCommand(t, handler, "GET", "key")
+	runCommand(t, handler, "GET", "missing")
+
+	assert.Equal(t, "OK", runCommand(t, handler, "CONFIG", "RESETSTAT").String)
+	stats := infoFields(runInfo(t, handler, "stats"))
+	assert.Equal(t, "0", stats["keyspace_hits"])
+	assert.Equal(t, "0", stats["keyspace_misses"])
+	assert.NotContains(t, runInfo(t, handler, "commandstats"), "cmdstat_get")
+	// 数据不受影响
+	assert.Equal(t, "value", string(runCommand(t, handler, "GET", "key").Bulk))
+}
+
+func TestConfigRewrite(t *testing.T) {
+	handler := NewRedisHandler()
+	reply := runCommand(t, handler, "CONFIG", "REWRITE")
+	assert.Equal(t, "ERR The server is running without a config file", reply.String)
+
+	path := filepath.Join(t.TempDir(), "spine.toml")
+	require.NoError(t, os.WriteFile(path, []byte("# listen = \"tcp://:6379\"\nmode = \"redis\"\ntimeout = 0\n"), 0644))
+	handler.SetConfigFile(path)
+	runCommand(t, handler, "CONFIG", "SET", "maxmemory", "1mb", "slowlog-max-len", "7", "save", "900 1")
+	handler.stopCron()
+	assert.Equal(t, "OK", runCommand(t, handler, "CONFIG", "REWRITE").String)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	text := string(data)
+	// 其他键保留，数值参数写为整数
+	assert.Contains(t, text, "mode = 'redis'")
+	assert.Contains(t, text, "maxmemory = 1048576")
+	assert.Contains(t, text, "slowlog-max-len = 7")
+	assert.Contains(t, text, "save = '900 1'")
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestDebugDisabledByDefault(t *testing.T) {
+	transport := newMockTransport()
+	handler := NewRedisHandler()
+
+	require.NoError(t, handler.handleCommand([]string{"DEBUG", "SLEEP", "0"}, resp.NewRespWriter(transport)))
+	assert.True(t, strings.HasPrefix(transport.writeBuf.String(), "-ERR DEBUG command not allowed"))
+}
+
+func TestDebugSleep(t *testing.T) {
+	transport := newMockTransport()
+	handler := NewRedisHandler()
+	handler.SetDebugCommandEnabled(true)
+
+	start := time.Now()
+	require.NoError(t, handler.handleCommand([]string{"DEBUG", "SLEEP", "0.2"}, resp.NewRespWriter(transport)))
+	elapsed := time.Since(start)
+
+	assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond)
+	assert.Equal(t, "+OK\r\n", transport.writeBuf.String())
+}
+
+func TestDebugSleepInvalidArgument(t *testing.T) {
+	transport := newMockTransport()
+	handler := NewRedisHandler()
+	handler.SetDebugCommandEnabled(true)
+
+	require.NoError(t, handler.handleCommand([]string{"DEBUG", "SLEEP", "abc"}, resp.NewRespWriter(transport)))
+	assert.Equal(t, "-ERR value is not a valid float\r\n", transport.writeBuf.String())
+}
+
+func TestDebugObject(t *testing.T) {
+	transport := newMockTransport()
+	handler := NewRedisHandler()
+	handler.SetDebugCommandEnabled(true)
+	respWriter := resp.NewRespWriter(transport)
+
+	require.NoError(t, handler.handleCommand([]string{"SET", "key", "hello"}, respWriter))
+	transport.writeBuf.Reset()
+
+	require.NoError(t, handler.handleCommand([]string{"DEBUG", "OBJECT", "key"}, respWriter))
+	response, err := transport.readResponse()
+	require.NoError(t, err)
+	assert.Equal(t, byte(resp.TypeSimpleString), byte(response.Type))
+	assert.Contains(t, response.String, "encoding:embstr")
+	assert.Contains(t, response.String, "serializedlength:5")
+
+	transport.writeBuf.Reset()
+	require.NoError(t, handler.handleCommand([]string{"DEBUG", "OBJECT", "missing"}, respWriter))
+	assert.Equal(t, "-ERR no such key\r\n", transport.writeBuf.String())
+}
+
+func TestDebugChangeReplID(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetDebugCommandEnabled(true)
+
+	before := infoFields(runInfo(t, handler, "replication"))["master_replid"]
+	assert.Equal(t, "OK", runCommand(t, handler, "DEBUG", "CHANGE-REPL-ID").String)
+	after := infoFields(runInfo(t, handler, "replication"))["master_replid"]
+	assert.Len(t, after, 40)
+	assert.NotEqual(t, before, after)
+}
+
+func TestDebugReload(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetDebugCommandEnabled(true)
+	handler.SetSnapshotPath(filepath.Join(t.TempDir(), "dump.snapshot"))
+
+	binary := "\x00\xff\r\n$3\r\n"
+	runCommand(t, handler, "SET", "plain", "value")
+	runCommand(t, handler, "SET", "binary", binary)
+	runCommand(t, handler, "SET", "volatile", "soon", "PX", "100000")
+	expiresAt := *handler.store["volatile"].ExpiresAt
+
+	assert.Equal(t, "OK", runCommand(t, handler, "DEBUG", "RELOAD").String)
+	assert.Equal(t, "value", string(runCommand(t, handler, "GET", "plain").Bulk))
+	assert.Equal(t, binary, string(runCommand(t, handler, "GET", "binary").Bulk))
+	assert.Equal(t, int64(-1), runCommand(t, handler, "TTL", "plain").Int)
+	// 过期时间以毫秒精度的绝对时间保存
+	require.NotNil(t, handler.store["volatile"].ExpiresAt)
+	assert.Equal(t, expiresAt.UnixMilli(), handler.store["volatile"].ExpiresAt.UnixMilli())
+
+	// NOSAVE 加载已有快照，之后写入的键被丢弃；NOFLUSH 保留快照中没有的键
+	runCommand(t, handler, "SET", "unsaved", "value")
+	assert.Equal(t, "OK", runCommand(t, handler, "DEBUG", "RELOAD", "NOSAVE").String)
+	assert.Equal(t, int64(0), runCommand(t, handler, "EXISTS", "unsaved").Int)
+	runCommand(t, handler, "SET", "unsaved", "value")
+	assert.Equal(t, "OK", runCommand(t, handler, "DEBUG", "RELOAD", "NOSAVE", "NOFLUSH").String)
+	assert.Equal(t, int64(4), runCommand(t, handler, "DBSIZE").Int)
+
+	assert.Equal(t, "ERR syntax error", runCommand(t, handler, "DEBUG", "RELOAD", "MERGE").String)
+}
+
+func TestDebugReloadWithoutSnapshotPath(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetDebugCommandEnabled(true)
+	runCommand(t, handler, "SET", "key", "value")
+
+	assert.Equal(t, "ERR no snapshot path configured", runCommand(t, handler, "DEBUG", "RELOAD").String)
+	assert.Equal(t, "ERR no snapshot path configured", runCommand(t, handler, "DEBUG", "RELOAD", "NOSAVE").String)
+	assert.Equal(t, int64(1), runCommand(t, handler, "EXISTS", "key").Int)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestDumpRestoreRoundTrip(t *testing.T) {
+	handler := NewRedisHandler()
+
+	for _, value := range []string{"", "12345", "hello\r\nworld\x00\xff", string(make([]byte, 1000))} {
+		runCommand(t, handler, "SET", "src", value)
+		payload := runCommand(t, handler, "DUMP", "src")
+		require.Equal(t, resp.DataType(resp.TypeBulkString), payload.Type)
+
+		reply := runCommand(t, handler, "RESTORE", "dst", "0", string(payload.Bulk), "REPLACE")
+		require.Equal(t, "OK", reply.String)
+		assert.Equal(t, value, string(runCommand(t, handler, "GET", "dst").Bulk))
+		assert.Equal(t, int64(-1), runCommand(t, handler, "TTL", "dst").Int)
+	}
+
+	// DUMP 不导出过期时间，由 RESTORE 的 ttl 参数指定
+	payload := runCommand(t, handler, "DUMP", "src")
+	runCommand(t, handler, "RESTORE", "withttl", "100000", string(payload.Bulk))
+	ttl := runCommand(t, handler, "TTL", "withttl").Int
+	assert.Greater(t, ttl, int64(90))
+	assert.LessOrEqual(t, ttl, int64(100))
+
+	abs := strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10)
+	runCommand(t, handler, "RESTORE", "absttl", abs, string(payload.Bulk), "ABSTTL")
+	assert.Greater(t, runCommand(t, handler, "TTL", "absttl").Int, int64(3500))
+
+	// 已过去的绝对过期时间不创建键
+	past := strconv.FormatInt(time.Now().Add(-time.Hour).UnixMilli(), 10)
+	assert.Equal(t, "OK", runCommand(t, handler, "RESTORE", "gone", past, string(payload.Bulk), "ABSTTL").String)
+	assert.Equal(t, int64(0), runCommand(t, handler, "EXISTS", "gone").Int)
+
+	assert.True(t, runCommand(t, handler, "DUMP", "missing").IsNil())
+}
+
+func TestRestoreExistingKey(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "src", "new")
+	runCommand(t, handler, "SET", "dst", "old")
+	payload := string(runCommand(t, handler, "DUMP", "src").Bulk)
+
+	reply := runCommand(t, handler, "RESTORE", "dst", "0", payload)
+	assert.Equal(t, resp.DataType(resp.TypeError), reply.Type)
+	assert.Equal(t, "BUSYKEY Target key name already exists.", reply.String)
+	assert.Equal(t, "old", string(runCommand(t, handler, "GET", "dst").Bulk))
+
+	assert.Equal(t, "OK", runCommand(t, handler, "RESTORE", "dst", "0", payload, "REPLACE").String)
+	assert.Equal(t, "new", string(runCommand(t, handler, "GET", "dst").Bulk))
+}
+
+func TestRestoreRejectsBadPayload(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "src", "value")
+	payload := runCommand(t, handler, "DUMP", "src").Bulk
+
+	corrupted := append([]byte(nil), payload...)
+	corrupted[2] ^= 0xff
+	wrongVersion := dumpValue("value")
+	wrongVersion[len(wrongVersion)-10] = 9
+
+	for _, bad := range []string{string(corrupted), string(wrongVersion), "", "garbage"} {
+		reply := runCommand(t, handler, "RESTORE", "dst", "0", bad)
+		assert.Equal(t, "ERR DUMP payload version or checksum are wrong", reply.String)
+	}
+	assert.Equal(t, int64(0), runCommand(t, handler, "EXISTS", "dst").Int)
+
+	reply := runCommand(t, handler, "RESTORE", "dst", "-1", string(payload))
+	assert.Equal(t, "ERR Invalid TTL value, must be >= 0", reply.String)
+	reply = runCommand(t, handler, "RESTORE", "dst", "0", string(payload), "FREQ", "256")
+	assert.Equal(t, "ERR Invalid FREQ value, must be >= 0 and <= 255", reply.String)
+	reply = runCommand(t, handler, "RESTORE", "dst", "0", string(payload), "IDLETIME", "1", "FREQ", "1")
+	assert.Equal(t, "ERR syntax error", reply.String)
+}
+
+func TestRestoreIdleTimeAndFreq(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "src", "value")
+	payload := string(runCommand(t, handler, "DUMP", "src").Bulk)
+
+	runCommand(t, handler, "RESTORE", "idle", "0", payload, "IDLETIME", "1000")
+	assert.GreaterOrEqual(t, runCommand(t, handler, "OBJECT", "IDLETIME", "idle").Int, int64(1000))
+
+	runCommand(t, handler, "RESTORE", "freq", "0", payload, "FREQ", "42")
+	assert.Equal(t, int64(42), runCommand(t, handler, "OBJECT", "FREQ", "freq").Int)
+}
+
+func TestAOFLogsRestoreAsSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+
+	handler := NewRedisHandler()
+	require.NoError(t, handler.EnableAOF(path, AOFFsyncAlways))
+	runCommand(t, handler, "SET", "src", "value")
+	payload := string(runCommand(t, handler, "DUMP", "src").Bulk)
+	runCommand(t, handler, "RESTORE", "dst", "100000", payload)
+	require.NoError(t, handler.Close())
+
+	restarted := NewRedisHandler()
+	require.NoError(t, restarted.EnableAOF(path, AOFFsyncAlways))
+	defer restarted.Close()
+
+	assert.Equal(t, "value", string(runCommand(t, restarted, "GET", "dst").Bulk))
+	ttl := runCommand(t, restarted, "TTL", "dst").Int
+	assert.Greater(t, ttl, int64(90))
+	assert.LessOrEqual(t, ttl, int64(100))
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestCommandErrorSerialization(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  []string
+		expected string
+	}{
+		{
+			name:     "SET with invalid expire time",
+			command:  []string{"SET", "key", "value", "EX", "abc"},
+			expected: "-ERR invalid expire time\r\n",
+		},
+		{
+			name:     "HELLO with unsupported version",
+			command:  []string{"HELLO", "4"},
+			expected: "-ERR HELLO only supports RESP protocol versions 2 and 3\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newMockTransport()
+			handler := NewRedisHandler()
+
+			// The typed error is turned into a reply, not returned to the caller
+			err := handler.handleCommand(tt.command, resp.NewRespWriter(transport))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expected, transport.writeBuf.String())
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setUsedMemory 把 handler 的已用内存固定为 used 字节
+func setUsedMemory(handler *RedisHandler, used uint64) {
+	handler.usedMemory = func() uint64 { return used }
+}
+
+func TestMaxmemoryNoevictionRejectsWrites(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "key", "value")
+	setUsedMemory(handler, 2000)
+
+	// 未超过 maxmemory 时正常写入
+	runCommand(t, handler, "CONFIG", "SET", "maxmemory", "2000")
+	assert.Equal(t, "OK", runCommand(t, handler, "SET", "other", "value").String)
+
+	runCommand(t, handler, "CONFIG", "SET", "maxmemory", "1000")
+	oom := "OOM command not allowed when used memory > 'maxmemory'."
+	assert.Equal(t, oom, runCommand(t, handler, "SET", "new", "value").String)
+	assert.Equal(t, oom, runCommand(t, handler, "APPEND", "key", "more").String)
+	// 读命令和不增加内存的写命令不受影响
+	assert.Equal(t, "value", string(runCommand(t, handler, "GET", "key").Bulk))
+	assert.Equal(t, int64(1), runCommand(t, handler, "DEL", "other").Int)
+	assert.Equal(t, int64(1), runCommand(t, handler, "DBSIZE").Int)
+
+	runCommand(t, handler, "CONFIG", "SET", "maxmemory", "0")
+	assert.Equal(t, "OK", runCommand(t, handler, "SET", "new", "value").String)
+}
+
+func TestMaxmemoryEvictsKeys(t *testing.T) {
+	clock := newFakeClock()
+	handler := NewRedisHandler()
+	handler.SetClock(clock)
+	for i := 0; i < 10; i++ {
+		runCommand(t, handler, "SET", fmt.Sprintf("key:%d", i), "value")
+		clock.Advance(time.Second)
+	}
+	// 最近访问过的键不会被 LRU 淘汰
+	runCommand(t, handler, "GET", "key:0")
+
+	// 超出的内存相当于一个键，每次写入淘汰一个最久未访问的键
+	setUsedMemory(handler, 1000+itemOverhead)
+	runCommand(t, handler, "CONFIG", "SET", "maxmemory", "1000", "maxmemory-policy", "allkeys-lru")
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, "OK", runCommand(t, handler, "SET", "new", "value").String)
+	}
+	assert.Equal(t, 6, storeSize(handler))
+	assert.Equal(t, int64(1), runCommand(t, handler, "EXISTS", "key:0").Int)
+	assert.Equal(t, "5", infoFields(runInfo(t, handler, "stats"))["evicted_keys"])
+}
+
+func TestMaxmemoryVolatilePolicies(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "forever", "value")
+	runCommand(t, handler, "SET", "later", "value", "EX", "200")
+	runCommand(t, handler, "SET", "sooner", "value", "EX", "100")
+
+	setUsedMemory(handler, 1000+itemOverhead)
+	runCommand(t, handler, "CONFIG", "SET", "maxmemory", "1000", "maxmemory-policy", "volatile-ttl")
+	assert.Equal(t, "OK", runCommand(t, handler, "SET", "new", "value").String)
+	assert.Equal(t, int64(0), runCommand(t, handler, "EXISTS", "sooner").Int)
+	assert.Equal(t, "OK", runCommand(t, handler, "SET", "new", "value").String)
+	assert.Equal(t, int64(0), runCommand(t, handler, "EXISTS", "later").Int)
+
+	// 没有带过期时间的键可以淘汰
+	reply := runCommand(t, handler, "SET", "new", "value")
+	assert.Equal(t, "OOM command not allowed when used memory > 'maxmemory'.", reply.String)
+	assert.Equal(t, int64(1), runCommand(t, handler, "EXISTS", "forever").Int)
+}
+
+func TestEvictionLoggedAsDel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+	handler := NewRedisHandler()
+	require.NoError(t, handler.EnableAOF(path, AOFFsyncAlways))
+	runCommand(t, handler, "SET", "old", "value")
+
+	setUsedMemory(handler, 1000+itemOverhead)
+	runCommand(t, handler, "CONFIG", "SET", "maxmemory", "1000", "maxmemory-policy", "allkeys-random")
+	runCommand(t, handler, "SET", "new", "value")
+	require.NoError(t, handler.Close())
+
+	commands := aofCommands(t, path)
+	require.GreaterOrEqual(t, len(commands), 2)
+	assert.Equal(t, []string{"DEL", "old"}, commands[len(commands)-2])
+	assert.Equal(t, []string{"SET", "new", "value"}, commands[len(commands)-1])
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeSize 返回存储中实际保存的键数，包括已过期但尚未删除的键
+func storeSize(handler *RedisHandler) int {
+	handler.mu.RLock()
+	defer handler.mu.RUnlock()
+	return len(handler.store)
+}
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestFakeClockExpiresKeys(t *testing.T) {
+	clock := newFakeClock()
+	handler := NewRedisHandler()
+	handler.SetClock(clock)
+
+	runCommand(t, handler, "SET", "session", "value", "EX", "100")
+	runCommand(t, handler, "SET", "short", "value", "PX", "1500")
+	runCommand(t, handler, "SET", "forever", "value")
+	assert.Equal(t, int64(100), runCommand(t, handler, "TTL", "session").Int)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, int64(99), runCommand(t, handler, "TTL", "session").Int)
+	assert.Equal(t, "value", string(runCommand(t, handler, "GET", "short").Bulk))
+
+	clock.Advance(time.Second)
+	assert.True(t, runCommand(t, handler, "GET", "short").IsNull)
+	assert.Equal(t, int64(-2), runCommand(t, handler, "TTL", "short").Int)
+
+	clock.Advance(99 * time.Second)
+	assert.Equal(t, int64(0), runCommand(t, handler, "EXISTS", "session").Int)
+	assert.Equal(t, int64(1), runCommand(t, handler, "DBSIZE").Int)
+	assert.Equal(t, "value", string(runCommand(t, handler, "GET", "forever").Bulk))
+}
+
+func TestExistsAndTTLDeleteExpiredKeys(t *testing.T) {
+	clock := newFakeClock()
+	handler := NewRedisHandler()
+	handler.SetClock(clock)
+
+	for i := 0; i < 100; i++ {
+		runCommand(t, handler, "SET", fmt.Sprintf("key:%d", i), "value", "EX", "10")
+	}
+	clock.Advance(11 * time.Second)
+
+	// 多个连接同时惰性删除过期的键
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("key:%d", i)
+				handler.exists(key)
+				handler.ttl(key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, storeSize(handler))
+	assert.Equal(t, "100", infoFields(runInfo(t, handler, "stats"))["expired_keys"])
+}
+
+func TestDelIgnoresExpiredKeys(t *testing.T) {
+	clock := newFakeClock()
+	handler := NewRedisHandler()
+	handler.SetClock(clock)
+
+	runCommand(t, handler, "SET", "session", "value", "EX", "10")
+	runCommand(t, handler, "SET", "forever", "value")
+	clock.Advance(11 * time.Second)
+
+	assert.Equal(t, int64(1), runCommand(t, handler, "DEL", "session", "forever").Int)
+	assert.Equal(t, 0, storeSize(handler))
+	assert.Equal(t, "1", infoFields(runInfo(t, handler, "stats"))["expired_keys"])
+}
+
+func TestFakeClockActiveExpireCycle(t *testing.T) {
+	clock := newFakeClock()
+	handler := NewRedisHandler()
+	handler.SetClock(clock)
+
+	// EXAT 是绝对时间，按假时钟判断是否过期
+	expireAt := fmt.Sprint(clock.Now().Add(time.Hour).Unix())
+	for i := 0; i < 10; i++ {
+		runCommand(t, handler, "SET", fmt.Sprintf("key:%d", i), "value", "EXAT", expireAt)
+	}
+	assert.Equal(t, 0, handler.activeExpireCycle(DefaultActiveExpireSamples))
+
+	// 恰好在过期时间时键仍然存在，主动过期与访问时的判断一致
+	clock.Advance(time.Hour)
+	assert.Equal(t, 0, handler.activeExpireCycle(DefaultActiveExpireSamples))
+	assert.Equal(t, int64(1), runCommand(t, handler, "EXISTS", "key:0").Int)
+	assert.Equal(t, int64(0), runCommand(t, handler, "TTL", "key:0").Int)
+
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, 10, handler.activeExpireCycle(DefaultActiveExpireSamples))
+	assert.Equal(t, 0, storeSize(handler))
+}
+
+func TestIdleTimeFollowsClock(t *testing.T) {
+	clock := newFakeClock()
+	handler := NewRedisHandler()
+	handler.SetClock(clock)
+
+	runCommand(t, handler, "SET", "string", "value")
+	runCommand(t, handler, "APPEND", "appended", "value")
+	runCommand(t, handler, "SETBIT", "bitmap", "7", "1")
+	clock.Advance(time.Minute)
+	for _, key := range []string{"string", "appended", "bitmap"} {
+		assert.Equal(t, int64(60), runCommand(t, handler, "OBJECT", "IDLETIME", key).Int, key)
+	}
+
+	runCommand(t, handler, "GET", "string")
+	assert.Equal(t, int64(0), runCommand(t, handler, "OBJECT", "IDLETIME", "string").Int)
+}
+
+func TestActiveExpireRemovesUnreadKeys(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "short", "value", "PX", "50")
+	runCommand(t, handler, "SET", "forever", "value")
+
+	handler.StartActiveExpire(10*time.Millisecond, DefaultActiveExpireSamples)
+	t.Cleanup(handler.StopActiveExpire)
+
+	// 从不读取 short，它也会被后台任务删除
+	require.Eventually(t, func() bool {
+		return storeSize(handler) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "value", string(runCommand(t, handler, "GET", "forever").Bulk))
+	assert.Equal(t, "1", infoFields(runInfo(t, handler, "stats"))["expired_keys"])
+}
+
+func TestActiveExpireCycleRepeatsWhileManyExpired(t *testing.T) {
+	handler := NewRedisHandler()
+	past := time.Now().Add(-time.Second)
+	handler.mu.Lock()
+	for i := 0; i < 100; i++ {
+		handler.store[fmt.Sprintf("expired:%d", i)] = &RedisItem{Value: "v", ExpiresAt: &past}
+	}
+	handler.mu.Unlock()
+	runCommand(t, handler, "SET", "live", "value", "EX", "100")
+
+	// 抽样中过期的比例高时继续抽样，一轮就能清理远多于 samples 个键
+	removed := handler.activeExpireCycle(10)
+	assert.Greater(t, removed, 10)
+	assert.Equal(t, int64(removed), handler.stats.expiredKeys.Load())
+	assert.Equal(t, int64(1), runCommand(t, handler, "DBSIZE").Int)
+}
+
+func TestStopActiveExpire(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.StopActiveExpire() // 未启动时可以安全调用
+
+	handler.StartActiveExpire(10*time.Millisecond, 0)
+	handler.StartActiveExpire(10*time.Millisecond, 0) // 重新启动会替换之前的任务
+	handler.StopActiveExpire()
+
+	runCommand(t, handler, "SET", "short", "value", "PX", "1")
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, storeSize(handler))
+}
+
+func TestDebugSetActiveExpire(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetDebugCommandEnabled(true)
+	handler.StartActiveExpire(10*time.Millisecond, DefaultActiveExpireSamples)
+	t.Cleanup(handler.StopActiveExpire)
+
+	assert.Equal(t, "OK", runCommand(t, handler, "DEBUG", "SET-ACTIVE-EXPIRE", "0").String)
+	runCommand(t, handler, "SET", "short", "value", "PX", "10")
+
+	// 暂停期间已过期的键留在存储中，直到被访问时惰性删除
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, storeSize(handler))
+	assert.True(t, runCommand(t, handler, "GET", "short").IsNil())
+	assert.Equal(t, 0, storeSize(handler))
+
+	assert.Equal(t, "OK", runCommand(t, handler, "DEBUG", "SET-ACTIVE-EXPIRE", "1").String)
+	runCommand(t, handler, "SET", "short", "value", "PX", "10")
+	require.Eventually(t, func() bool {
+		return storeSize(handler) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "ERR syntax error", runCommand(t, handler, "DEBUG", "SET-ACTIVE-EXPIRE", "yes").String)
+}
@@ -118,7 +118,7 @@ func TestHandleHELLO(t *testing.T) {
 			require.NoError(t, err)
 			
 			// Check protocol version was updated
-			assert.Equal(t, tt.expectedVersion, handler.protocolVersion)
+			assert.Equal(t, tt.expectedVersion, handler.localClient.protocol)
 			
 			// Check response type matches expected protocol version
 			assert.Equal(t, byte(tt.expectedType), byte(response.Type))
@@ -200,7 +200,7 @@ func TestProtocolVersionPersistence(t *testing.T) {
 	handler := NewRedisHandler()
 	
 	// Initially should be RESP2
-	assert.Equal(t, 2, handler.protocolVersion)
+	assert.Equal(t, 2, handler.localClient.protocol)
 	
 	// Send HELLO 3 command
 	helloCommand := []string{"HELLO", "3"}
@@ -211,7 +211,7 @@ func TestProtocolVersionPersistence(t *testing.T) {
 	require.NoError(t, err)
 	
 	// Should now be RESP3
-	assert.Equal(t, 3, handler.protocolVersion)
+	assert.Equal(t, 3, handler.localClient.protocol)
 	
 	// Clear the write buffer to prepare for next command
 	transport.writeBuf.Reset()
@@ -232,3 +232,47 @@ func TestProtocolVersionPersistence(t *testing.T) {
 	strVal, _ := response.StringValue()
 	assert.Equal(t, "PONG", strVal)
 }
+
+func TestProtocolVersionIsPerConnection(t *testing.T) {
+	handler := NewRedisHandler()
+	resp3 := newRedisClient()
+	resp2 := newRedisClient()
+
+	runClientCommand(t, handler, resp3, "HELLO", "3")
+	assert.Equal(t, 3, resp3.protocol)
+	assert.Equal(t, 2, resp2.protocol)
+
+	// 其他连接切换到 RESP3 不影响本连接的 map 回复
+	response := runClientCommand(t, handler, resp2, "CONFIG", "GET", "maxmemory")
+	assert.Equal(t, resp.DataType(resp.TypeArray), response.Type)
+	response = runClientCommand(t, handler, resp3, "CONFIG", "GET", "maxmemory")
+	assert.Equal(t, resp.DataType(resp.TypeMap), response.Type)
+}
+
+// helloFields 把 HELLO 3 的 map 回复转换为以键名索引的 map
+func helloFields(t *testing.T, conn *testConn) map[string]resp.Value {
+	t.Helper()
+	response := conn.do(t, "HELLO", "3")
+	require.Equal(t, resp.DataType(resp.TypeMap), response.Type)
+	fields := make(map[string]resp.Value, len(response.Map))
+	for _, item := range response.Map {
+		fields[string(item.Key.Bulk)] = item.Value
+	}
+	return fields
+}
+
+func TestHelloReportsClientIDAndRole(t *testing.T) {
+	primary := NewRedisHandler()
+	primaryAddr := serveRedis(t, primary)
+	conn := dialRedis(t, primaryAddr)
+	id := conn.do(t, "CLIENT", "ID").Int
+
+	fields := helloFields(t, conn)
+	assert.Equal(t, id, fields["id"].Int)
+	assert.Equal(t, "master", string(fields["role"].Bulk))
+
+	replica := NewRedisHandler()
+	startReplica(t, replica, primaryAddr)
+	fields = helloFields(t, dialRedis(t, serveRedis(t, replica)))
+	assert.Equal(t, "replica", string(fields["role"].Bulk))
+}
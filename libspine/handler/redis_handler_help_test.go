@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+// helpLines 执行 HELP 子命令并返回回复的每一行
+func helpLines(t *testing.T, handler *RedisHandler, name string) []string {
+	t.Helper()
+	reply := runCommand(t, handler, name, "help")
+	require.Equal(t, resp.DataType(resp.TypeArray), reply.Type, reply.String)
+	lines := make([]string, len(reply.Array))
+	for i, line := range reply.Array {
+		lines[i] = line.String
+	}
+	return lines
+}
+
+func TestObjectHelp(t *testing.T) {
+	lines := helpLines(t, NewRedisHandler(), "OBJECT")
+	require.NotEmpty(t, lines)
+	assert.Equal(t, "OBJECT <subcommand> [<arg> [value] [opt] ...]. Subcommands are:", lines[0])
+	assert.Contains(t, lines, "ENCODING <key>")
+	assert.Equal(t, []string{"HELP", "    Print this help."}, lines[len(lines)-2:])
+}
+
+func TestContainerCommandsHaveHelp(t *testing.T) {
+	handler := NewRedisHandler()
+	for _, name := range []string{"CLIENT", "COMMAND", "ACL", "SLOWLOG", "DEBUG"} {
+		lines := helpLines(t, handler, name)
+		assert.True(t, strings.HasPrefix(lines[0], name+" <subcommand>"), lines[0])
+		// 每个子命令之后都有缩进的说明
+		for i, line := range lines[1:] {
+			if !strings.HasPrefix(line, "    ") {
+				assert.True(t, strings.HasPrefix(lines[i+2], "    "), "%s: %q has no description", name, line)
+			}
+		}
+	}
+
+	// 带参数的 HELP 不是 HELP 子命令
+	reply := runCommand(t, handler, "OBJECT", "HELP", "key")
+	assert.Equal(t, "ERR unknown subcommand 'HELP'. Try OBJECT HELP.", reply.String)
+}
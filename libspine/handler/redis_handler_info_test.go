@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/transport"
+)
+
+// infoFields 把 INFO 的回复解析为 field -> value
+func infoFields(text string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(text, "\r\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if field, value, ok := strings.Cut(line, ":"); ok {
+			fields[field] = value
+		}
+	}
+	return fields
+}
+
+// runInfo 执行 INFO 命令并返回文本
+func runInfo(t *testing.T, handler *RedisHandler, sections ...string) string {
+	t.Helper()
+	return string(runCommand(t, handler, append([]string{"INFO"}, sections...)...).Bulk)
+}
+
+func TestInfoKeyspaceMatchesDBSize(t *testing.T) {
+	handler := NewRedisHandler()
+
+	runCommand(t, handler, "SET", "a", "1")
+	runCommand(t, handler, "SET", "b", "2")
+	runCommand(t, handler, "SET", "c", "3", "EX", "100")
+
+	dbsize := runCommand(t, handler, "DBSIZE")
+	assert.Equal(t, int64(3), dbsize.Int)
+
+	keyspace := runInfo(t, handler, "keyspace")
+	assert.True(t, strings.HasPrefix(keyspace, "# Keyspace\r\n"), keyspace)
+	db0 := infoFields(keyspace)["db0"]
+	assert.True(t, strings.HasPrefix(db0, fmt.Sprintf("keys=%d,expires=1,", dbsize.Int)), db0)
+
+	// 已过期但尚未删除的键不计入
+	past := time.Now().Add(-time.Second)
+	handler.mu.Lock()
+	handler.store["c"].ExpiresAt = nil
+	handler.store["a"].ExpiresAt = &past
+	handler.mu.Unlock()
+	assert.Equal(t, int64(2), runCommand(t, handler, "DBSIZE").Int)
+	assert.True(t, strings.HasPrefix(infoFields(runInfo(t, handler, "keyspace"))["db0"], "keys=2,expires=0,"))
+
+	// 空数据库不输出 db0
+	runCommand(t, handler, "FLUSHALL")
+	assert.Equal(t, "# Keyspace\r\n", runInfo(t, handler, "keyspace"))
+}
+
+func TestInfoSections(t *testing.T) {
+	handler := NewRedisHandler()
+
+	for _, args := range [][]string{nil, {"all"}, {"default"}} {
+		text := runInfo(t, handler, args...)
+		for _, header := range []string{"# Server", "# Clients", "# Memory", "# Stats", "# Keyspace"} {
+			assert.Contains(t, text, header+"\r\n")
+		}
+	}
+
+	// 只返回指定的分节，分节名不区分大小写
+	server := runInfo(t, handler, "SERVER")
+	assert.True(t, strings.HasPrefix(server, "# Server\r\n"))
+	assert.NotContains(t, server, "# Clients")
+	assert.Equal(t, compatibleRedisVersion, infoFields(server)["redis_version"])
+
+	assert.Empty(t, runInfo(t, handler, "nosuchsection"))
+}
+
+func TestInfoClientsAndStats(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.setServerInfo(&transport.ServerInfo{Address: "127.0.0.1:6380"})
+	address := serveRedis(t, handler)
+
+	first := dialRedis(t, address)
+	second := dialRedis(t, address)
+	second.do(t, "PING")
+
+	fields := infoFields(string(first.do(t, "INFO").Bulk))
+	assert.Equal(t, "2", fields["connected_clients"])
+	assert.Equal(t, "2", fields["total_connections_received"])
+	assert.Equal(t, "1", fields["total_commands_processed"]) // 只有 PING，INFO 执行完才计数
+	assert.Equal(t, "6380", fields["tcp_port"])
+	require.NotEmpty(t, fields["used_memory"])
+}
+
+func TestHumanBytes(t *testing.T) {
+	assert.Equal(t, "100B", humanBytes(100))
+	assert.Equal(t, "1.50K", humanBytes(1536))
+	assert.Equal(t, "2.00M", humanBytes(2<<20))
+}
+
+func TestLolwut(t *testing.T) {
+	handler := NewRedisHandler()
+
+	reply := string(runCommand(t, handler, "LOLWUT").Bulk)
+	assert.Contains(t, reply, "spine-go ver. "+serverVersion)
+	assert.Contains(t, reply, runtime.Version())
+	assert.Contains(t, reply, runtime.GOOS+"/"+runtime.GOARCH)
+
+	assert.Equal(t, reply, string(runCommand(t, handler, "LOLWUT", "VERSION", "5").Bulk))
+	assert.Equal(t, "ERR value is not an integer or out of range", runCommand(t, handler, "LOLWUT", "VERSION", "x").String)
+	assert.Equal(t, "ERR syntax error", runCommand(t, handler, "LOLWUT", "COLUMNS").String)
+
+	// ldflags 设置的构建信息优先于工具链记录的信息
+	defer func(commit, date string) { buildCommit, buildDate = commit, date }(buildCommit, buildDate)
+	buildCommit, buildDate = "abc1234", "2024-01-02T03:04:05Z"
+	reply = string(runCommand(t, handler, "LOLWUT").Bulk)
+	assert.Contains(t, reply, "commit: abc1234 built: 2024-01-02T03:04:05Z")
+	assert.Equal(t, "abc1234", infoFields(runInfo(t, handler, "server"))["redis_git_sha1"])
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyspaceHitsAndMisses(t *testing.T) {
+	handler := NewRedisHandler()
+
+	runCommand(t, handler, "SET", "present", "value")
+	runCommand(t, handler, "GET", "present")
+	runCommand(t, handler, "GET", "present")
+	runCommand(t, handler, "GET", "missing")
+	runCommand(t, handler, "GETBIT", "missing", "0")
+
+	stats := infoFields(runInfo(t, handler, "stats"))
+	assert.Equal(t, "2", stats["keyspace_hits"])
+	assert.Equal(t, "2", stats["keyspace_misses"])
+	assert.Equal(t, "5", stats["total_commands_processed"])
+}
+
+func TestCommandStats(t *testing.T) {
+	handler := NewRedisHandler()
+
+	runCommand(t, handler, "SET", "key", "value")
+	runCommand(t, handler, "GET", "key")
+	runCommand(t, handler, "GET", "key")
+
+	// commandstats 只在 INFO all 或显式指定时输出
+	assert.NotContains(t, runInfo(t, handler), "# Commandstats")
+	commandstats := infoFields(runInfo(t, handler, "commandstats"))
+	assert.Equal(t, "calls=2", commandstats["cmdstat_get"])
+	assert.Equal(t, "calls=1", commandstats["cmdstat_set"])
+	assert.NotContains(t, commandstats, "cmdstat_del")
+
+	all := infoFields(runInfo(t, handler, "all"))
+	assert.Equal(t, "calls=2", all["cmdstat_info"]) // 不包含正在执行的这一次
+}
+
+func TestWriteMetrics(t *testing.T) {
+	handler := NewRedisHandler()
+
+	runCommand(t, handler, "SET", "key", "value")
+	runCommand(t, handler, "GET", "key")
+	runCommand(t, handler, "GET", "missing")
+
+	var buf bytes.Buffer
+	require.NoError(t, handler.WriteMetrics(&buf))
+	metrics := buf.String()
+	assert.Contains(t, metrics, "# TYPE spine_commands_processed_total counter\n")
+	assert.Contains(t, metrics, "spine_commands_processed_total 3\n")
+	assert.Contains(t, metrics, `spine_command_calls_total{command="get"} 2`+"\n")
+	assert.Contains(t, metrics, "spine_keyspace_hits_total 1\n")
+	assert.Contains(t, metrics, "spine_keyspace_misses_total 1\n")
+	assert.Contains(t, metrics, "spine_keys 1\n")
+}
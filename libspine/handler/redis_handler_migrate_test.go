@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+// startMigratePair 启动源和目标两个服务器，返回连接源服务器的客户端和目标的主机、端口
+func startMigratePair(t *testing.T) (source, target *RedisHandler, conn *testConn, host, port string) {
+	t.Helper()
+	source, target = NewRedisHandler(), NewRedisHandler()
+	conn = dialRedis(t, serveRedis(t, source))
+	host, port, err := net.SplitHostPort(serveRedis(t, target))
+	require.NoError(t, err)
+	return source, target, conn, host, port
+}
+
+func TestMigrateMovesKey(t *testing.T) {
+	source, target, conn, host, port := startMigratePair(t)
+
+	conn.do(t, "SET", "key", "value", "EX", "100")
+	reply := conn.do(t, "MIGRATE", host, port, "key", "0", "1000")
+	require.Equal(t, "OK", reply.String)
+
+	assert.Equal(t, int64(0), runCommand(t, source, "EXISTS", "key").Int)
+	assert.Equal(t, "value", string(runCommand(t, target, "GET", "key").Bulk))
+	ttl := runCommand(t, target, "TTL", "key").Int
+	assert.Greater(t, ttl, int64(90))
+	assert.LessOrEqual(t, ttl, int64(100))
+
+	// 源上已没有该键
+	assert.Equal(t, "NOKEY", conn.do(t, "MIGRATE", host, port, "key", "0", "1000").String)
+}
+
+func TestMigrateCopyReplaceAndKeys(t *testing.T) {
+	source, target, conn, host, port := startMigratePair(t)
+
+	conn.do(t, "SET", "a", "1")
+	conn.do(t, "SET", "b", "2")
+	runCommand(t, target, "SET", "a", "old")
+
+	// 目标上已存在的键需要 REPLACE
+	reply := conn.do(t, "MIGRATE", host, port, "", "0", "1000", "COPY", "KEYS", "a", "b")
+	assert.Equal(t, resp.DataType(resp.TypeError), reply.Type)
+	assert.Equal(t, "ERR Target instance replied with error: BUSYKEY Target key name already exists.", reply.String)
+
+	reply = conn.do(t, "MIGRATE", host, port, "", "0", "1000", "COPY", "REPLACE", "KEYS", "a", "b", "missing")
+	require.Equal(t, "OK", reply.String)
+	assert.Equal(t, "1", string(runCommand(t, target, "GET", "a").Bulk))
+	assert.Equal(t, "2", string(runCommand(t, target, "GET", "b").Bulk))
+	// COPY 保留本地的键
+	assert.Equal(t, int64(2), runCommand(t, source, "EXISTS", "a", "b").Int)
+}
+
+func TestMigrateAuthAndErrors(t *testing.T) {
+	_, target, conn, host, port := startMigratePair(t)
+	target.SetRequirePass("secret")
+	conn.do(t, "SET", "key", "value")
+
+	reply := conn.do(t, "MIGRATE", host, port, "key", "0", "1000")
+	assert.Equal(t, "ERR Target instance replied with error: NOAUTH Authentication required.", reply.String)
+
+	reply = conn.do(t, "MIGRATE", host, port, "key", "0", "1000", "AUTH", "secret")
+	require.Equal(t, "OK", reply.String)
+	item, exists := target.lookupItem("key")
+	require.True(t, exists)
+	assert.Equal(t, "value", item.Value)
+
+	// 无法连接的目标
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, closedPort, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+	conn.do(t, "SET", "key", "value")
+	reply = conn.do(t, "MIGRATE", "127.0.0.1", closedPort, "key", "0", "1000")
+	assert.Equal(t, "IOERR error or timeout connecting to the client", reply.String)
+	assert.Equal(t, "value", string(conn.do(t, "GET", "key").Bulk))
+
+	assert.Equal(t, "ERR DB index is out of range", conn.do(t, "MIGRATE", host, port, "key", "1", "1000").String)
+	assert.Equal(t, "ERR syntax error", conn.do(t, "MIGRATE", host, port, "key", "0", "1000", "BOGUS").String)
+}
+
+func TestAOFLogsMigrateAsDel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+	target := NewRedisHandler()
+	host, port, err := net.SplitHostPort(serveRedis(t, target))
+	require.NoError(t, err)
+
+	source := NewRedisHandler()
+	require.NoError(t, source.EnableAOF(path, AOFFsyncAlways))
+	runCommand(t, source, "SET", "moved", "1")
+	runCommand(t, source, "SET", "copied", "2")
+	runCommand(t, source, "MIGRATE", host, port, "moved", "0", "1000")
+	runCommand(t, source, "MIGRATE", host, port, "copied", "0", "1000", "COPY")
+	require.NoError(t, source.Close())
+
+	restarted := NewRedisHandler()
+	require.NoError(t, restarted.EnableAOF(path, AOFFsyncAlways))
+	defer restarted.Close()
+	assert.Equal(t, int64(0), runCommand(t, restarted, "EXISTS", "moved").Int)
+	assert.Equal(t, "2", string(runCommand(t, restarted, "GET", "copied").Bulk))
+}
+
+func TestMigratePartialFailureDeletesOnlyRestoredKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "appendonly.aof")
+	target := NewRedisHandler()
+	host, port, err := net.SplitHostPort(serveRedis(t, target))
+	require.NoError(t, err)
+	runCommand(t, target, "SET", "b", "old")
+
+	source := NewRedisHandler()
+	require.NoError(t, source.EnableAOF(path, AOFFsyncAlways))
+	for _, key := range []string{"a", "b", "c"} {
+		runCommand(t, source, "SET", key, key)
+	}
+
+	// b 在目标上已存在，a 已经迁移成功，c 没有迁移
+	reply := runCommand(t, source, "MIGRATE", host, port, "", "0", "1000", "KEYS", "a", "b", "c")
+	assert.Equal(t, "ERR Target instance replied with error: BUSYKEY Target key name already exists.", reply.String)
+	assert.Equal(t, "a", string(runCommand(t, target, "GET", "a").Bulk))
+	assert.Equal(t, int64(0), runCommand(t, source, "EXISTS", "a").Int)
+	assert.Equal(t, int64(2), runCommand(t, source, "EXISTS", "b", "c").Int)
+	require.NoError(t, source.Close())
+
+	commands := aofCommands(t, path)
+	assert.Equal(t, []string{"DEL", "a"}, commands[len(commands)-1])
+}
+
+func TestMigrateDoesNotBlockWrites(t *testing.T) {
+	source := NewRedisHandler()
+	require.NoError(t, source.EnableAOF(filepath.Join(t.TempDir(), "appendonly.aof"), AOFFsyncNo))
+	defer source.Close()
+	runCommand(t, source, "SET", "key", "value")
+
+	// 接受连接但从不回复的目标，MIGRATE 会一直等到超时
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	done := make(chan resp.Value)
+	go func() {
+		done <- runClientCommand(t, source, newRedisClient(), "MIGRATE", host, port, "key", "0", "2000")
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	assert.Equal(t, "OK", runCommand(t, source, "SET", "other", "value").String)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Contains(t, (<-done).String, "IOERR")
+	assert.Equal(t, "value", string(runCommand(t, source, "GET", "key").Bulk))
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readMonitorLine 读取监视连接收到的下一行
+func readMonitorLine(t *testing.T, conn *testConn) string {
+	t.Helper()
+	conn.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	value, err := conn.parser.Parse()
+	require.NoError(t, err)
+	return value.String
+}
+
+func TestMonitorReceivesCommands(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetRequirePass("secret")
+	address := serveRedis(t, handler)
+
+	monitor := dialRedis(t, address)
+	monitor.do(t, "AUTH", "secret")
+	assert.Equal(t, "OK", monitor.do(t, "MONITOR").String)
+
+	other := dialRedis(t, address)
+	other.do(t, "AUTH", "secret")
+	other.do(t, "SET", "key", "va\"lue")
+
+	// AUTH 的密码被隐藏
+	line := readMonitorLine(t, monitor)
+	assert.True(t, strings.HasSuffix(line, `"AUTH" "(redacted)"`), line)
+	assert.NotContains(t, line, "secret")
+
+	line = readMonitorLine(t, monitor)
+	assert.True(t, strings.HasSuffix(line, `"SET" "key" "va\"lue"`), line)
+	assert.Contains(t, line, "[0 "+other.conn.LocalAddr().String()+"]")
+}
+
+func TestMonitorConnectionCanStillRunCommands(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+
+	monitor := dialRedis(t, address)
+	monitor.do(t, "MONITOR")
+
+	// 监视连接仍可执行命令，先收到回复，随后是自己的命令出现在输出中
+	assert.Equal(t, "PONG", monitor.do(t, "PING").String)
+	line := readMonitorLine(t, monitor)
+	assert.True(t, strings.HasSuffix(line, `"PING"`), line)
+
+	// 连接关闭后不再向它发送
+	monitor.conn.Close()
+	require.Eventually(t, func() bool {
+		handler.monitorMu.RLock()
+		defer handler.monitorMu.RUnlock()
+		return len(handler.monitors) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestQuoteMonitorArg(t *testing.T) {
+	assert.Equal(t, `"plain"`, quoteMonitorArg("plain"))
+	assert.Equal(t, `"a\\b\"c"`, quoteMonitorArg(`a\b"c`))
+	assert.Equal(t, `"line\r\n\x00\xff"`, quoteMonitorArg("line\r\n\x00\xff"))
+}
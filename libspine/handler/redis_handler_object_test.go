@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestObjectEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{name: "integer", value: "12345", expected: "int"},
+		{name: "negative integer", value: "-42", expected: "int"},
+		{name: "short string", value: "hello", expected: "embstr"},
+		{name: "integer out of range", value: "123456789012345678901", expected: "embstr"},
+		{name: "long string", value: strings.Repeat("x", 45), expected: "raw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newMockTransport()
+			handler := NewRedisHandler()
+			respWriter := resp.NewRespWriter(transport)
+
+			require.NoError(t, handler.handleCommand([]string{"SET", "key", tt.value}, respWriter))
+			transport.writeBuf.Reset()
+
+			require.NoError(t, handler.handleCommand([]string{"OBJECT", "ENCODING", "key"}, respWriter))
+			response, err := transport.readResponse()
+			require.NoError(t, err)
+
+			encoding, _ := response.StringValue()
+			assert.Equal(t, tt.expected, encoding)
+		})
+	}
+}
+
+func TestObjectEncodingMissingKey(t *testing.T) {
+	transport := newMockTransport()
+	handler := NewRedisHandler()
+
+	require.NoError(t, handler.handleCommand([]string{"OBJECT", "ENCODING", "missing"}, resp.NewRespWriter(transport)))
+	response, err := transport.readResponse()
+	require.NoError(t, err)
+	assert.True(t, response.IsNil())
+}
+
+func TestObjectUnknownSubcommand(t *testing.T) {
+	transport := newMockTransport()
+	handler := NewRedisHandler()
+
+	require.NoError(t, handler.handleCommand([]string{"OBJECT", "BOGUS"}, resp.NewRespWriter(transport)))
+	assert.True(t, strings.HasPrefix(transport.writeBuf.String(), "-ERR unknown subcommand 'BOGUS'"))
+}
+
+func TestObjectIdleTime(t *testing.T) {
+	transport := newMockTransport()
+	handler := NewRedisHandler()
+	respWriter := resp.NewRespWriter(transport)
+
+	require.NoError(t, handler.handleCommand([]string{"SET", "key", "value"}, respWriter))
+
+	// 将最近访问时间回拨，模拟键空闲了一段时间
+	handler.store["key"].LastAccess = time.Now().Add(-5 * time.Second)
+	transport.writeBuf.Reset()
+	require.NoError(t, handler.handleCommand([]string{"OBJECT", "IDLETIME", "key"}, respWriter))
+	response, err := transport.readResponse()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, response.Int, int64(5))
+
+	// OBJECT 本身不算访问，GET 之后空闲时间归零
+	require.NoError(t, handler.handleCommand([]string{"GET", "key"}, respWriter))
+	transport.writeBuf.Reset()
+	require.NoError(t, handler.handleCommand([]string{"OBJECT", "IDLETIME", "key"}, respWriter))
+	response, err = transport.readResponse()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), response.Int)
+}
+
+func TestObjectIdleTimeIncreases(t *testing.T) {
+	transport := newMockTransport()
+	handler := NewRedisHandler()
+	respWriter := resp.NewRespWriter(transport)
+
+	require.NoError(t, handler.handleCommand([]string{"SET", "key", "value"}, respWriter))
+	require.NoError(t, handler.handleCommand([]string{"GET", "key"}, respWriter))
+
+	time.Sleep(1100 * time.Millisecond)
+
+	transport.writeBuf.Reset()
+	require.NoError(t, handler.handleCommand([]string{"OBJECT", "IDLETIME", "key"}, respWriter))
+	response, err := transport.readResponse()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, response.Int, int64(1))
+}
+
+func TestObjectFreqAndRefcount(t *testing.T) {
+	transport := newMockTransport()
+	handler := NewRedisHandler()
+	respWriter := resp.NewRespWriter(transport)
+
+	require.NoError(t, handler.handleCommand([]string{"SET", "key", "value"}, respWriter))
+	for i := 0; i < 3; i++ {
+		require.NoError(t, handler.handleCommand([]string{"GET", "key"}, respWriter))
+	}
+
+	transport.writeBuf.Reset()
+	require.NoError(t, handler.handleCommand([]string{"OBJECT", "FREQ", "key"}, respWriter))
+	response, err := transport.readResponse()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), response.Int)
+
+	transport.writeBuf.Reset()
+	require.NoError(t, handler.handleCommand([]string{"OBJECT", "REFCOUNT", "key"}, respWriter))
+	response, err = transport.readResponse()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), response.Int)
+
+	transport.writeBuf.Reset()
+	require.NoError(t, handler.handleCommand([]string{"OBJECT", "FREQ", "missing"}, respWriter))
+	response, err = transport.readResponse()
+	require.NoError(t, err)
+	assert.True(t, response.IsNil())
+}
+
+func TestTouchUpdatesIdleTime(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "a", "1")
+	runCommand(t, handler, "SET", "b", "2")
+	runCommand(t, handler, "SET", "expired", "3", "PX", "1")
+	time.Sleep(5 * time.Millisecond)
+
+	handler.store["a"].LastAccess = time.Now().Add(-10 * time.Second)
+	handler.store["b"].LastAccess = time.Now().Add(-10 * time.Second)
+	freq := handler.store["a"].Freq
+
+	// 与 Redis 一致，重复的键每次出现都计数，不存在和已过期的键不计数
+	assert.Equal(t, int64(3), runCommand(t, handler, "TOUCH", "a", "b", "a", "missing", "expired").Int)
+	assert.Equal(t, int64(0), runCommand(t, handler, "OBJECT", "IDLETIME", "a").Int)
+	assert.Equal(t, int64(0), runCommand(t, handler, "OBJECT", "IDLETIME", "b").Int)
+	assert.Greater(t, handler.store["a"].Freq, freq)
+
+	assert.Equal(t, int64(0), runCommand(t, handler, "TOUCH", "missing").Int)
+	assert.Equal(t, "ERR wrong number of arguments for 'touch' command", runCommand(t, handler, "TOUCH").String)
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestPipelinedCommands(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+	conn := dialRedis(t, address)
+
+	// 三条命令在一次 Write 中发出，不等待中间的回复
+	var pipeline bytes.Buffer
+	for _, command := range [][]string{
+		{"SET", "key", "value"},
+		{"GET", "key"},
+		{"DEL", "key"},
+	} {
+		data, err := resp.SerializeCommand(command[0], command[1:]...)
+		require.NoError(t, err)
+		pipeline.Write(data)
+	}
+	_, err := conn.conn.Write(pipeline.Bytes())
+	require.NoError(t, err)
+
+	conn.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	replies := make([]resp.Value, 3)
+	for i := range replies {
+		replies[i], err = conn.parser.Parse()
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "OK", replies[0].String)
+	assert.Equal(t, "value", string(replies[1].Bulk))
+	assert.Equal(t, int64(1), replies[2].Int)
+
+	// 流水线之后的普通请求仍然正常
+	assert.Equal(t, "PONG", conn.do(t, "PING").String)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendRaw 在新连接上发送原始数据，返回服务器关闭连接前的全部回复
+func sendRaw(t *testing.T, address string, data string) string {
+	t.Helper()
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Write([]byte(data))
+	require.NoError(t, err)
+	reply, err := io.ReadAll(bufio.NewReader(conn))
+	require.NoError(t, err, "server should close the connection")
+	return string(reply)
+}
+
+func TestProtocolRejectsOversizedRequests(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+
+	// 声明 2GB 的批量字符串，服务器不等待数据，直接回复协议错误并关闭连接
+	reply := sendRaw(t, address, "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$2147483648\r\n")
+	assert.Equal(t, "-ERR Protocol error: invalid bulk length\r\n", reply)
+
+	reply = sendRaw(t, address, "*2147483648\r\n")
+	assert.Equal(t, "-ERR Protocol error: invalid multibulk length\r\n", reply)
+
+	// 之前已到达的命令照常执行
+	reply = sendRaw(t, address, "*1\r\n$4\r\nPING\r\n*1\r\n$600000000\r\n")
+	assert.Equal(t, "+PONG\r\n-ERR Protocol error: invalid bulk length\r\n", reply)
+}
+
+func TestProtocolLimitsAreConfigurable(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+
+	assert.Equal(t, map[string]string{"proto-max-bulk-len": "536870912"}, configGet(t, handler, "proto-max-bulk-len"))
+	assert.Equal(t, map[string]string{"proto-max-multibulk-len": "1048576"}, configGet(t, handler, "proto-max-multibulk-len"))
+
+	assert.Equal(t, "OK", runCommand(t, handler, "CONFIG", "SET", "proto-max-bulk-len", "1mb", "proto-max-multibulk-len", "3").String)
+	assert.Contains(t, runCommand(t, handler, "CONFIG", "SET", "proto-max-bulk-len", "1000").String, "must be between 1048576")
+	assert.Contains(t, runCommand(t, handler, "CONFIG", "SET", "proto-max-multibulk-len", "0").String, "must be between 1")
+
+	// 新连接使用修改后的限制
+	conn := dialRedis(t, address)
+	assert.Equal(t, "OK", conn.do(t, "SET", "k", "v").String)
+	reply := sendRaw(t, address, "*4\r\n$3\r\nDEL\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n")
+	assert.Equal(t, "-ERR Protocol error: invalid multibulk length\r\n", reply)
+	reply = sendRaw(t, address, "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1048577\r\n")
+	assert.Equal(t, "-ERR Protocol error: invalid bulk length\r\n", reply)
+}
+
+func TestInlineCommands(t *testing.T) {
+	handler := NewRedisHandler()
+	address := serveRedis(t, handler)
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	// 像在 telnet 中输入一样发送命令，内联命令和 RESP 命令可以混用
+	for _, step := range []struct{ request, reply string }{
+		{"PING\r\n", "+PONG\r\n"},
+		{"set \"a b\" c\r\n", "+OK\r\n"},
+		{"GET 'a b'\n", "$1\r\n"},
+		{"", "c\r\n"},
+		{"*2\r\n$3\r\nGET\r\n$3\r\na b\r\n", "$1\r\n"},
+		{"", "c\r\n"},
+		{"\r\nNOSUCHCOMMAND\r\n", "-ERR unknown command 'NOSUCHCOMMAND'\r\n"},
+	} {
+		if step.request != "" {
+			_, err := conn.Write([]byte(step.request))
+			require.NoError(t, err)
+		}
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, step.reply, line, "reply to %q", step.request)
+	}
+
+	reply := sendRaw(t, address, "set \"a b c\r\n")
+	assert.Equal(t, "-ERR Protocol error: unbalanced quotes in request\r\n", reply)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomKeyReturnsEveryKey(t *testing.T) {
+	handler := NewRedisHandler()
+	assert.True(t, runCommand(t, handler, "RANDOMKEY").IsNil())
+
+	keys := []string{"a", "b", "c", "d", "e"}
+	for _, key := range keys {
+		runCommand(t, handler, "SET", key, "value")
+	}
+	runCommand(t, handler, "SET", "bits", "")
+	runCommand(t, handler, "SETBIT", "bits", "7", "1")
+	keys = append(keys, "bits")
+
+	counts := make(map[string]int)
+	const calls = 6000
+	for i := 0; i < calls; i++ {
+		counts[string(runCommand(t, handler, "RANDOMKEY").Bulk)]++
+	}
+	assert.Len(t, counts, len(keys))
+	for _, key := range keys {
+		// 每个键期望 1000 次，留出足够的余量避免偶发失败
+		assert.InDelta(t, calls/len(keys), counts[key], 300, "key %s", key)
+	}
+}
+
+func TestRandomKeySkipsExpiredKeys(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "gone", "value", "PX", "1")
+	time.Sleep(5 * time.Millisecond)
+	assert.True(t, runCommand(t, handler, "RANDOMKEY").IsNil())
+
+	runCommand(t, handler, "SET", "live", "value")
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, "live", string(runCommand(t, handler, "RANDOMKEY").Bulk))
+	}
+	assert.Equal(t, "ERR wrong number of arguments for 'randomkey' command", runCommand(t, handler, "RANDOMKEY", "x").String)
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+	"spine-go/libspine/transport"
+)
+
+// startReplica 让 replica 成为 primaryAddr 的副本并等待同步完成
+func startReplica(t *testing.T, replica *RedisHandler, primaryAddr string) {
+	t.Helper()
+	host, port, err := net.SplitHostPort(primaryAddr)
+	require.NoError(t, err)
+	assert.Equal(t, "OK", runCommand(t, replica, "REPLICAOF", host, port).String)
+	t.Cleanup(func() { replica.stopReplication() })
+	require.Eventually(t, func() bool {
+		return infoFields(runInfo(t, replica, "replication"))["master_link_status"] == "up"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// eventuallyGet 等待 key 在 handler 上的值变为 want，want 为空表示键不存在
+func eventuallyGet(t *testing.T, handler *RedisHandler, key, want string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		reply := runCommand(t, handler, "GET", key)
+		if want == "" {
+			return reply.IsNil()
+		}
+		return string(reply.Bulk) == want
+	}, 5*time.Second, 10*time.Millisecond, "waiting for %s=%q", key, want)
+}
+
+func TestReplicaReceivesSnapshotAndStream(t *testing.T) {
+	primary := NewRedisHandler()
+	runCommand(t, primary, "SET", "before", "1")
+	runCommand(t, primary, "SET", "ttl", "x", "EX", "100")
+	address := serveRedis(t, primary)
+
+	replica := NewRedisHandler()
+	runCommand(t, replica, "SET", "stale", "gone")
+	startReplica(t, replica, address)
+
+	// 全量同步替换副本原有的数据
+	eventuallyGet(t, replica, "before", "1")
+	eventuallyGet(t, replica, "stale", "")
+	ttl := runCommand(t, replica, "TTL", "ttl").Int
+	assert.True(t, ttl > 90 && ttl <= 100, "ttl %d", ttl)
+
+	runCommand(t, primary, "SET", "after", "2")
+	runCommand(t, primary, "DEL", "before")
+	eventuallyGet(t, replica, "after", "2")
+	eventuallyGet(t, replica, "before", "")
+
+	// 失败的命令和只读命令不传播
+	runCommand(t, primary, "GET", "after")
+	info := infoFields(runInfo(t, primary, "replication"))
+	assert.Equal(t, "master", info["role"])
+	assert.Equal(t, "1", info["connected_slaves"])
+}
+
+func TestReplicaSkipsUnsetConditionalSet(t *testing.T) {
+	primary := NewRedisHandler()
+	address := serveRedis(t, primary)
+	replica := NewRedisHandler()
+	startReplica(t, replica, address)
+
+	runCommand(t, primary, "SET", "a", "old")
+	eventuallyGet(t, replica, "a", "old")
+	offset := infoFields(runInfo(t, primary, "replication"))["master_repl_offset"]
+
+	// 条件不满足的 SET 不传播，复制偏移量不变
+	assert.True(t, runCommand(t, primary, "SET", "a", "new", "NX").IsNull)
+	assert.True(t, runCommand(t, primary, "SET", "missing", "value", "XX").IsNull)
+	assert.Equal(t, offset, infoFields(runInfo(t, primary, "replication"))["master_repl_offset"])
+
+	runCommand(t, primary, "SET", "a", "newer", "XX")
+	runCommand(t, primary, "SET", "marker", "done", "NX")
+	eventuallyGet(t, replica, "marker", "done")
+	assert.Equal(t, "newer", string(runCommand(t, replica, "GET", "a").Bulk))
+	assert.True(t, runCommand(t, replica, "GET", "missing").IsNull)
+}
+
+func TestReplicaIsReadOnlyUntilPromoted(t *testing.T) {
+	primary := NewRedisHandler()
+	address := serveRedis(t, primary)
+	replica := NewRedisHandler()
+	startReplica(t, replica, address)
+
+	reply := runCommand(t, replica, "SET", "key", "value")
+	assert.Equal(t, resp.DataType(resp.TypeError), reply.Type)
+	assert.Equal(t, "READONLY You can't write against a read only replica.", reply.String)
+	assert.Equal(t, "ERR WAIT cannot be used with replica instances.", runCommand(t, replica, "WAIT", "0", "0").String)
+	info := infoFields(runInfo(t, replica, "replication"))
+	assert.Equal(t, "slave", info["role"])
+	assert.Equal(t, "1", info["slave_read_only"])
+
+	assert.Equal(t, "OK", runCommand(t, replica, "REPLICAOF", "NO", "ONE").String)
+	assert.Equal(t, "OK", runCommand(t, replica, "SET", "key", "value").String)
+	assert.Equal(t, "master", infoFields(runInfo(t, replica, "replication"))["role"])
+
+	// 提升后不再接收主节点的命令
+	runCommand(t, primary, "SET", "key", "primary")
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, "value", string(runCommand(t, replica, "GET", "key").Bulk))
+	require.Eventually(t, func() bool {
+		return infoFields(runInfo(t, primary, "replication"))["connected_slaves"] == "0"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWritableReplica(t *testing.T) {
+	primary := NewRedisHandler()
+	address := serveRedis(t, primary)
+	replica := NewRedisHandler()
+	startReplica(t, replica, address)
+
+	// 默认只读，读命令正常执行
+	runCommand(t, primary, "SET", "key", "primary")
+	eventuallyGet(t, replica, "key", "primary")
+	assert.Equal(t, "READONLY You can't write against a read only replica.", runCommand(t, replica, "SET", "local", "value").String)
+
+	assert.Equal(t, "OK", runCommand(t, replica, "CONFIG", "SET", "replica-read-only", "no").String)
+	assert.Equal(t, "0", infoFields(runInfo(t, replica, "replication"))["slave_read_only"])
+	assert.Equal(t, "OK", runCommand(t, replica, "SET", "local", "value").String)
+	assert.Equal(t, "value", string(runCommand(t, replica, "GET", "local").Bulk))
+	// 主节点的命令流仍然照常应用
+	runCommand(t, primary, "SET", "key", "updated")
+	eventuallyGet(t, replica, "key", "updated")
+
+	assert.Equal(t, "OK", runCommand(t, replica, "CONFIG", "SET", "replica-read-only", "yes").String)
+	assert.Equal(t, resp.DataType(resp.TypeError), runCommand(t, replica, "SET", "local", "other").Type)
+	assert.Equal(t, "ERR CONFIG SET failed (possibly related to argument 'replica-read-only') - argument must be 'yes' or 'no'",
+		runCommand(t, replica, "CONFIG", "SET", "replica-read-only", "maybe").String)
+}
+
+func TestReplicaAuthenticatesWithMasterauth(t *testing.T) {
+	primary := NewRedisHandler()
+	primary.SetRequirePass("secret")
+	address := serveRedis(t, primary)
+
+	replica := NewRedisHandler()
+	require.NoError(t, replica.SetConfig("masterauth", "secret"))
+	startReplica(t, replica, address)
+
+	client := newRedisClient()
+	runClientCommand(t, primary, client, "AUTH", "secret")
+	runClientCommand(t, primary, client, "SET", "key", "value")
+	eventuallyGet(t, replica, "key", "value")
+}
+
+func TestWaitCountsAcknowledgingReplicas(t *testing.T) {
+	primary := NewRedisHandler()
+	address := serveRedis(t, primary)
+	startReplica(t, NewRedisHandler(), address)
+
+	runCommand(t, primary, "SET", "key", "value")
+	start := time.Now()
+	reply := runCommand(t, primary, "WAIT", "1", "5000")
+	assert.Equal(t, int64(1), reply.Int, reply.String)
+	// GETACK 让副本立即确认，不必等定时报告
+	assert.Less(t, time.Since(start), time.Second)
+
+	assert.Equal(t, int64(1), runCommand(t, primary, "WAIT", "2", "100").Int)
+}
+
+func TestReplicaofArguments(t *testing.T) {
+	handler := NewRedisHandler()
+	assert.Equal(t, "ERR Invalid master port", runCommand(t, handler, "REPLICAOF", "localhost", "nope").String)
+	assert.Equal(t, "OK", runCommand(t, handler, "REPLICAOF", "NO", "ONE").String)
+	assert.Equal(t, "master", infoFields(runInfo(t, handler, "replication"))["role"])
+
+	// 主节点不可达时保持副本身份并在后台重试
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+	assert.Equal(t, "OK", runCommand(t, handler, "REPLICAOF", host, port).String)
+	assert.Equal(t, "OK Already connected to specified master", runCommand(t, handler, "REPLICAOF", host, port).String)
+	assert.Equal(t, "down", infoFields(runInfo(t, handler, "replication"))["master_link_status"])
+	assert.Equal(t, "OK", runCommand(t, handler, "REPLICAOF", "NO", "ONE").String)
+}
+
+func TestCommandSizeMatchesSerialization(t *testing.T) {
+	for _, command := range [][]string{
+		{"PING"},
+		{"SET", "key", "value"},
+		{"SET", "", "0123456789"},
+	} {
+		data, err := resp.SerializeCommand(command[0], command[1:]...)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), commandSize(command), "%v", command)
+	}
+}
+
+func TestRoleStandalone(t *testing.T) {
+	handler := NewRedisHandler()
+
+	reply := runCommand(t, handler, "ROLE")
+	require.Len(t, reply.Array, 3)
+	assert.Equal(t, "master", string(reply.Array[0].Bulk))
+	assert.Equal(t, int64(0), reply.Array[1].Int)
+	assert.Equal(t, resp.DataType(resp.TypeArray), reply.Array[2].Type)
+	assert.Empty(t, reply.Array[2].Array)
+}
+
+func TestRoleWithReplica(t *testing.T) {
+	primary := NewRedisHandler()
+	address := serveRedis(t, primary)
+	replica := NewRedisHandler()
+	replicaAddr := serveRedis(t, replica)
+	_, replicaPort, err := net.SplitHostPort(replicaAddr)
+	require.NoError(t, err)
+	replica.setServerInfo(&transport.ServerInfo{Address: replicaAddr})
+	startReplica(t, replica, address)
+
+	runCommand(t, primary, "SET", "key", "value")
+	eventuallyGet(t, replica, "key", "value")
+
+	// 副本定时报告复制进度，主节点的副本列表中出现已确认的偏移量
+	require.Eventually(t, func() bool {
+		reply := runCommand(t, primary, "ROLE")
+		if len(reply.Array[2].Array) != 1 {
+			return false
+		}
+		entry := reply.Array[2].Array[0].Array
+		return string(entry[0].Bulk) == "127.0.0.1" && string(entry[1].Bulk) == replicaPort &&
+			string(entry[2].Bulk) == strconv.FormatInt(reply.Array[1].Int, 10)
+	}, 5*time.Second, 50*time.Millisecond)
+
+	host, port, err := net.SplitHostPort(address)
+	require.NoError(t, err)
+	reply := runCommand(t, replica, "ROLE")
+	require.Len(t, reply.Array, 5)
+	assert.Equal(t, "slave", string(reply.Array[0].Bulk))
+	assert.Equal(t, host, string(reply.Array[1].Bulk))
+	assert.Equal(t, port, strconv.FormatInt(reply.Array[2].Int, 10))
+	assert.Equal(t, "connected", string(reply.Array[3].Bulk))
+	assert.Equal(t, runCommand(t, primary, "ROLE").Array[1].Int, reply.Array[4].Int)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestResetClearsConnectionState(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetRequirePass("secret")
+	address := serveRedis(t, handler)
+
+	conn := dialRedis(t, address)
+	conn.do(t, "AUTH", "secret")
+	conn.do(t, "CLIENT", "SETNAME", "pooled")
+	conn.do(t, "MONITOR")
+	assert.Equal(t, "RESET", conn.do(t, "RESET").String)
+
+	// 退出监视模式，下一条回复不会是其他连接的命令
+	other := dialRedis(t, address)
+	other.do(t, "AUTH", "secret")
+	other.do(t, "SET", "key", "value")
+
+	// 认证被取消
+	assert.True(t, strings.HasPrefix(conn.do(t, "GET", "key").String, "NOAUTH"))
+	conn.do(t, "AUTH", "secret")
+	assert.True(t, conn.do(t, "CLIENT", "GETNAME").IsNil())
+	assert.Equal(t, "value", string(conn.do(t, "GET", "key").Bulk))
+}
+
+func TestResetWithoutPassword(t *testing.T) {
+	handler := NewRedisHandler()
+	client := newRedisClient()
+	runClientCommand(t, handler, client, "CLIENT", "SETNAME", "pooled")
+	assert.Equal(t, "RESET", runClientCommand(t, handler, client, "RESET").String)
+	assert.Equal(t, "PONG", runClientCommand(t, handler, client, "PING").String)
+	assert.Equal(t, "ERR wrong number of arguments for 'reset' command", runClientCommand(t, handler, client, "RESET", "x").String)
+}
+
+func TestResetRestoresRESP2(t *testing.T) {
+	handler := NewRedisHandler()
+	client := newRedisClient()
+	runClientCommand(t, handler, client, "HELLO", "3")
+	assert.Equal(t, resp.DataType(resp.TypeMap), runClientCommand(t, handler, client, "CONFIG", "GET", "timeout").Type)
+
+	assert.Equal(t, "RESET", runClientCommand(t, handler, client, "RESET").String)
+	assert.Equal(t, 2, client.protocol)
+	assert.Equal(t, resp.DataType(resp.TypeArray), runClientCommand(t, handler, client, "CONFIG", "GET", "timeout").Type)
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestSlowlogRecordsDebugSleep(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetDebugCommandEnabled(true)
+	handler.SetSlowlogLogSlowerThan(20000) // 20ms
+
+	runCommand(t, handler, "SET", "fast", "value")
+	runCommand(t, handler, "DEBUG", "SLEEP", "0.05")
+
+	// 只有超过阈值的 DEBUG SLEEP 被记录
+	assert.Equal(t, int64(1), runCommand(t, handler, "SLOWLOG", "LEN").Int)
+
+	entries := runCommand(t, handler, "SLOWLOG", "GET").Array
+	require.Len(t, entries, 1)
+	entry := entries[0].Array
+	require.Len(t, entry, 6)
+	assert.Equal(t, int64(0), entry[0].Int)
+	assert.GreaterOrEqual(t, entry[2].Int, int64(50000))
+	args := make([]string, len(entry[3].Array))
+	for i, arg := range entry[3].Array {
+		args[i] = string(arg.Bulk)
+	}
+	assert.Equal(t, []string{"DEBUG", "SLEEP", "0.05"}, args)
+
+	assert.Equal(t, "OK", runCommand(t, handler, "SLOWLOG", "RESET").String)
+	assert.Equal(t, int64(0), runCommand(t, handler, "SLOWLOG", "LEN").Int)
+}
+
+func TestSlowlogGetCountAndMaxLen(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetSlowlogLogSlowerThan(0) // 记录所有命令
+	handler.SetSlowlogMaxLen(3)
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		runCommand(t, handler, "GET", key)
+	}
+
+	// 超过上限时丢弃最旧的记录，最新的记录在前
+	entries := runCommand(t, handler, "SLOWLOG", "GET", "-1").Array
+	require.Len(t, entries, 3)
+	assert.Equal(t, "d", string(entries[0].Array[3].Array[1].Bulk))
+	assert.Equal(t, "b", string(entries[2].Array[3].Array[1].Bulk))
+
+	assert.Len(t, runCommand(t, handler, "SLOWLOG", "GET", "1").Array, 1)
+	assert.Equal(t, resp.DataType(resp.TypeError), runCommand(t, handler, "SLOWLOG", "GET", "-2").Type)
+}
+
+func TestSlowlogDisabled(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetSlowlogLogSlowerThan(-1)
+
+	runCommand(t, handler, "GET", "key")
+	assert.Equal(t, int64(0), runCommand(t, handler, "SLOWLOG", "LEN").Int)
+}
+
+func TestSlowlogArgs(t *testing.T) {
+	// 密码不会出现在慢查询日志中
+	assert.Equal(t, []string{"AUTH", "(redacted)", "(redacted)"}, slowlogArgs([]string{"AUTH", "user", "secret"}))
+	assert.Equal(t, []string{"HELLO", "3", "AUTH", "(redacted)", "(redacted)", "SETNAME", "app"},
+		slowlogArgs([]string{"HELLO", "3", "AUTH", "user", "secret", "SETNAME", "app"}))
+	assert.Equal(t, []string{"MIGRATE", "h", "1", "", "0", "10", "AUTH2", "(redacted)", "(redacted)", "KEYS", "auth"},
+		slowlogArgs([]string{"MIGRATE", "h", "1", "", "0", "10", "AUTH2", "user", "secret", "KEYS", "auth"}))
+	assert.Equal(t, []string{"CONFIG", "SET", "timeout", "0", "requirepass", "(redacted)", "MASTERAUTH", "(redacted)"},
+		slowlogArgs([]string{"CONFIG", "SET", "timeout", "0", "requirepass", "secret", "MASTERAUTH", "secret"}))
+	assert.Equal(t, []string{"ACL", "setuser", "alice", "on", "(redacted)", "(redacted)", "(redacted)", "(redacted)", "~*", "+get"},
+		slowlogArgs([]string{"ACL", "setuser", "alice", "on", ">secret", "<old", "#" + hashPassword("secret"), "!" + hashPassword("old"), "~*", "+get"}))
+
+	// 过长的参数和过多的参数会被截断
+	long := strings.Repeat("x", slowlogMaxArgLen+10)
+	assert.Equal(t, strings.Repeat("x", slowlogMaxArgLen)+"... (10 more bytes)", slowlogArgs([]string{"SET", long})[1])
+
+	many := make([]string, slowlogMaxArgc+5)
+	for i := range many {
+		many[i] = "DEL"
+	}
+	args := slowlogArgs(many)
+	require.Len(t, args, slowlogMaxArgc)
+	assert.Equal(t, "... (6 more arguments)", args[slowlogMaxArgc-1])
+}
+
+func TestSlowlogGetEmpty(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "SLOWLOG", "GET")
+	assert.Equal(t, resp.DataType(resp.TypeArray), response.Type)
+	assert.Empty(t, response.Array)
+}
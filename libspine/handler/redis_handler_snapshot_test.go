@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestLastSaveAdvancesAfterSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.snapshot")
+
+	handler := NewRedisHandler()
+	handler.SetSnapshotPath(path)
+	// 将上次保存时间回拨，避免测试依赖秒级时钟跳变
+	handler.snapshot.lastSave = time.Now().Add(-time.Hour)
+
+	before := runCommand(t, handler, "LASTSAVE")
+	runCommand(t, handler, "SET", "key", "value")
+
+	response := runCommand(t, handler, "SAVE")
+	assert.Equal(t, "OK", response.String)
+
+	after := runCommand(t, handler, "LASTSAVE")
+	assert.Greater(t, after.Int, before.Int)
+	assert.InDelta(t, time.Now().Unix(), after.Int, 1)
+}
+
+func TestLastSaveUnchangedAfterFailedSave(t *testing.T) {
+	handler := NewRedisHandler()
+	handler.SetSnapshotPath(filepath.Join(t.TempDir(), "missing", "dump.snapshot"))
+	handler.snapshot.lastSave = time.Now().Add(-time.Hour)
+
+	before := runCommand(t, handler, "LASTSAVE")
+
+	response := runCommand(t, handler, "SAVE")
+	assert.Equal(t, resp.DataType(resp.TypeError), response.Type)
+
+	after := runCommand(t, handler, "LASTSAVE")
+	assert.Equal(t, before.Int, after.Int)
+}
+
+func TestSaveWithoutSnapshotPath(t *testing.T) {
+	handler := NewRedisHandler()
+
+	response := runCommand(t, handler, "SAVE")
+	assert.Equal(t, resp.DataType(resp.TypeError), response.Type)
+	assert.Contains(t, response.String, "no snapshot path configured")
+}
+
+func TestSnapshotRestoresData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.snapshot")
+
+	handler := NewRedisHandler()
+	handler.SetSnapshotPath(path)
+	runCommand(t, handler, "SET", "a", "1")
+	runCommand(t, handler, "SET", "b", "2", "EX", "100")
+
+	response := runCommand(t, handler, "BGSAVE")
+	assert.Equal(t, "Background saving started", response.String)
+	require.Eventually(t, func() bool {
+		handler.mu.RLock()
+		defer handler.mu.RUnlock()
+		return !handler.snapshot.inProgress
+	}, time.Second, 10*time.Millisecond)
+
+	restarted := NewRedisHandler()
+	restarted.SetSnapshotPath(path)
+	require.NoError(t, restarted.LoadSnapshot())
+
+	value := runCommand(t, restarted, "GET", "a")
+	assert.Equal(t, "1", string(value.Bulk))
+	ttl := runCommand(t, restarted, "TTL", "b")
+	assert.Greater(t, ttl.Int, int64(90))
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// binaryValue 包含零字节、CRLF 和不构成合法 UTF-8 的高位字节
+const binaryValue = "a\x00b\r\n\xff\xfe\x80c"
+
+func TestStringCommandsAreBinarySafe(t *testing.T) {
+	handler := NewRedisHandler()
+	conn := dialRedis(t, serveRedis(t, handler))
+
+	key := "key\x00\xff"
+	assert.Equal(t, "OK", conn.do(t, "SET", key, binaryValue).String)
+	assert.Equal(t, binaryValue, string(conn.do(t, "GET", key).Bulk))
+	assert.Equal(t, int64(len(binaryValue)), conn.do(t, "STRLEN", key).Int)
+	assert.Equal(t, "\x00b\r", string(conn.do(t, "GETRANGE", key, "1", "3").Bulk))
+	assert.Equal(t, "\xfe\x80c", string(conn.do(t, "GETRANGE", key, "-3", "-1").Bulk))
+
+	assert.Equal(t, int64(len(binaryValue)+2), conn.do(t, "APPEND", key, "\x00\xc3").Int)
+	assert.Equal(t, binaryValue+"\x00\xc3", string(conn.do(t, "GET", key).Bulk))
+
+	// 覆盖多字节 UTF-8 字符的一半，按字节而不是按字符处理
+	conn.do(t, "SET", "utf8", "héllo")
+	assert.Equal(t, int64(6), conn.do(t, "STRLEN", "utf8").Int)
+	assert.Equal(t, "\xc3", string(conn.do(t, "GETRANGE", "utf8", "1", "1").Bulk))
+	assert.Equal(t, int64(6), conn.do(t, "SETRANGE", "utf8", "2", "\x00").Int)
+	assert.Equal(t, "h\xc3\x00llo", string(conn.do(t, "GET", "utf8").Bulk))
+}
+
+func TestGetRange(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "key", "This is a string")
+
+	for _, tt := range []struct {
+		start, end, want string
+	}{
+		{"0", "3", "This"},
+		{"-3", "-1", "ing"},
+		{"0", "-1", "This is a string"},
+		{"10", "100", "string"},
+		{"5", "3", ""},
+		{"-1", "-5", ""},
+		{"-100", "3", "This"},
+	} {
+		reply := runCommand(t, handler, "GETRANGE", "key", tt.start, tt.end)
+		assert.Equal(t, tt.want, string(reply.Bulk), "GETRANGE %s %s", tt.start, tt.end)
+	}
+	assert.Equal(t, "This", string(runCommand(t, handler, "SUBSTR", "key", "0", "3").Bulk))
+	assert.Equal(t, "", string(runCommand(t, handler, "GETRANGE", "missing", "0", "-1").Bulk))
+	assert.Equal(t, "ERR value is not an integer or out of range", runCommand(t, handler, "GETRANGE", "key", "a", "1").String)
+	assert.Equal(t, "ERR wrong number of arguments for 'substr' command", runCommand(t, handler, "substr", "key").String)
+}
+
+func TestSetRangeAndAppend(t *testing.T) {
+	handler := NewRedisHandler()
+
+	// 超出原长度时用零字节补齐
+	assert.Equal(t, int64(8), runCommand(t, handler, "SETRANGE", "key", "5", "abc").Int)
+	assert.Equal(t, "\x00\x00\x00\x00\x00abc", string(runCommand(t, handler, "GET", "key").Bulk))
+	assert.Equal(t, int64(8), runCommand(t, handler, "SETRANGE", "key", "0", "xy").Int)
+	assert.Equal(t, "xy\x00\x00\x00abc", string(runCommand(t, handler, "GET", "key").Bulk))
+
+	// 空值不创建键
+	assert.Equal(t, int64(0), runCommand(t, handler, "SETRANGE", "empty", "10", "").Int)
+	assert.Equal(t, int64(0), runCommand(t, handler, "EXISTS", "empty").Int)
+	assert.Equal(t, "ERR offset is out of range", runCommand(t, handler, "SETRANGE", "key", "-1", "x").String)
+	assert.Equal(t, "ERR string exceeds maximum allowed size (proto-max-bulk-len)",
+		runCommand(t, handler, "SETRANGE", "key", "536870911", "xy").String)
+
+	assert.Equal(t, int64(5), runCommand(t, handler, "APPEND", "new", "hello").Int)
+	assert.Equal(t, int64(0), runCommand(t, handler, "STRLEN", "missing").Int)
+
+	// APPEND 和 SETRANGE 保留过期时间
+	runCommand(t, handler, "SET", "ttl", "value", "EX", "100")
+	runCommand(t, handler, "APPEND", "ttl", "!")
+	runCommand(t, handler, "SETRANGE", "ttl", "0", "V")
+	assert.Equal(t, "Value!", string(runCommand(t, handler, "GET", "ttl").Bulk))
+	assert.Greater(t, runCommand(t, handler, "TTL", "ttl").Int, int64(90))
+
+	// 已过期的键视为不存在
+	runCommand(t, handler, "SET", "expired", "old", "PX", "1")
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, int64(3), runCommand(t, handler, "APPEND", "expired", "new").Int)
+	assert.Equal(t, int64(-1), runCommand(t, handler, "TTL", "expired").Int)
+}
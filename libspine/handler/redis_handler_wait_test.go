@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"spine-go/libspine/common/resp"
+)
+
+func TestWaitWithoutReplicas(t *testing.T) {
+	handler := NewRedisHandler()
+	runCommand(t, handler, "SET", "key", "value")
+
+	start := time.Now()
+	reply := runCommand(t, handler, "WAIT", "0", "0")
+	assert.Equal(t, resp.DataType(resp.TypeInteger), reply.Type, reply.String)
+	assert.Equal(t, int64(0), reply.Int)
+	assert.Less(t, time.Since(start), 50*time.Millisecond, "WAIT 0 0 should not block")
+
+	start = time.Now()
+	reply = runCommand(t, handler, "WAIT", "1", "100")
+	elapsed := time.Since(start)
+	assert.Equal(t, int64(0), reply.Int)
+	assert.GreaterOrEqual(t, elapsed, 100*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+}
+
+func TestWaitArguments(t *testing.T) {
+	handler := NewRedisHandler()
+	assert.Equal(t, "ERR value is not an integer or out of range", runCommand(t, handler, "WAIT", "x", "0").String)
+	assert.Equal(t, "ERR timeout is not an integer or out of range", runCommand(t, handler, "WAIT", "0", "x").String)
+	assert.Equal(t, "ERR timeout is negative", runCommand(t, handler, "WAIT", "1", "-1").String)
+	assert.Equal(t, "ERR wrong number of arguments for 'wait' command", runCommand(t, handler, "WAIT", "1").String)
+}
+
+func TestWaitReturnsWhenClientIsKilled(t *testing.T) {
+	handler := NewRedisHandler()
+	client := newRedisClient()
+	done := make(chan int64, 1)
+	go func() {
+		done <- runClientCommand(t, handler, client, "WAIT", "1", "0").Int
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	client.kill()
+	select {
+	case n := <-done:
+		assert.Equal(t, int64(0), n)
+	case <-time.After(2 * time.Second):
+		t.Fatal("WAIT did not return after the client was killed")
+	}
+}
@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"spine-go/libspine/common/resp"
+	"spine-go/libspine/transport"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// serverVersion spine-go 自身的版本，与 HELLO 的回复一致
+	serverVersion = "1.0.0"
+	// compatibleRedisVersion 兼容的 Redis 版本，部分客户端据此判断可用的命令
+	compatibleRedisVersion = "7.0.0"
+)
+
+// 构建信息，发布时通过 -ldflags "-X spine-go/libspine/handler.buildCommit=... -X spine-go/libspine/handler.buildDate=..." 设置
+var (
+	buildCommit string
+	buildDate   string
+)
+
+// buildInfo 返回构建时的提交和时间，没有通过 ldflags 设置时取 Go 工具链记录的版本控制信息
+func buildInfo() (commit, date string) {
+	commit, date = buildCommit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && commit == "":
+				commit = setting.Value
+			case setting.Key == "vcs.time" && date == "":
+				date = setting.Value
+			}
+		}
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return commit, date
+}
+
+// serverStats 服务器运行统计，由命令分发和连接处理更新
+type serverStats struct {
+	startTime           time.Time
+	connectionsReceived atomic.Int64
+	commandsProcessed   atomic.Int64
+	keyspaceHits        atomic.Int64
+	keyspaceMisses      atomic.Int64
+	expiredKeys         atomic.Int64
+	evictedKeys         atomic.Int64
+}
+
+// reset 清零统计，用于 CONFIG RESETSTAT
+func (s *serverStats) reset() {
+	s.connectionsReceived.Store(0)
+	s.commandsProcessed.Store(0)
+	s.keyspaceHits.Store(0)
+	s.keyspaceMisses.Store(0)
+	s.expiredKeys.Store(0)
+	s.evictedKeys.Store(0)
+}
+
+// recordLookup 记录一次读取键的命中或未命中
+func (s *serverStats) recordLookup(hit bool) {
+	if hit {
+		s.keyspaceHits.Add(1)
+	} else {
+		s.keyspaceMisses.Add(1)
+	}
+}
+
+// infoSections INFO 默认输出的分节，按输出顺序排列
+var infoSections = []string{"server", "clients", "memory", "stats", "replication", "cluster", "keyspace"}
+
+// allInfoSections INFO all 输出的分节，包含默认不输出的 commandstats
+var allInfoSections = []string{"server", "clients", "memory", "stats", "replication", "commandstats", "cluster", "keyspace"}
+
+// handleLOLWUT 处理 LOLWUT 命令，返回服务器版本和构建信息，VERSION 只做格式检查
+// LOLWUT [VERSION version]
+func (h *RedisHandler) handleLOLWUT(client *redisClient, command []string, writer *resp.RespWriter) error {
+	switch {
+	case len(command) == 1:
+	case len(command) == 3 && strings.EqualFold(command[1], "VERSION"):
+		if _, err := strconv.ParseInt(command[2], 10, 64); err != nil {
+			return resp.NewCommandError("value is not an integer or out of range")
+		}
+	default:
+		return resp.NewSyntaxError()
+	}
+
+	commit, date := buildInfo()
+	var banner strings.Builder
+	fmt.Fprintf(&banner, "spine-go ver. %s (Redis %s compatible)\n", serverVersion, compatibleRedisVersion)
+	fmt.Fprintf(&banner, "commit: %s built: %s\n", commit, date)
+	fmt.Fprintf(&banner, "%s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return writer.WriteBulkStringString(banner.String())
+}
+
+// handleINFO 处理 INFO 命令
+// INFO [section [section ...]]
+func (h *RedisHandler) handleINFO(client *redisClient, command []string, writer *resp.RespWriter) error {
+	wanted := make(map[string]bool)
+	for _, arg := range command[1:] {
+		switch section := strings.ToLower(arg); section {
+		case "default":
+			for _, name := range infoSections {
+				wanted[name] = true
+			}
+		case "all", "everything":
+			for _, name := range allInfoSections {
+				wanted[name] = true
+			}
+		default:
+			wanted[section] = true
+		}
+	}
+	if len(wanted) == 0 {
+		for _, name := range infoSections {
+			wanted[name] = true
+		}
+	}
+
+	var sections []string
+	for _, name := range allInfoSections {
+		if wanted[name] {
+			sections = append(sections, h.infoSection(name))
+		}
+	}
+	return writer.WriteBulkStringString(strings.Join(sections, "\r\n"))
+}
+
+// infoSection 生成单个分节的文本，每行为 field:value
+func (h *RedisHandler) infoSection(name string) string {
+	var fields [][2]string
+	add := func(field string, value interface{}) {
+		fields = append(fields, [2]string{field, fmt.Sprint(value)})
+	}
+
+	switch name {
+	case "server":
+		uptime := time.Since(h.stats.startTime)
+		add("redis_version", compatibleRedisVersion)
+		add("server_name", "spine-go")
+		add("server_version", serverVersion)
+		commit, _ := buildInfo()
+		add("redis_git_sha1", commit)
+		add("redis_mode", "standalone")
+		add("os", runtime.GOOS+" "+runtime.GOARCH)
+		add("arch_bits", strconv.IntSize)
+		add("go_version", runtime.Version())
+		add("process_id", os.Getpid())
+		add("tcp_port", h.tcpPort())
+		add("uptime_in_seconds", int64(uptime.Seconds()))
+		add("uptime_in_days", int64(uptime.Hours()/24))
+
+	case "clients":
+		add("connected_clients", h.clients.count())
+
+	case "memory":
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		add("used_memory", mem.HeapAlloc)
+		add("used_memory_human", humanBytes(mem.HeapAlloc))
+		add("used_memory_rss", mem.Sys)
+		add("used_memory_rss_human", humanBytes(mem.Sys))
+		h.configMu.RLock()
+		add("maxmemory", h.config.maxmemory)
+		add("maxmemory_human", humanBytes(uint64(h.config.maxmemory)))
+		add("maxmemory_policy", h.config.maxmemoryPolicy)
+		h.configMu.RUnlock()
+		add("mem_allocator", "go")
+
+	case "stats":
+		add("total_connections_received", h.stats.connectionsReceived.Load())
+		add("total_commands_processed", h.stats.commandsProcessed.Load())
+		add("keyspace_hits", h.stats.keyspaceHits.Load())
+		add("keyspace_misses", h.stats.keyspaceMisses.Load())
+		add("expired_keys", h.stats.expiredKeys.Load())
+		add("evicted_keys", h.stats.evictedKeys.Load())
+
+	case "replication":
+		if link := h.master.Load(); link != nil {
+			up, offset := link.status()
+			status := "down"
+			if up {
+				status = "up"
+			}
+			add("role", "slave")
+			add("master_host", link.host)
+			add("master_port", link.port)
+			add("master_link_status", status)
+			add("slave_repl_offset", offset)
+			readOnly := 0
+			if h.replicaReadOnly() {
+				readOnly = 1
+			}
+			add("slave_read_only", readOnly)
+		} else {
+			add("role", "master")
+		}
+		replicas := h.primary.replicaInfo()
+		add("connected_slaves", len(replicas))
+		for i, replica := range replicas {
+			add(fmt.Sprintf("slave%d", i), replica)
+		}
+		h.primary.mu.Lock()
+		add("master_replid", h.primary.replid)
+		add("master_repl_offset", h.primary.repOff)
+		h.primary.mu.Unlock()
+
+	case "commandstats":
+		// 只输出执行过的命令
+		for _, cmd := range h.sortedCommands() {
+			if calls := cmd.calls.Load(); calls > 0 {
+				add("cmdstat_"+cmd.Name, fmt.Sprintf("calls=%d", calls))
+			}
+		}
+
+	case "cluster":
+		add("cluster_enabled", 0)
+
+	case "keyspace":
+		// 与 Redis 一致，没有键的数据库不输出
+		keys, expires, avgTTL := h.keyspaceStats()
+		if keys > 0 {
+			add("db0", fmt.Sprintf("keys=%d,expires=%d,avg_ttl=%d", keys, expires, avgTTL))
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("# " + strings.ToUpper(name[:1]) + name[1:] + "\r\n")
+	for _, field := range fields {
+		builder.WriteString(field[0] + ":" + field[1] + "\r\n")
+	}
+	return builder.String()
+}
+
+// keyspaceStats 统计未过期的键数、带过期时间的键数以及平均剩余生存时间（毫秒）
+func (h *RedisHandler) keyspaceStats() (keys, expires, avgTTL int64) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	now := h.clock.Now()
+	var totalTTL int64
+	for _, item := range h.store {
+		if item.ExpiresAt != nil {
+			if item.expired(now) {
+				continue
+			}
+			expires++
+			totalTTL += item.ExpiresAt.Sub(now).Milliseconds()
+		}
+		keys++
+	}
+	if expires > 0 {
+		avgTTL = totalTTL / expires
+	}
+	return keys, expires, avgTTL
+}
+
+// handleDBSIZE 处理 DBSIZE 命令，返回未过期的键数
+func (h *RedisHandler) handleDBSIZE(client *redisClient, command []string, writer *resp.RespWriter) error {
+	keys, _, _ := h.keyspaceStats()
+	return writer.WriteInteger(keys)
+}
+
+// setServerInfo 记录传输层提供的服务器信息，供 INFO 使用
+func (h *RedisHandler) setServerInfo(info *transport.ServerInfo) {
+	if info != nil {
+		h.serverInfo.Store(info)
+	}
+}
+
+// tcpPort 从服务器监听地址中取出端口，未知时为 0
+func (h *RedisHandler) tcpPort() int {
+	info := h.serverInfo.Load()
+	if info == nil {
+		return 0
+	}
+	_, port, err := net.SplitHostPort(info.Address)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// humanBytes 按 Redis 的格式输出可读的字节数，例如 1.50M
+func humanBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value := float64(n)
+	for _, suffix := range []string{"K", "M", "G", "T"} {
+		value /= unit
+		if value < unit || suffix == "T" {
+			return fmt.Sprintf("%.2f%s", value, suffix)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteMetrics 以 Prometheus 文本格式输出运行统计，实现 transport.MetricsProvider
+func (h *RedisHandler) WriteMetrics(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	metric("spine_connected_clients", "gauge", "Number of client connections.")
+	fmt.Fprintf(out, "spine_connected_clients %d\n", h.clients.count())
+
+	metric("spine_connections_received_total", "counter", "Total number of connections accepted.")
+	fmt.Fprintf(out, "spine_connections_received_total %d\n", h.stats.connectionsReceived.Load())
+
+	metric("spine_commands_processed_total", "counter", "Total number of commands processed.")
+	fmt.Fprintf(out, "spine_commands_processed_total %d\n", h.stats.commandsProcessed.Load())
+
+	metric("spine_command_calls_total", "counter", "Number of calls per command.")
+	for _, cmd := range h.sortedCommands() {
+		if calls := cmd.calls.Load(); calls > 0 {
+			fmt.Fprintf(out, "spine_command_calls_total{command=%q} %d\n", cmd.Name, calls)
+		}
+	}
+
+	metric("spine_keyspace_hits_total", "counter", "Number of successful key lookups.")
+	fmt.Fprintf(out, "spine_keyspace_hits_total %d\n", h.stats.keyspaceHits.Load())
+
+	metric("spine_keyspace_misses_total", "counter", "Number of failed key lookups.")
+	fmt.Fprintf(out, "spine_keyspace_misses_total %d\n", h.stats.keyspaceMisses.Load())
+
+	keys, expires, _ := h.keyspaceStats()
+	metric("spine_keys", "gauge", "Number of keys in the database.")
+	fmt.Fprintf(out, "spine_keys %d\n", keys)
+	metric("spine_expiring_keys", "gauge", "Number of keys with an expiration.")
+	fmt.Fprintf(out, "spine_expiring_keys %d\n", expires)
+
+	return out.Flush()
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"spine-go/libspine/client"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMigrateTimeout timeout 参数不大于 0 时使用的超时时间，与 Redis 一致
+const defaultMigrateTimeout = time.Second
+
+// migrateArgs MIGRATE 命令解析后的参数
+type migrateArgs struct {
+	address  string
+	keys     []string
+	timeout  time.Duration
+	copy     bool
+	replace  bool
+	username string
+	password string
+}
+
+// parseMIGRATE 解析 MIGRATE 命令
+// MIGRATE host port key|"" destination-db timeout [COPY] [REPLACE] [AUTH password | AUTH2 username password] [KEYS key...]
+func parseMIGRATE(command []string) (*migrateArgs, error) {
+	if _, err := strconv.ParseUint(command[2], 10, 16); err != nil {
+		return nil, resp.NewCommandError("Invalid TCP port specified: %s", command[2])
+	}
+	db, err := strconv.ParseInt(command[4], 10, 64)
+	if err != nil {
+		return nil, resp.NewCommandError("value is not an integer or out of range")
+	}
+	// 只有一个数据库
+	if db != 0 {
+		return nil, resp.NewCommandError("DB index is out of range")
+	}
+	timeout, err := strconv.ParseInt(command[5], 10, 64)
+	if err != nil {
+		return nil, resp.NewCommandError("value is not an integer or out of range")
+	}
+
+	args := &migrateArgs{
+		address: net.JoinHostPort(command[1], command[2]),
+		timeout: time.Duration(timeout) * time.Millisecond,
+	}
+	if args.timeout <= 0 {
+		args.timeout = defaultMigrateTimeout
+	}
+
+	for i := 6; i < len(command); i++ {
+		switch strings.ToUpper(command[i]) {
+		case "COPY":
+			args.copy = true
+		case "REPLACE":
+			args.replace = true
+		case "AUTH":
+			if i+1 >= len(command) {
+				return nil, resp.NewSyntaxError()
+			}
+			args.username, args.password = "", command[i+1]
+			i++
+		case "AUTH2":
+			if i+2 >= len(command) {
+				return nil, resp.NewSyntaxError()
+			}
+			args.username, args.password = command[i+1], command[i+2]
+			i += 2
+		case "KEYS":
+			if command[3] != "" {
+				return nil, resp.NewCommandError("When using MIGRATE KEYS option, the key argument must be set to the empty string")
+			}
+			args.keys = command[i+1:]
+			i = len(command)
+		default:
+			return nil, resp.NewSyntaxError()
+		}
+	}
+	if args.keys == nil {
+		args.keys = command[3:4]
+	}
+	return args, nil
+}
+
+// handleMIGRATE 处理 MIGRATE 命令：把键 DUMP 后在目标服务器上 RESTORE，成功后删除本地的键，指定 COPY 时保留
+func (h *RedisHandler) handleMIGRATE(client *redisClient, command []string, writer *resp.RespWriter) error {
+	args, err := parseMIGRATE(command)
+	if err != nil {
+		return err
+	}
+
+	// 记录迁移时的值，删除前确认键没有被其他连接修改
+	type migrating struct {
+		key  string
+		item *RedisItem
+		ttl  int64
+	}
+	var items []migrating
+	h.mu.RLock()
+	now := h.clock.Now()
+	for _, key := range args.keys {
+		item, exists := h.store[key]
+		if !exists || item.expired(now) {
+			continue
+		}
+		var ttl int64
+		if item.ExpiresAt != nil {
+			// 剩余不足 1 毫秒时按 1 毫秒计算，0 会让目标上的键永不过期
+			ttl = max(item.ExpiresAt.Sub(now).Milliseconds(), 1)
+		}
+		items = append(items, migrating{key: key, item: item, ttl: ttl})
+	}
+	h.mu.RUnlock()
+
+	if len(items) == 0 {
+		client.unchanged = true
+		return writer.WriteSimpleString("NOKEY")
+	}
+
+	// 与目标服务器的网络往返不持有任何锁，不阻塞其他命令
+	ctx, cancel := context.WithTimeout(context.Background(), args.timeout)
+	defer cancel()
+	target, err := dialMigrateTarget(ctx, args)
+	if err != nil {
+		client.unchanged = true
+		return err
+	}
+	defer target.Close()
+
+	// RESTORE 中途失败时，已经迁移的键仍按成功处理，然后回复错误
+	restored := 0
+	for _, m := range items {
+		restore := []string{"RESTORE", m.key, strconv.FormatInt(m.ttl, 10), string(dumpValue(m.item.Value))}
+		if args.replace {
+			restore = append(restore, "REPLACE")
+		}
+		if _, err = target.Do(ctx, restore...); err != nil {
+			err = migrateError(err)
+			break
+		}
+		restored++
+	}
+
+	deleted := 0
+	if !args.copy {
+		// 只删除迁移成功且没有被其他连接修改的键，并只记录和传播这些键的删除
+		h.propagateMu.Lock()
+		h.mu.Lock()
+		del := []string{"DEL"}
+		for _, m := range items[:restored] {
+			if h.store[m.key] == m.item {
+				delete(h.store, m.key)
+				del = append(del, m.key)
+			}
+		}
+		h.mu.Unlock()
+		if deleted = len(del) - 1; deleted > 0 {
+			h.propagateLocked(h.currentAOF(), del)
+		}
+		h.propagateMu.Unlock()
+	}
+	client.unchanged = deleted == 0
+	if err != nil {
+		return err
+	}
+	return writer.WriteOK()
+}
+
+// dialMigrateTarget 连接目标服务器并按需认证
+func dialMigrateTarget(ctx context.Context, args *migrateArgs) (*client.Client, error) {
+	target, err := client.Dial(ctx, "tcp", args.address)
+	if err != nil {
+		return nil, resp.NewCommandErrorWithCode(resp.ErrCodeIOError, "error or timeout connecting to the client")
+	}
+	if args.password != "" {
+		if err := target.Auth(ctx, args.username, args.password); err != nil {
+			target.Close()
+			return nil, migrateError(err)
+		}
+	}
+	return target, nil
+}
+
+// migrateError 把与目标服务器通信时的错误转换为回复给客户端的错误
+func migrateError(err error) error {
+	if replyErr, ok := resp.AsCommandError(err); ok {
+		return resp.NewCommandError("Target instance replied with error: %s", replyErr.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return resp.NewCommandErrorWithCode(resp.ErrCodeIOError, "error or timeout reading to target instance")
+	}
+	return resp.NewCommandErrorWithCode(resp.ErrCodeIOError, "error or timeout writing to target instance")
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"fmt"
+	"spine-go/libspine/common/resp"
+	"strings"
+	"time"
+)
+
+// monitorBufferSize 每个 MONITOR 连接最多缓存的待发送行数，
+// 消费跟不上时断开该连接，避免拖慢其他命令
+const monitorBufferSize = 1024
+
+// handleMONITOR 处理 MONITOR 命令，连接进入监视模式后会收到服务器执行的每条命令
+func (h *RedisHandler) handleMONITOR(client *redisClient, command []string, writer *resp.RespWriter) error {
+	if client.monitorCh == nil {
+		ch := make(chan string, monitorBufferSize)
+		h.monitorMu.Lock()
+		h.monitors[client] = ch
+		h.monitorMu.Unlock()
+		client.monitorCh = ch
+	}
+	return writer.WriteOK()
+}
+
+// stopMonitor 让连接退出监视模式
+func (h *RedisHandler) stopMonitor(client *redisClient) {
+	if client.monitorCh == nil {
+		return
+	}
+	h.monitorMu.Lock()
+	delete(h.monitors, client)
+	h.monitorMu.Unlock()
+	client.monitorCh = nil
+}
+
+// feedMonitors 把即将执行的命令发送给所有监视连接
+func (h *RedisHandler) feedMonitors(client *redisClient, command []string) {
+	h.monitorMu.RLock()
+	defer h.monitorMu.RUnlock()
+	if len(h.monitors) == 0 {
+		return
+	}
+
+	line := monitorLine(time.Now(), client.addr, command)
+	for monitor, ch := range h.monitors {
+		select {
+		case ch <- line:
+		default:
+			monitor.kill()
+		}
+	}
+}
+
+// monitorLine 生成 MONITOR 输出的一行，格式与 Redis 一致：
+// 1339518083.107412 [0 127.0.0.1:60866] "set" "key" "value"
+func monitorLine(now time.Time, addr string, command []string) string {
+	if addr == "" {
+		addr = "local"
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d.%06d [0 %s]", now.Unix(), now.Nanosecond()/1000, addr)
+	for _, arg := range redactedArgs(command) {
+		builder.WriteByte(' ')
+		builder.WriteString(quoteMonitorArg(arg))
+	}
+	return builder.String()
+}
+
+// quoteMonitorArg 按 Redis 的 sdscatrepr 规则给参数加引号并转义不可打印字符
+func quoteMonitorArg(arg string) string {
+	var builder strings.Builder
+	builder.WriteByte('"')
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; c {
+		case '\\', '"':
+			builder.WriteByte('\\')
+			builder.WriteByte(c)
+		case '\n':
+			builder.WriteString(`\n`)
+		case '\r':
+			builder.WriteString(`\r`)
+		case '\t':
+			builder.WriteString(`\t`)
+		case '\a':
+			builder.WriteString(`\a`)
+		case '\b':
+			builder.WriteString(`\b`)
+		default:
+			if c >= ' ' && c <= '~' {
+				builder.WriteByte(c)
+			} else {
+				fmt.Fprintf(&builder, `\x%02x`, c)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+// monitorRead 监视模式下读取到的一条命令或读取错误
+type monitorRead struct {
+	value resp.Value
+	err   error
+}
+
+// serveMonitor 在连接进入监视模式后接管连接的处理循环：
+// 在后台读取客户端的命令，同时把其他连接执行的命令写给客户端，直到连接关闭
+func (h *RedisHandler) serveMonitor(client *redisClient, reader *resp.RespReader, writer *resp.RespWriter) error {
+	reads := make(chan monitorRead)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			value, err := reader.ReadValue()
+			select {
+			case reads <- monitorRead{value: value, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		if err := writer.Flush(); err != nil {
+			return nil
+		}
+
+		// 退出监视模式后 monitorCh 为 nil，只处理客户端的命令
+		select {
+		case line := <-client.monitorCh:
+			writer.WriteSimpleString(line)
+
+		case read := <-reads:
+			if read.err != nil {
+				return nil
+			}
+			command, ok := commandArgs(read.value)
+			if !ok {
+				writer.WriteSyntaxError("expected array of bulk strings")
+				continue
+			}
+			if !h.beginCommand() {
+				return nil
+			}
+			if err := h.processCommand(client, command, writer); err != nil {
+				h.endCommand()
+				return nil
+			}
+			h.endCommand()
+			if client.quitting {
+				return nil
+			}
+		}
+	}
+}
+
+// commandArgs 把 RESP 数组转换为命令参数
+func commandArgs(value resp.Value) ([]string, bool) {
+	if value.Type != resp.TypeArray || len(value.Array) == 0 {
+		return nil, false
+	}
+	command := make([]string, len(value.Array))
+	for i, item := range value.Array {
+		if item.Type != resp.TypeBulkString {
+			return nil, false
+		}
+		command[i] = string(item.Bulk)
+	}
+	return command, true
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// embstrSizeLimit 与 Redis 一致，不超过该长度的字符串报告为 embstr
+const embstrSizeLimit = 44
+
+// encoding 返回值的内部编码名称，对应 Redis 中字符串对象的三种编码
+func (item *RedisItem) encoding() string {
+	if len(item.Value) <= 20 {
+		if _, err := strconv.ParseInt(item.Value, 10, 64); err == nil {
+			return "int"
+		}
+	}
+	if len(item.Value) <= embstrSizeLimit {
+		return "embstr"
+	}
+	return "raw"
+}
+
+// touch 记录一次在 now 时的访问，更新最近访问时间和频率计数
+func (item *RedisItem) touch(now time.Time) {
+	item.LastAccess = now
+	// 频率计数与 Redis LFU 计数器一样在 255 处饱和
+	if item.Freq < 255 {
+		item.Freq++
+	}
+}
+
+// lookupItem 查找未过期的键，不修改存储
+func (h *RedisHandler) lookupItem(key string) (*RedisItem, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	item, exists := h.store[key]
+	if !exists {
+		return nil, false
+	}
+	if item.expired(h.clock.Now()) {
+		return nil, false
+	}
+	return item, true
+}
+
+// objectSubcommands OBJECT HELP 列出的子命令
+var objectSubcommands = []redisSubcommand{
+	{Syntax: "ENCODING <key>", Summary: "Return the kind of internal representation used in order to store the value\nassociated with a <key>."},
+	{Syntax: "FREQ <key>", Summary: "Return the access frequency index of the <key>. The returned integer is\nproportional to the logarithm of the recent access frequency of the key."},
+	{Syntax: "IDLETIME <key>", Summary: "Return the idle time of the <key>, that is the approximated number of\nseconds elapsed since the last access to the key."},
+	{Syntax: "REFCOUNT <key>", Summary: "Return the number of references of the value associated with the specified\n<key>."},
+}
+
+// handleOBJECT 处理 OBJECT 命令
+// OBJECT ENCODING|REFCOUNT|IDLETIME|FREQ key
+func (h *RedisHandler) handleOBJECT(client *redisClient, command []string, writer *resp.RespWriter) error {
+	subcommand := strings.ToUpper(command[1])
+	switch subcommand {
+	case "ENCODING":
+		if len(command) != 3 {
+			return writer.WriteWrongNumberOfArgumentsError("OBJECT|ENCODING")
+		}
+		item, exists := h.lookupItem(command[2])
+		if !exists {
+			return writer.WriteNil()
+		}
+		return writer.WriteBulkStringString(item.encoding())
+	case "REFCOUNT", "IDLETIME", "FREQ":
+		if len(command) != 3 {
+			return writer.WriteWrongNumberOfArgumentsError("OBJECT|" + subcommand)
+		}
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		item, exists := h.store[command[2]]
+		if !exists || item.expired(h.clock.Now()) {
+			return writer.WriteNil()
+		}
+		switch subcommand {
+		case "REFCOUNT":
+			// 值不在键之间共享，引用计数恒为 1
+			return writer.WriteInteger(1)
+		case "IDLETIME":
+			return writer.WriteInteger(int64(h.clock.Now().Sub(item.LastAccess).Seconds()))
+		default:
+			return writer.WriteInteger(int64(item.Freq))
+		}
+	default:
+		return resp.NewCommandError("unknown subcommand '%s'. Try OBJECT HELP.", command[1])
+	}
+}
+
+// handleTOUCH 处理 TOUCH 命令，更新键的访问时间和频率而不读取值，返回存在的键数
+// TOUCH key [key ...]
+func (h *RedisHandler) handleTOUCH(client *redisClient, command []string, writer *resp.RespWriter) error {
+	h.mu.Lock()
+	touched := 0
+	for _, key := range command[1:] {
+		if item := h.lookupLocked(key); item != nil {
+			item.touch(h.clock.Now())
+			touched++
+		}
+	}
+	h.mu.Unlock()
+	return writer.WriteInteger(int64(touched))
+}
@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// replicaReconnectInterval 与主节点的连接断开后重连的间隔
+	replicaReconnectInterval = time.Second
+	// replicaAckInterval 副本向主节点报告复制进度的间隔
+	replicaAckInterval = time.Second
+	// replicaHandshakeTimeout 握手和接收快照的超时时间
+	replicaHandshakeTimeout = 10 * time.Second
+)
+
+// masterLink 作为副本时与主节点的连接，断开后自动重连并重新全量同步
+type masterLink struct {
+	host string
+	port string
+	stop chan struct{}
+	done chan struct{}
+
+	mu     sync.Mutex
+	conn   net.Conn // 当前连接，未连接时为 nil
+	up     bool     // 是否已完成同步、正在接收命令流
+	replid string
+	offset int64 // 已处理的主节点复制偏移量
+}
+
+// handleREPLICAOF 处理 REPLICAOF 命令
+// REPLICAOF host port | REPLICAOF NO ONE
+func (h *RedisHandler) handleREPLICAOF(client *redisClient, command []string, writer *resp.RespWriter) error {
+	if strings.EqualFold(command[1], "NO") && strings.EqualFold(command[2], "ONE") {
+		if h.stopReplication() {
+			log.Printf("MASTER MODE enabled (user request from '%s')", client.addr)
+		}
+		return writer.WriteOK()
+	}
+
+	port, err := strconv.ParseUint(command[2], 10, 16)
+	if err != nil || port == 0 {
+		return resp.NewCommandError("Invalid master port")
+	}
+	if link := h.master.Load(); link != nil && link.host == command[1] && link.port == command[2] {
+		return writer.WriteSimpleString("OK Already connected to specified master")
+	}
+	h.startReplication(command[1], command[2])
+	log.Printf("REPLICAOF %s:%s enabled (user request from '%s')", command[1], command[2], client.addr)
+	return writer.WriteOK()
+}
+
+// startReplication 成为 host:port 的副本，替换已有的主节点连接
+func (h *RedisHandler) startReplication(host, port string) {
+	h.replicaofMu.Lock()
+	defer h.replicaofMu.Unlock()
+
+	if old := h.master.Load(); old != nil {
+		old.close()
+	}
+	link := &masterLink{
+		host: host,
+		port: port,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	h.master.Store(link)
+	go h.runMasterLink(link)
+}
+
+// stopReplication 断开与主节点的连接并提升为主节点，返回之前是否是副本
+func (h *RedisHandler) stopReplication() bool {
+	h.replicaofMu.Lock()
+	defer h.replicaofMu.Unlock()
+
+	link := h.master.Swap(nil)
+	if link == nil {
+		return false
+	}
+	link.close()
+	h.primary.resetID()
+	return true
+}
+
+// stopMasterLink 关闭时断开与主节点的连接，仍保留副本身份，不再接受写命令
+func (h *RedisHandler) stopMasterLink() {
+	h.replicaofMu.Lock()
+	defer h.replicaofMu.Unlock()
+	if link := h.master.Load(); link != nil {
+		link.close()
+	}
+}
+
+// close 停止同步并等待后台任务退出，调用方需持有 replicaofMu
+func (l *masterLink) close() {
+	if l.stopped() {
+		return
+	}
+	close(l.stop)
+	l.mu.Lock()
+	if l.conn != nil {
+		l.conn.Close()
+	}
+	l.mu.Unlock()
+	<-l.done
+}
+
+// stopped 是否已被 close
+func (l *masterLink) stopped() bool {
+	select {
+	case <-l.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// status 返回连接状态和已处理的复制偏移量
+func (l *masterLink) status() (up bool, offset int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.up, l.offset
+}
+
+// state 返回 ROLE 中的连接状态和已处理的复制偏移量：
+// connect 表示等待重连，sync 表示正在握手和全量同步，connected 表示正在接收命令流
+func (l *masterLink) state() (string, int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case l.up:
+		return "connected", l.offset
+	case l.conn != nil:
+		return "sync", l.offset
+	}
+	return "connect", l.offset
+}
+
+// runMasterLink 保持与主节点的同步，连接断开后重连，直到 close
+func (h *RedisHandler) runMasterLink(link *masterLink) {
+	defer close(link.done)
+	for {
+		err := h.syncWithMaster(link)
+		if link.stopped() {
+			return
+		}
+		log.Printf("Replication with master %s:%s failed: %v", link.host, link.port, err)
+		select {
+		case <-link.stop:
+			return
+		case <-time.After(replicaReconnectInterval):
+		}
+	}
+}
+
+// syncWithMaster 连接主节点、全量同步，然后应用命令流直到连接断开
+func (h *RedisHandler) syncWithMaster(link *masterLink) error {
+	dialer := net.Dialer{Timeout: replicaHandshakeTimeout}
+	conn, err := dialer.Dial("tcp", net.JoinHostPort(link.host, link.port))
+	if err != nil {
+		return err
+	}
+	link.mu.Lock()
+	if link.stopped() {
+		link.mu.Unlock()
+		conn.Close()
+		return nil
+	}
+	link.conn = conn
+	link.mu.Unlock()
+	defer func() {
+		link.mu.Lock()
+		link.conn = nil
+		link.up = false
+		link.mu.Unlock()
+		conn.Close()
+	}()
+
+	// 握手期间只有当前 goroutine 写连接，之后与定时 ACK 共用，由 writeMu 保护
+	var writeMu sync.Mutex
+	send := func(command ...string) error {
+		data, err := resp.SerializeCommand(command[0], command[1:]...)
+		if err != nil {
+			return err
+		}
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err = conn.Write(data)
+		return err
+	}
+	parser := resp.NewParser(bufio.NewReader(conn))
+	call := func(command ...string) (resp.Value, error) {
+		if err := send(command...); err != nil {
+			return resp.Value{}, err
+		}
+		reply, err := parser.Parse()
+		if err != nil {
+			return reply, err
+		}
+		if reply.Type == resp.TypeError {
+			return reply, fmt.Errorf("%s replied: %s", strings.ToUpper(command[0]), reply.String)
+		}
+		return reply, nil
+	}
+
+	conn.SetDeadline(time.Now().Add(replicaHandshakeTimeout))
+	if password, _ := h.GetConfig("masterauth"); password != "" {
+		if _, err := call("AUTH", password); err != nil {
+			return err
+		}
+	}
+	if _, err := call("PING"); err != nil {
+		return err
+	}
+	if port := h.tcpPort(); port > 0 {
+		if _, err := call("REPLCONF", "listening-port", strconv.Itoa(port)); err != nil {
+			return err
+		}
+	}
+	reply, err := call("PSYNC", "?", "-1")
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(reply.String)
+	if len(fields) != 3 || fields[0] != "FULLRESYNC" {
+		return fmt.Errorf("unexpected reply to PSYNC: %q", reply.String)
+	}
+	offset, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return fmt.Errorf("unexpected reply to PSYNC: %q", reply.String)
+	}
+	payload, err := parser.Parse()
+	if err != nil {
+		return err
+	}
+	if payload.Type != resp.TypeBulkString {
+		return errors.New("master did not send a snapshot")
+	}
+	conn.SetDeadline(time.Time{})
+
+	// 用主节点的快照替换全部数据，经由命令表执行，本地的 AOF 和下游副本也随之更新
+	if err := h.applyFromMaster([]string{"FLUSHALL"}); err != nil {
+		return err
+	}
+	loaded := 0
+	snapshot := resp.NewParser(bytes.NewReader(payload.Bulk))
+	for {
+		value, err := snapshot.Parse()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("bad snapshot format: %v", err)
+		}
+		command, ok := commandArgs(value)
+		if !ok {
+			return errors.New("bad snapshot format")
+		}
+		if err := h.applyFromMaster(command); err != nil {
+			return err
+		}
+		loaded++
+	}
+	link.mu.Lock()
+	link.up, link.replid, link.offset = true, fields[1], offset
+	link.mu.Unlock()
+	log.Printf("MASTER <-> REPLICA sync: loaded %d keys from %s:%s", loaded, link.host, link.port)
+
+	// 定时报告复制进度，供主节点的 WAIT 使用
+	stopAcks := make(chan struct{})
+	defer close(stopAcks)
+	go func() {
+		ticker := time.NewTicker(replicaAckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_, offset := link.status()
+				send("REPLCONF", "ACK", strconv.FormatInt(offset, 10))
+			case <-stopAcks:
+				return
+			}
+		}
+	}()
+
+	for {
+		value, err := parser.Parse()
+		if err != nil {
+			return err
+		}
+		command, ok := commandArgs(value)
+		if !ok {
+			return errors.New("bad command in replication stream")
+		}
+
+		link.mu.Lock()
+		link.offset += commandSize(command)
+		offset := link.offset
+		link.mu.Unlock()
+
+		if len(command) >= 2 && strings.EqualFold(command[0], "REPLCONF") && strings.EqualFold(command[1], "GETACK") {
+			if err := send("REPLCONF", "ACK", strconv.FormatInt(offset, 10)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := h.applyFromMaster(command); err != nil {
+			return err
+		}
+	}
+}
+
+// applyFromMaster 执行主节点传播的命令，回复被丢弃
+func (h *RedisHandler) applyFromMaster(command []string) error {
+	if !h.beginCommand() {
+		return errors.New("server is shutting down")
+	}
+	defer h.endCommand()
+	return h.processCommand(h.masterClient, command, resp.NewRespWriter(discardWriter{}))
+}
+
+// replicaReadOnly 返回 replica-read-only 配置，为 true 时副本拒绝客户端的写命令
+func (h *RedisHandler) replicaReadOnly() bool {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.config.replicaReadOnly
+}
@@ -0,0 +1,423 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// waitPollInterval WAIT 阻塞期间检查连接和服务器状态的间隔
+	waitPollInterval = 100 * time.Millisecond
+	// replicaBufferLimit 每个副本最多积压的待发送字节数，超过时断开该副本，避免拖慢其他命令
+	replicaBufferLimit = 64 << 20
+)
+
+// replicationBackend 复制后端：记录传播给副本的数据量并统计副本的确认进度
+type replicationBackend interface {
+	// offset 返回当前的复制偏移量，即已传播给副本的命令总字节数
+	offset() int64
+	// ackedReplicas 返回已确认收到 offset 之前全部数据的副本数
+	ackedReplicas(offset int64) int
+	// requestAcks 请求副本尽快报告复制进度
+	requestAcks()
+	// ackUpdated 返回的 channel 在任一副本下一次报告进度时关闭
+	ackUpdated() <-chan struct{}
+}
+
+// primaryReplication 作为主节点时的复制状态：已连接的副本和传播给它们的命令流
+type primaryReplication struct {
+	mu       sync.Mutex
+	replid   string
+	repOff   int64
+	replicas map[*redisClient]*replicaConn
+	acked    chan struct{} // 任一副本报告进度时关闭并替换
+}
+
+// replicaConn 主节点上一个副本连接的状态，由 primaryReplication.mu 保护
+type replicaConn struct {
+	pending      [][]string    // 待发送给副本的命令
+	pendingBytes int64         // pending 的总字节数
+	notify       chan struct{} // 有新命令时发送信号，容量为 1
+	ackOffset    int64         // 副本最近一次报告的复制偏移量
+	addr         string
+	port         string // 副本通过 REPLCONF listening-port 报告的端口
+}
+
+// newPrimaryReplication 创建没有副本的复制状态
+func newPrimaryReplication() *primaryReplication {
+	return &primaryReplication{
+		replid:   newReplicationID(),
+		replicas: make(map[*redisClient]*replicaConn),
+		acked:    make(chan struct{}),
+	}
+}
+
+// newReplicationID 生成 40 个十六进制字符的复制 ID
+func newReplicationID() string {
+	buf := make([]byte, 20)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// commandSize 返回命令序列化为 RESP 数组后的字节数，主节点和副本用它计算复制偏移量
+func commandSize(command []string) int64 {
+	size := 1 + len(strconv.Itoa(len(command))) + 2
+	for _, arg := range command {
+		size += 1 + len(strconv.Itoa(len(arg))) + 2 + len(arg) + 2
+	}
+	return int64(size)
+}
+
+func (p *primaryReplication) offset() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.repOff
+}
+
+func (p *primaryReplication) ackedReplicas(offset int64) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, replica := range p.replicas {
+		if replica.ackOffset >= offset {
+			n++
+		}
+	}
+	return n
+}
+
+func (p *primaryReplication) requestAcks() {
+	p.propagate([]string{"REPLCONF", "GETACK", "*"})
+}
+
+func (p *primaryReplication) ackUpdated() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.acked
+}
+
+// hasReplicas 是否有已连接的副本
+func (p *primaryReplication) hasReplicas() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.replicas) > 0
+}
+
+// propagate 把命令追加到每个副本的待发送队列，没有副本时不做任何事
+func (p *primaryReplication) propagate(command []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.replicas) == 0 {
+		return
+	}
+
+	size := commandSize(command)
+	p.repOff += size
+	for client, replica := range p.replicas {
+		if replica.pendingBytes+size > replicaBufferLimit {
+			// 副本跟不上时断开，由副本重新全量同步
+			client.kill()
+			continue
+		}
+		replica.pending = append(replica.pending, command)
+		replica.pendingBytes += size
+		select {
+		case replica.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// addReplica 注册副本，返回复制 ID 和副本开始接收命令流时的偏移量
+func (p *primaryReplication) addReplica(client *redisClient) (*replicaConn, string, int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	replica := &replicaConn{
+		notify:    make(chan struct{}, 1),
+		ackOffset: p.repOff,
+		addr:      client.addr,
+		port:      client.replicaPort,
+	}
+	p.replicas[client] = replica
+	return replica, p.replid, p.repOff
+}
+
+// removeReplica 注销副本
+func (p *primaryReplication) removeReplica(client *redisClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.replicas, client)
+}
+
+// take 取出副本的全部待发送命令
+func (p *primaryReplication) take(replica *replicaConn) [][]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pending := replica.pending
+	replica.pending = nil
+	replica.pendingBytes = 0
+	return pending
+}
+
+// ack 记录副本报告的复制偏移量并唤醒等待中的 WAIT
+func (p *primaryReplication) ack(client *redisClient, offset int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	replica, exists := p.replicas[client]
+	if !exists {
+		return
+	}
+	if offset > replica.ackOffset {
+		replica.ackOffset = offset
+	}
+	close(p.acked)
+	p.acked = make(chan struct{})
+}
+
+// resetID 更换复制 ID，副本提升为主节点时调用，表示开始了新的复制历史
+func (p *primaryReplication) resetID() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.replid = newReplicationID()
+}
+
+// replicaInfo 返回 INFO replication 中每个副本 slaveN 字段的值
+func (p *primaryReplication) replicaInfo() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	infos := make([]string, 0, len(p.replicas))
+	for _, replica := range p.replicas {
+		host, _, err := net.SplitHostPort(replica.addr)
+		if err != nil {
+			host = replica.addr
+		}
+		infos = append(infos, fmt.Sprintf("ip=%s,port=%s,state=online,offset=%d,lag=0", host, replica.port, replica.ackOffset))
+	}
+	sort.Strings(infos)
+	return infos
+}
+
+// replicaRoles 返回 ROLE 回复中的副本列表，每个副本为 [ip, port, 已确认的偏移量]
+func (p *primaryReplication) replicaRoles() []resp.Value {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	roles := make([]resp.Value, 0, len(p.replicas))
+	for _, replica := range p.replicas {
+		host, _, err := net.SplitHostPort(replica.addr)
+		if err != nil {
+			host = replica.addr
+		}
+		roles = append(roles, resp.NewArray([]resp.Value{
+			resp.NewBulkStringString(host),
+			resp.NewBulkStringString(replica.port),
+			resp.NewBulkStringString(strconv.FormatInt(replica.ackOffset, 10)),
+		}))
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		a, b := roles[i].Array, roles[j].Array
+		if string(a[0].Bulk) != string(b[0].Bulk) {
+			return string(a[0].Bulk) < string(b[0].Bulk)
+		}
+		return string(a[1].Bulk) < string(b[1].Bulk)
+	})
+	return roles
+}
+
+// handleROLE 处理 ROLE 命令，格式与 Redis 一致：
+// 主节点返回 [master, 复制偏移量, [[ip, port, offset], ...]]，
+// 副本返回 [slave, 主节点地址, 主节点端口, 连接状态, 已处理的偏移量]
+func (h *RedisHandler) handleROLE(client *redisClient, command []string, writer *resp.RespWriter) error {
+	if link := h.master.Load(); link != nil {
+		port, _ := strconv.ParseInt(link.port, 10, 64)
+		state, offset := link.state()
+		return writer.WriteArray([]resp.Value{
+			resp.NewBulkStringString("slave"),
+			resp.NewBulkStringString(link.host),
+			resp.NewInteger(port),
+			resp.NewBulkStringString(state),
+			resp.NewInteger(offset),
+		})
+	}
+	return writer.WriteArray([]resp.Value{
+		resp.NewBulkStringString("master"),
+		resp.NewInteger(h.primary.offset()),
+		resp.NewArray(h.primary.replicaRoles()),
+	})
+}
+
+// handleREPLCONF 处理 REPLCONF 命令，副本在同步前报告自身信息，同步后报告复制进度
+// REPLCONF listening-port port | REPLCONF capa capability | REPLCONF ACK offset
+func (h *RedisHandler) handleREPLCONF(client *redisClient, command []string, writer *resp.RespWriter) error {
+	if len(command) < 3 || len(command)%2 != 1 {
+		return resp.NewSyntaxError()
+	}
+	for i := 1; i < len(command); i += 2 {
+		switch strings.ToLower(command[i]) {
+		case "listening-port":
+			if _, err := strconv.ParseUint(command[i+1], 10, 16); err != nil {
+				return resp.NewCommandError("value is not an integer or out of range")
+			}
+			client.replicaPort = command[i+1]
+		case "capa", "ip-address":
+			// 只支持全量同步，忽略副本声明的能力
+		case "ack":
+			// 与 Redis 一致，ACK 没有回复
+			if offset, err := strconv.ParseInt(command[i+1], 10, 64); err == nil {
+				h.primary.ack(client, offset)
+			}
+			return nil
+		default:
+			return resp.NewCommandError("Unrecognized REPLCONF option: %s", command[i])
+		}
+	}
+	return writer.WriteOK()
+}
+
+// handlePSYNC 处理 PSYNC 和 SYNC 命令：把当前数据作为快照发给副本，之后连接转为传播命令流。
+// 总是进行全量同步，PSYNC 的参数只做格式检查
+// PSYNC replicationid offset | SYNC
+func (h *RedisHandler) handlePSYNC(client *redisClient, command []string, writer *resp.RespWriter) error {
+	psync := strings.EqualFold(command[0], "PSYNC")
+	if psync && len(command) != 3 || !psync && len(command) != 1 {
+		return writer.WriteWrongNumberOfArgumentsError(strings.ToUpper(command[0]))
+	}
+	if client.closer == nil {
+		return resp.NewCommandError("Replication is only supported on network connections")
+	}
+	if client.replica != nil {
+		return resp.NewCommandError("Replica already connected")
+	}
+
+	// 持有 propagateMu 时没有修改数据的命令在执行，快照与命令流的起点一致
+	h.propagateMu.Lock()
+	snapshot := h.snapshotCommands()
+	replica, replid, offset := h.primary.addReplica(client)
+	h.propagateMu.Unlock()
+	client.replica = replica
+
+	var payload strings.Builder
+	for _, cmd := range snapshot {
+		data, err := resp.SerializeCommand(cmd[0], cmd[1:]...)
+		if err != nil {
+			return err
+		}
+		payload.Write(data)
+	}
+	if psync {
+		writer.WriteSimpleString(fmt.Sprintf("FULLRESYNC %s %d", replid, offset))
+	}
+	return writer.WriteBulkStringString(payload.String())
+}
+
+// serveReplica 在全量同步之后接管副本连接的处理循环：
+// 把修改数据的命令传播给副本，同时读取副本报告的复制进度，直到连接关闭
+func (h *RedisHandler) serveReplica(client *redisClient, reader *resp.RespReader, writer *resp.RespWriter) error {
+	defer h.primary.removeReplica(client)
+
+	reads := make(chan monitorRead)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			value, err := reader.ReadValue()
+			select {
+			case reads <- monitorRead{value: value, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		if err := writer.Flush(); err != nil {
+			return nil
+		}
+
+		select {
+		case <-client.replica.notify:
+			for _, command := range h.primary.take(client.replica) {
+				writer.WriteArrayHeader(len(command))
+				for _, arg := range command {
+					writer.WriteBulkStringString(arg)
+				}
+			}
+
+		case read := <-reads:
+			if read.err != nil {
+				return nil
+			}
+			// 同步之后副本只会发送 REPLCONF ACK
+			command, ok := commandArgs(read.value)
+			if ok && len(command) == 3 && strings.EqualFold(command[0], "REPLCONF") && strings.EqualFold(command[1], "ACK") {
+				if offset, err := strconv.ParseInt(command[2], 10, 64); err == nil {
+					h.primary.ack(client, offset)
+				}
+			}
+		}
+	}
+}
+
+// handleWAIT 处理 WAIT 命令，阻塞直到至少 numreplicas 个副本确认了此前的全部写命令或超时
+// WAIT numreplicas timeout
+func (h *RedisHandler) handleWAIT(client *redisClient, command []string, writer *resp.RespWriter) error {
+	if h.master.Load() != nil {
+		return resp.NewCommandError("WAIT cannot be used with replica instances.")
+	}
+	numReplicas, err := strconv.ParseInt(command[1], 10, 64)
+	if err != nil {
+		return resp.NewCommandError("value is not an integer or out of range")
+	}
+	timeoutMs, err := strconv.ParseInt(command[2], 10, 64)
+	if err != nil {
+		return resp.NewCommandError("timeout is not an integer or out of range")
+	}
+	if timeoutMs < 0 {
+		return resp.NewCommandError("timeout is negative")
+	}
+
+	backend := h.replication
+	offset := backend.offset()
+	acked := backend.ackedReplicas(offset)
+	if int64(acked) >= numReplicas {
+		return writer.WriteInteger(int64(acked))
+	}
+	backend.requestAcks()
+
+	// timeout 为 0 表示一直等待
+	var deadline <-chan time.Time
+	if timeoutMs > 0 {
+		timer := time.NewTimer(time.Duration(timeoutMs) * time.Millisecond)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	poll := time.NewTicker(waitPollInterval)
+	defer poll.Stop()
+
+	for {
+		updated := backend.ackUpdated()
+		if acked = backend.ackedReplicas(offset); int64(acked) >= numReplicas {
+			return writer.WriteInteger(int64(acked))
+		}
+		select {
+		case <-updated:
+		case <-deadline:
+			return writer.WriteInteger(int64(backend.ackedReplicas(offset)))
+		case <-poll.C:
+			// 连接已关闭或服务器正在关闭时不再等待
+			if client.isKilled() || h.isShuttingDown() {
+				return writer.WriteInteger(int64(backend.ackedReplicas(offset)))
+			}
+		}
+	}
+}
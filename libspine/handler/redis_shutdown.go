@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// beginCommand 登记一条正在执行的命令，服务器关闭中时返回 false
+func (h *RedisHandler) beginCommand() bool {
+	h.lifecycleMu.Lock()
+	defer h.lifecycleMu.Unlock()
+
+	if h.shuttingDown {
+		return false
+	}
+	h.inflight.Add(1)
+	return true
+}
+
+// isShuttingDown 服务器是否正在关闭
+func (h *RedisHandler) isShuttingDown() bool {
+	h.lifecycleMu.Lock()
+	defer h.lifecycleMu.Unlock()
+	return h.shuttingDown
+}
+
+// endCommand 命令执行结束
+func (h *RedisHandler) endCommand() {
+	h.inflight.Done()
+}
+
+// Shutdown 停止执行新命令，等待正在执行的命令完成（最多 timeout），
+// 然后停止主动过期、关闭 AOF，并在配置了快照路径时保存最后一次快照
+func (h *RedisHandler) Shutdown(timeout time.Duration) error {
+	h.lifecycleMu.Lock()
+	h.shuttingDown = true
+	h.lifecycleMu.Unlock()
+	// 不再应用主节点传播的命令
+	h.stopMasterLink()
+
+	var drainErr error
+	done := make(chan struct{})
+	go func() {
+		h.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		drainErr = fmt.Errorf("timed out after %v waiting for in-flight commands", timeout)
+		log.Printf("Shutdown: %v", drainErr)
+	}
+
+	h.StopActiveExpire()
+	h.stopCron()
+
+	if err := h.DisableAOF(); err != nil {
+		log.Printf("Shutdown: error closing AOF: %v", err)
+	}
+
+	h.mu.RLock()
+	snapshotPath := h.snapshot.path
+	h.mu.RUnlock()
+	if snapshotPath != "" {
+		path, err := h.beginSave()
+		if err == nil {
+			err = h.save(path)
+		}
+		if err != nil {
+			log.Printf("Shutdown: error saving snapshot: %v", err)
+		} else {
+			log.Printf("Shutdown: snapshot saved to %s", path)
+		}
+	}
+
+	return drainErr
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"fmt"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSlowlogLogSlowerThan 默认的慢查询阈值（微秒），与 Redis 一致
+	DefaultSlowlogLogSlowerThan = 10000
+	// DefaultSlowlogMaxLen 默认最多保留的慢查询条数
+	DefaultSlowlogMaxLen = 128
+
+	// 单条记录最多保存的参数个数和每个参数的最大长度，与 Redis 一致
+	slowlogMaxArgc   = 32
+	slowlogMaxArgLen = 128
+)
+
+// slowlogEntry 一条慢查询记录
+type slowlogEntry struct {
+	id       int64
+	time     time.Time
+	duration time.Duration
+	args     []string
+	addr     string
+	name     string
+}
+
+// slowlog 慢查询日志，最新的记录在前，超过 maxLen 时丢弃最旧的记录
+type slowlog struct {
+	mu            sync.Mutex
+	entries       []*slowlogEntry
+	nextID        int64
+	logSlowerThan int64 // 微秒，负数表示关闭，0 表示记录所有命令
+	maxLen        int
+}
+
+// newSlowlog 创建使用默认配置的慢查询日志
+func newSlowlog() *slowlog {
+	return &slowlog{
+		logSlowerThan: DefaultSlowlogLogSlowerThan,
+		maxLen:        DefaultSlowlogMaxLen,
+	}
+}
+
+// SetSlowlogLogSlowerThan 设置慢查询阈值（微秒），负数关闭慢查询日志，0 记录所有命令
+func (h *RedisHandler) SetSlowlogLogSlowerThan(micros int64) {
+	h.slowlog.mu.Lock()
+	defer h.slowlog.mu.Unlock()
+	h.slowlog.logSlowerThan = micros
+}
+
+// SetSlowlogMaxLen 设置最多保留的慢查询条数
+func (h *RedisHandler) SetSlowlogMaxLen(maxLen int) {
+	if maxLen < 0 {
+		maxLen = 0
+	}
+	h.slowlog.mu.Lock()
+	defer h.slowlog.mu.Unlock()
+	h.slowlog.maxLen = maxLen
+	if len(h.slowlog.entries) > maxLen {
+		h.slowlog.entries = h.slowlog.entries[:maxLen]
+	}
+}
+
+// record 命令执行时间超过阈值时追加一条记录
+func (l *slowlog) record(client *redisClient, command []string, duration time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.logSlowerThan < 0 || duration.Microseconds() < l.logSlowerThan || l.maxLen == 0 {
+		return
+	}
+
+	entry := &slowlogEntry{
+		id:       l.nextID,
+		time:     time.Now(),
+		duration: duration,
+		args:     slowlogArgs(command),
+		addr:     client.addr,
+		name:     client.getName(),
+	}
+	l.nextID++
+
+	l.entries = append([]*slowlogEntry{entry}, l.entries...)
+	if len(l.entries) > l.maxLen {
+		l.entries = l.entries[:l.maxLen]
+	}
+}
+
+// slowlogArgs 截断过多或过长的参数并隐藏密码
+func slowlogArgs(command []string) []string {
+	command = redactedArgs(command)
+	argc := len(command)
+	if argc > slowlogMaxArgc {
+		argc = slowlogMaxArgc
+	}
+
+	args := make([]string, argc)
+	for i := 0; i < argc; i++ {
+		if i == slowlogMaxArgc-1 && len(command) > slowlogMaxArgc {
+			args[i] = fmt.Sprintf("... (%d more arguments)", len(command)-slowlogMaxArgc+1)
+			break
+		}
+		arg := command[i]
+		if len(arg) > slowlogMaxArgLen {
+			arg = fmt.Sprintf("%s... (%d more bytes)", arg[:slowlogMaxArgLen], len(arg)-slowlogMaxArgLen)
+		}
+		args[i] = arg
+	}
+	return args
+}
+
+// redactedArgs 返回隐藏了密码等敏感参数的命令副本
+func redactedArgs(command []string) []string {
+	if len(command) == 0 {
+		return command
+	}
+	args := append([]string(nil), command...)
+	switch strings.ToUpper(args[0]) {
+	case "AUTH":
+		for i := 1; i < len(args); i++ {
+			args[i] = "(redacted)"
+		}
+	case "HELLO":
+		for i := 2; i < len(args); i++ {
+			if strings.ToUpper(args[i]) == "AUTH" && i+2 < len(args) {
+				args[i+1] = "(redacted)"
+				args[i+2] = "(redacted)"
+				i += 2
+			}
+		}
+	case "CONFIG":
+		// CONFIG SET 的 requirepass、masterauth 参数值
+		if len(args) > 3 && strings.ToUpper(args[1]) == "SET" {
+			for i := 2; i+1 < len(args); i += 2 {
+				switch strings.ToLower(args[i]) {
+				case "requirepass", "masterauth":
+					args[i+1] = "(redacted)"
+				}
+			}
+		}
+	case "ACL":
+		// ACL SETUSER 的 >密码、<密码、#摘要、!摘要 规则
+		if len(args) > 3 && strings.ToUpper(args[1]) == "SETUSER" {
+			for i := 3; i < len(args); i++ {
+				if args[i] != "" && strings.ContainsRune("><#!", rune(args[i][0])) {
+					args[i] = "(redacted)"
+				}
+			}
+		}
+	case "MIGRATE":
+		for i := 6; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "AUTH":
+				if i+1 < len(args) {
+					args[i+1] = "(redacted)"
+					i++
+				}
+			case "AUTH2":
+				if i+2 < len(args) {
+					args[i+1] = "(redacted)"
+					args[i+2] = "(redacted)"
+					i += 2
+				}
+			case "KEYS":
+				i = len(args)
+			}
+		}
+	}
+	return args
+}
+
+// slowlogSubcommands SLOWLOG HELP 列出的子命令
+var slowlogSubcommands = []redisSubcommand{
+	{Syntax: "GET [<count>]", Summary: "Return top <count> entries from the slowlog (default: 10, -1 mean all).\nEntries are made of:\n    id, timestamp, time in microseconds, arguments array, client IP and port,\n    client name"},
+	{Syntax: "LEN", Summary: "Return the length of the slowlog."},
+	{Syntax: "RESET", Summary: "Reset the slowlog."},
+}
+
+// handleSLOWLOG 处理 SLOWLOG 命令
+// SLOWLOG GET [count] | SLOWLOG LEN | SLOWLOG RESET
+func (h *RedisHandler) handleSLOWLOG(client *redisClient, command []string, writer *resp.RespWriter) error {
+	switch strings.ToUpper(command[1]) {
+	case "GET":
+		if len(command) > 3 {
+			return writer.WriteWrongNumberOfArgumentsError("SLOWLOG|GET")
+		}
+		count := 10
+		if len(command) == 3 {
+			n, err := strconv.Atoi(command[2])
+			if err != nil || n < -1 {
+				return resp.NewCommandError("count should be greater than or equal to -1")
+			}
+			count = n
+		}
+
+		h.slowlog.mu.Lock()
+		defer h.slowlog.mu.Unlock()
+		entries := h.slowlog.entries
+		if count >= 0 && count < len(entries) {
+			entries = entries[:count]
+		}
+		// 逐条写出，不为整个回复构建 []resp.Value
+		writer.WriteArrayHeader(len(entries))
+		for _, entry := range entries {
+			writer.WriteArrayHeader(6)
+			writer.WriteInteger(entry.id)
+			writer.WriteInteger(entry.time.Unix())
+			writer.WriteInteger(entry.duration.Microseconds())
+			writer.WriteArrayHeader(len(entry.args))
+			for _, arg := range entry.args {
+				writer.WriteBulkStringString(arg)
+			}
+			writer.WriteBulkStringString(entry.addr)
+			if err := writer.WriteBulkStringString(entry.name); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "LEN":
+		if len(command) != 2 {
+			return writer.WriteWrongNumberOfArgumentsError("SLOWLOG|LEN")
+		}
+		h.slowlog.mu.Lock()
+		n := len(h.slowlog.entries)
+		h.slowlog.mu.Unlock()
+		return writer.WriteInteger(int64(n))
+
+	case "RESET":
+		if len(command) != 2 {
+			return writer.WriteWrongNumberOfArgumentsError("SLOWLOG|RESET")
+		}
+		h.slowlog.mu.Lock()
+		h.slowlog.entries = nil
+		h.slowlog.mu.Unlock()
+		return writer.WriteOK()
+
+	default:
+		return resp.NewCommandError("unknown subcommand '%s'. Try SLOWLOG HELP.", command[1])
+	}
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"spine-go/libspine/common/resp"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// snapshotState 快照状态
+type snapshotState struct {
+	path       string       // 快照文件路径，为空表示未配置
+	lastSave   time.Time    // 最近一次成功保存的时间
+	inProgress bool         // 是否有保存正在进行
+	dirty      atomic.Int64 // 上次保存以来修改数据的命令数，用于 save 自动保存
+	savedDirty int64        // 正在进行的保存开始时的 dirty，保存成功后从 dirty 中扣除
+}
+
+// SetSnapshotPath 设置 SAVE/BGSAVE 写入的快照文件路径
+func (h *RedisHandler) SetSnapshotPath(path string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.snapshot.path = path
+}
+
+// LoadSnapshot 从快照文件恢复数据，文件不存在时不做任何事
+func (h *RedisHandler) LoadSnapshot() error {
+	h.mu.RLock()
+	path := h.snapshot.path
+	h.mu.RUnlock()
+	if path == "" {
+		return nil
+	}
+
+	loaded, err := h.replayFile(path)
+	if err != nil {
+		return err
+	}
+	if loaded > 0 {
+		log.Printf("Loaded %d keys from snapshot %s", loaded, path)
+	}
+	return nil
+}
+
+// LastSave 返回最近一次成功保存快照的时间
+func (h *RedisHandler) LastSave() time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.snapshot.lastSave
+}
+
+// snapshotCommands 将当前数据转换为可回放的 SET 命令，过期时间使用绝对时间
+func (h *RedisHandler) snapshotCommands() [][]string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.snapshotCommandsLocked()
+}
+
+// snapshotCommandsLocked 同 snapshotCommands，调用方必须持有锁
+func (h *RedisHandler) snapshotCommandsLocked() [][]string {
+	now := h.clock.Now()
+	commands := make([][]string, 0, len(h.store))
+	for key, item := range h.store {
+		if item.expired(now) {
+			continue
+		}
+		command := []string{"SET", key, item.Value}
+		if item.ExpiresAt != nil {
+			command = append(command, "PXAT", strconv.FormatInt(item.ExpiresAt.UnixMilli(), 10))
+		}
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// writeSnapshot 先写入临时文件再重命名，避免保存失败时破坏已有快照
+func writeSnapshot(path string, commands [][]string) error {
+	tmpPath := fmt.Sprintf("%s.tmp-%d", path, os.Getpid())
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	for _, command := range commands {
+		data, err := resp.SerializeCommand(command[0], command[1:]...)
+		if err == nil {
+			_, err = writer.Write(data)
+		}
+		if err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// beginSave 标记保存开始，已有保存进行中时返回错误
+func (h *RedisHandler) beginSave() (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.snapshot.path == "" {
+		return "", resp.NewCommandError("no snapshot path configured")
+	}
+	if h.snapshot.inProgress {
+		return "", resp.NewCommandError("Background save already in progress")
+	}
+	h.snapshot.inProgress = true
+	h.snapshot.savedDirty = h.snapshot.dirty.Load()
+	return h.snapshot.path, nil
+}
+
+// endSave 标记保存结束，只有成功时才更新 LASTSAVE
+func (h *RedisHandler) endSave(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.snapshot.inProgress = false
+	if err == nil {
+		h.snapshot.lastSave = time.Now()
+		h.snapshot.dirty.Add(-h.snapshot.savedDirty)
+	}
+}
+
+// save 同步保存快照
+func (h *RedisHandler) save(path string) error {
+	err := writeSnapshot(path, h.snapshotCommands())
+	h.endSave(err)
+	return err
+}
+
+// handleSAVE 处理 SAVE 命令
+func (h *RedisHandler) handleSAVE(client *redisClient, command []string, writer *resp.RespWriter) error {
+	path, err := h.beginSave()
+	if err != nil {
+		return err
+	}
+	if err := h.save(path); err != nil {
+		log.Printf("Error saving snapshot: %v", err)
+		return resp.NewCommandError("%s", err.Error())
+	}
+	return writer.WriteOK()
+}
+
+// bgsave 在后台保存快照，数据在调用时取出，写文件在后台进行
+func (h *RedisHandler) bgsave() error {
+	path, err := h.beginSave()
+	if err != nil {
+		return err
+	}
+
+	commands := h.snapshotCommands()
+	go func() {
+		err := writeSnapshot(path, commands)
+		if err != nil {
+			log.Printf("Background saving error: %v", err)
+		}
+		h.endSave(err)
+	}()
+	return nil
+}
+
+// handleBGSAVE 处理 BGSAVE 命令
+func (h *RedisHandler) handleBGSAVE(client *redisClient, command []string, writer *resp.RespWriter) error {
+	if err := h.bgsave(); err != nil {
+		return err
+	}
+	return writer.WriteSimpleString("Background saving started")
+}
+
+// handleLASTSAVE 处理 LASTSAVE 命令
+func (h *RedisHandler) handleLASTSAVE(client *redisClient, command []string, writer *resp.RespWriter) error {
+	return writer.WriteInteger(h.LastSave().Unix())
+}
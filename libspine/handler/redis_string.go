@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"spine-go/libspine/common/resp"
+	"strconv"
+)
+
+// maxStringLength 与 Redis 的 proto-max-bulk-len 默认值一致，字符串最大 512MB
+const maxStringLength = 512 * 1024 * 1024
+
+// 字符串命令都按字节处理，值可以包含任意字节，包括零字节和非 UTF-8 序列
+
+// setArguments SET 的参数说明
+var setArguments = []redisArg{
+	keyArg,
+	{Name: "value", Type: "string"},
+	{Name: "condition", Type: "oneof", Optional: true, Arguments: []redisArg{
+		{Name: "nx", Type: "pure-token", Token: "NX"},
+		{Name: "xx", Type: "pure-token", Token: "XX"},
+	}},
+	{Name: "expiration", Type: "oneof", Optional: true, Arguments: []redisArg{
+		{Name: "seconds", Type: "integer", Token: "EX"},
+		{Name: "milliseconds", Type: "integer", Token: "PX"},
+		{Name: "unix-time-seconds", Type: "unix-time", Token: "EXAT"},
+		{Name: "unix-time-milliseconds", Type: "unix-time", Token: "PXAT"},
+	}},
+}
+
+// rangeArguments GETRANGE 和 SUBSTR 的参数说明
+var rangeArguments = []redisArg{
+	keyArg,
+	{Name: "start", Type: "integer"},
+	{Name: "end", Type: "integer"},
+}
+
+// handleAPPEND 处理 APPEND key value，键不存在时创建，保留原有的过期时间，返回追加后的长度
+func (h *RedisHandler) handleAPPEND(client *redisClient, command []string, writer *resp.RespWriter) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	item := h.lookupLocked(command[1])
+	if item == nil {
+		item = &RedisItem{LastAccess: h.clock.Now()}
+		h.store[command[1]] = item
+	} else if len(item.Value)+len(command[2]) > maxStringLength {
+		return resp.NewCommandError("string exceeds maximum allowed size (proto-max-bulk-len)")
+	}
+	item.Value += command[2]
+	item.touch(h.clock.Now())
+	return writer.WriteInteger(int64(len(item.Value)))
+}
+
+// handleSTRLEN 处理 STRLEN key，返回值的字节数，键不存在时返回 0
+func (h *RedisHandler) handleSTRLEN(client *redisClient, command []string, writer *resp.RespWriter) error {
+	value, err := h.get(command[1])
+	if err != nil {
+		return writer.WriteInteger(0)
+	}
+	return writer.WriteInteger(int64(len(value)))
+}
+
+// handleGETRANGE 处理 GETRANGE key start end 和旧名称 SUBSTR，
+// start 和 end 是包含两端的字节下标，可以为负数，表示从末尾开始计数
+func (h *RedisHandler) handleGETRANGE(client *redisClient, command []string, writer *resp.RespWriter) error {
+	start, err1 := strconv.ParseInt(command[2], 10, 64)
+	end, err2 := strconv.ParseInt(command[3], 10, 64)
+	if err1 != nil || err2 != nil {
+		return resp.NewCommandError("value is not an integer or out of range")
+	}
+
+	value, err := h.get(command[1])
+	if err != nil {
+		return writer.WriteBulkStringString("")
+	}
+	return writer.WriteBulkStringString(byteRange(value, start, end))
+}
+
+// byteRange 按 GETRANGE 的规则截取 [start, end] 范围内的字节
+func byteRange(value string, start, end int64) string {
+	length := int64(len(value))
+	if start < 0 && end < 0 && start > end {
+		return ""
+	}
+	if start < 0 {
+		start += length
+	}
+	if end < 0 {
+		end += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 {
+		end = 0
+	}
+	if end >= length {
+		end = length - 1
+	}
+	if length == 0 || start > end {
+		return ""
+	}
+	return value[start : end+1]
+}
+
+// handleSETRANGE 处理 SETRANGE key offset value，从 offset 字节处覆盖写入，
+// 超出原长度的部分用零字节补齐，保留原有的过期时间，返回修改后的长度
+func (h *RedisHandler) handleSETRANGE(client *redisClient, command []string, writer *resp.RespWriter) error {
+	offset, err := strconv.ParseInt(command[2], 10, 64)
+	if err != nil {
+		return resp.NewCommandError("value is not an integer or out of range")
+	}
+	if offset < 0 {
+		return resp.NewCommandError("offset is out of range")
+	}
+	patch := command[3]
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	item := h.lookupLocked(command[1])
+	if len(patch) == 0 {
+		// 空值不修改也不创建键，只返回当前长度
+		if item == nil {
+			return writer.WriteInteger(0)
+		}
+		return writer.WriteInteger(int64(len(item.Value)))
+	}
+	if offset+int64(len(patch)) > maxStringLength {
+		return resp.NewCommandError("string exceeds maximum allowed size (proto-max-bulk-len)")
+	}
+	if item == nil {
+		item = &RedisItem{LastAccess: h.clock.Now()}
+		h.store[command[1]] = item
+	}
+
+	value := []byte(item.Value)
+	if end := offset + int64(len(patch)); end > int64(len(value)) {
+		value = append(value, make([]byte, end-int64(len(value)))...)
+	}
+	copy(value[offset:], patch)
+	item.Value = string(value)
+	item.touch(h.clock.Now())
+	return writer.WriteInteger(int64(len(item.Value)))
+}
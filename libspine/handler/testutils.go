@@ -36,6 +36,7 @@ func NewMockReaderFromRequests(requests []*transport.Request) *MockReader {
 		}
 		
 		chatRequest := map[string]interface{}{
+			"id":     req.ID,
 			"method": req.Method,
 			"path":   req.Path,
 			"data":   requestData,
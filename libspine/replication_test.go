@@ -0,0 +1,47 @@
+package libspine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicationBetweenServers(t *testing.T) {
+	ctx := context.Background()
+	primaryServer, primary := startRedisServer(t, &Config{})
+	defer primaryServer.Stop()
+	defer primary.Close()
+	replicaServer, replica := startRedisServer(t, &Config{})
+	defer replicaServer.Stop()
+	defer replica.Close()
+
+	_, err := primary.Do(ctx, "SET", "before", "1")
+	require.NoError(t, err)
+
+	tcp := primaryServer.config.ListenConfigs[0]
+	_, err = replica.Do(ctx, "REPLICAOF", tcp.Host, tcp.Port)
+	require.NoError(t, err)
+
+	get := func(key string) string {
+		reply, err := replica.Do(ctx, "GET", key)
+		require.NoError(t, err)
+		return string(reply.Bulk)
+	}
+	require.Eventually(t, func() bool { return get("before") == "1" }, 5*time.Second, 10*time.Millisecond)
+
+	_, err = primary.Do(ctx, "SET", "after", "2")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return get("after") == "2" }, 5*time.Second, 10*time.Millisecond)
+
+	// 副本只读
+	_, err = replica.Do(ctx, "SET", "key", "value")
+	assert.ErrorContains(t, err, "READONLY")
+
+	_, err = replica.Do(ctx, "REPLICAOF", "NO", "ONE")
+	require.NoError(t, err)
+	_, err = replica.Do(ctx, "SET", "key", "value")
+	assert.NoError(t, err)
+}
@@ -3,9 +3,12 @@ package libspine
 import (
 	"fmt"
 	"log"
+	"net"
+	"os"
 	"runtime"
 	"spine-go/libspine/handler"
 	"spine-go/libspine/transport"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +17,7 @@ import (
 // Server 服务器结构
 type Server struct {
 	transports []transport.Transport
+	handlers   map[string]transport.Handler // 服务器模式 -> 处理器，同一模式的监听地址共享处理器
 	config     *Config
 	serverCtx  *transport.ServerContext
 	mu         sync.RWMutex
@@ -22,17 +26,122 @@ type Server struct {
 
 // ListenConfig 监听配置
 type ListenConfig struct {
-	Schema string // "tcp", "local", "http"
+	Schema string // "tcp", "local", "http"（"ws" 为 "http" 的别名）
 	Host   string // 监听主机
 	Port   string // 监听端口
 	Path   string // 路径， http / local 可用
+	Mode   string // 该监听地址使用的服务器模式，"chat" 或 "redis"，为空时使用 Config.ServerMode
+}
+
+// ParseSocketPerm 解析八进制的 socket 文件权限，例如 770 或 0660
+func ParseSocketPerm(value string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || perm > 0777 {
+		return 0, fmt.Errorf("invalid socket permissions %q (expected octal such as 770)", value)
+	}
+	return os.FileMode(perm), nil
+}
+
+// ParseListenAddress 解析 schema://host:port 格式的监听地址，local schema 的地址部分为路径，
+// 例如 tcp://:8080、http://127.0.0.1:8000、local:///tmp/spine.sock，Linux 上 local://@spine 为抽象命名空间的 socket。
+// 地址后可以用 ?mode=chat 或 ?mode=redis 指定该监听地址的服务器模式，例如 tcp://:6379?mode=redis
+func ParseListenAddress(addr string) (ListenConfig, error) {
+	addr = strings.TrimSpace(addr)
+	mode := ""
+	if i := strings.LastIndex(addr, "?"); i >= 0 {
+		option, value, _ := strings.Cut(addr[i+1:], "=")
+		if option != "mode" || (value != "chat" && value != "redis") {
+			return ListenConfig{}, fmt.Errorf("invalid listen option in %s (expected ?mode=chat or ?mode=redis)", addr)
+		}
+		addr, mode = addr[:i], value
+	}
+
+	parts := strings.SplitN(addr, "://", 2)
+	if len(parts) != 2 {
+		return ListenConfig{}, fmt.Errorf("invalid listen address format: %s (expected schema://host:port)", addr)
+	}
+
+	schema, hostPort := parts[0], parts[1]
+	if schema == "local" {
+		return ListenConfig{Schema: schema, Path: hostPort, Mode: mode}, nil
+	}
+
+	// 对于 tcp 和 http，分割 host 和 port，IPv6 地址需要放在方括号中，如 [::1]:8080
+	host, port := "", hostPort
+	if strings.Contains(hostPort, ":") {
+		var err error
+		host, port, err = net.SplitHostPort(hostPort)
+		if err != nil {
+			return ListenConfig{}, fmt.Errorf("invalid listen address %s: %v (IPv6 hosts must be bracketed, e.g. tcp://[::1]:8080)", addr, err)
+		}
+	}
+	return ListenConfig{Schema: schema, Host: host, Port: port, Mode: mode}, nil
 }
 
 // Config 服务器配置
 type Config struct {
 	ListenConfigs []ListenConfig // 监听配置数组
-	ServerMode    string         // "chat" 或 "redis"
+	ServerMode    string         // "chat" 或 "redis"，没有指定模式的监听地址使用该模式
 	StaticPath    string         // 静态文件路径，用于 chat webui
+	// EnableDebugCommand 是否允许 redis 模式下的 DEBUG 命令，仅用于测试环境
+	EnableDebugCommand bool
+	// AOFPath redis 模式下的 AOF 文件路径，为空表示不启用持久化
+	AOFPath string
+	// AOFFsync AOF 刷盘策略：always / everysec / no，默认 everysec
+	AOFFsync string
+	// SnapshotPath redis 模式下 SAVE/BGSAVE 写入的快照文件路径，启动时从该文件恢复数据
+	SnapshotPath string
+	// RequirePass redis 模式下的访问密码，为空表示不需要认证
+	RequirePass string
+	// Timeout 空闲连接超时，连接在该时间内没有收到数据则关闭，0 表示不超时。
+	// redis 模式下是 timeout 参数的初始值，可以通过 CONFIG SET timeout 修改
+	Timeout time.Duration
+	// ShutdownTimeout 关闭时等待正在执行的命令完成的最长时间，0 表示使用默认值
+	ShutdownTimeout time.Duration
+	// ChatHistoryLimit chat 模式下保留的历史消息条数，0 表示使用默认值
+	ChatHistoryLimit int
+	// EnableMetrics 是否在 http 监听上提供 /metrics（Prometheus 格式）
+	EnableMetrics bool
+	// SlowlogLogSlowerThan redis 模式下的慢查询阈值（微秒），0 表示使用默认值，负数表示关闭
+	SlowlogLogSlowerThan int64
+	// SlowlogMaxLen redis 模式下最多保留的慢查询条数，0 表示使用默认值
+	SlowlogMaxLen int
+	// ActiveExpireInterval redis 模式下主动删除过期键的扫描间隔，0 表示使用默认值，负数表示关闭
+	ActiveExpireInterval time.Duration
+	// ActiveExpireSamples 每轮扫描最多检查的带过期时间的键数，0 表示使用默认值
+	ActiveExpireSamples int
+	// MaxMemory redis 模式下的 maxmemory，格式与 CONFIG SET 相同（如 100mb），为空表示使用默认值
+	MaxMemory string
+	// MaxMemoryPolicy redis 模式下的 maxmemory-policy，为空表示使用默认值
+	MaxMemoryPolicy string
+	// ProtoMaxBulkLen redis 模式下请求中批量字符串的最大长度，格式与 CONFIG SET 相同（如 512mb），为空表示使用默认值
+	ProtoMaxBulkLen string
+	// ProtoMaxMultiBulkLen redis 模式下请求数组的最大元素个数，为空表示使用默认值
+	ProtoMaxMultiBulkLen string
+	// Save redis 模式下的自动保存条件，格式与 CONFIG SET save 相同，为空表示不自动保存
+	Save string
+	// MasterAuth redis 模式下作为副本时连接主节点使用的密码
+	MasterAuth string
+	// ReplicaReadOnly redis 模式下作为副本时是否拒绝客户端的写命令（yes/no），为空表示使用默认值 yes
+	ReplicaReadOnly string
+	// ReusePort tcp 监听地址是否设置 SO_REUSEPORT，允许多个进程监听同一端口（Windows 上不支持）
+	ReusePort bool
+	// ProxyProtocol tcp 监听地址是否先读取负载均衡器发送的 PROXY v1/v2 协议头，以其中的地址作为客户端地址
+	ProxyProtocol bool
+	// UnixSocketPerm local 监听地址在 Unix 上创建的 socket 文件权限，0 表示保留默认权限（受 umask 影响）
+	UnixSocketPerm os.FileMode
+	// UnixSocketGroup socket 文件的属组，可以是组名或 gid，为空表示不修改
+	UnixSocketGroup string
+	// ConfigFile 启动时读取的配置文件，CONFIG REWRITE 写回该文件，为空表示没有配置文件
+	ConfigFile string
+}
+
+// defaultShutdownTimeout 默认的优雅关闭等待时间
+const defaultShutdownTimeout = 10 * time.Second
+
+// gracefulHandler 支持优雅关闭的处理器
+type gracefulHandler interface {
+	Shutdown(timeout time.Duration) error
 }
 
 // isWindows 检测当前操作系统是否为 Windows
@@ -77,7 +186,9 @@ func NewServer(config *Config) *Server {
 // Start 启动服务器
 func (s *Server) Start() error {
 	// 注册处理器
-	s.registerHandlers()
+	if err := s.registerHandlers(); err != nil {
+		return err
+	}
 
 	// 启动各种传输层
 	var errs []error
@@ -87,7 +198,11 @@ func (s *Server) Start() error {
 		wg.Add(1)
 		go func(config ListenConfig) {
 			defer wg.Done()
-			if err := s.startTransport(config, s.config.ServerMode, s.config.StaticPath); err != nil {
+			mode := config.Mode
+			if mode == "" {
+				mode = s.config.ServerMode
+			}
+			if err := s.startTransport(config, mode, s.config.StaticPath); err != nil {
 				errs = append(errs, fmt.Errorf("%s error: %v", config.Schema, err))
 			}
 		}(listenConfig)
@@ -102,26 +217,44 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// startTransport 根据配置启动传输层
-func (s *Server) startTransport(config ListenConfig, _ string, staticPath string) error {
+// transportIdleTimeout 返回传输层的空闲超时。redis 模式由处理器按 timeout 参数的当前值关闭空闲连接，
+// 传输层不再设置读取超时，否则 MONITOR 连接会被断开，CONFIG SET timeout 0 也无法生效
+func (s *Server) transportIdleTimeout(mode string) time.Duration {
+	if mode == "redis" {
+		return 0
+	}
+	return s.config.Timeout
+}
+
+// startTransport 根据配置启动传输层，连接交给 mode 对应的处理器
+func (s *Server) startTransport(config ListenConfig, mode string, staticPath string) error {
 	var transportInstance transport.Transport
-	var err error
 	var address string
 
+	serverCtx := s.serverCtx
+	if mode != s.config.ServerMode {
+		serverCtx = s.serverCtx.WithHandler(s.handlers[mode])
+	}
+
 	switch config.Schema {
 	case "tcp":
-		address = config.Host + ":" + config.Port
-		transportInstance, err = transport.NewTCPTransport(address)
+		address = net.JoinHostPort(config.Host, config.Port)
+		tcpTransport, err := transport.NewTCPTransportWithOptions(address, transport.TCPOptions{
+			ReusePort:     s.config.ReusePort,
+			ProxyProtocol: s.config.ProxyProtocol,
+		})
 		if err != nil {
 			return err
 		}
+		tcpTransport.SetIdleTimeout(s.transportIdleTimeout(mode))
+		transportInstance = tcpTransport
 
 		s.mu.Lock()
 		s.transports = append(s.transports, transportInstance)
 		s.mu.Unlock()
 
-		log.Printf("TCP transport starting on %s", address)
-		return transportInstance.Start(s.serverCtx)
+		log.Printf("TCP transport starting on %s (%s)", address, mode)
+		return transportInstance.Start(serverCtx)
 
 	case "local":
 		// 根据平台转换路径
@@ -129,27 +262,34 @@ func (s *Server) startTransport(config ListenConfig, _ string, staticPath string
 		
 		// 根据平台选择传输层
 		if isWindows() {
-			transportInstance, err = transport.NewNamedPipeTransport(address)
+			pipeTransport, err := transport.NewNamedPipeTransport(address)
 			if err != nil {
 				return err
 			}
-			log.Printf("Named pipe transport starting on %s", address)
+			pipeTransport.SetIdleTimeout(s.transportIdleTimeout(mode))
+			transportInstance = pipeTransport
+			log.Printf("Named pipe transport starting on %s (%s)", address, mode)
 		} else {
-			transportInstance, err = transport.NewUnixSocketTransport(address)
+			unixTransport, err := transport.NewUnixSocketTransport(address)
 			if err != nil {
 				return err
 			}
-			log.Printf("Unix socket transport starting on %s", address)
+			unixTransport.SetIdleTimeout(s.transportIdleTimeout(mode))
+			if err := unixTransport.SetPermissions(s.config.UnixSocketPerm, s.config.UnixSocketGroup); err != nil {
+				return fmt.Errorf("setting permissions of %s: %v", address, err)
+			}
+			transportInstance = unixTransport
+			log.Printf("Unix socket transport starting on %s (%s)", address, mode)
 		}
 
 		s.mu.Lock()
 		s.transports = append(s.transports, transportInstance)
 		s.mu.Unlock()
 
-		return transportInstance.Start(s.serverCtx)
+		return transportInstance.Start(serverCtx)
 
-	case "http":
-		address := config.Host + ":" + config.Port
+	case "http", "ws":
+		address := net.JoinHostPort(config.Host, config.Port)
 		if config.Path != "" {
 			address += "/" + config.Path
 		}
@@ -163,12 +303,15 @@ func (s *Server) startTransport(config ListenConfig, _ string, staticPath string
 		if staticPath != "" {
 			s.serverCtx.ServerInfo.Config["static_path"] = staticPath
 		}
+		if s.config.EnableMetrics {
+			s.serverCtx.ServerInfo.Config["metrics"] = true
+		}
 
-		log.Printf("WebSocket transport starting on %s", address)
+		log.Printf("WebSocket transport starting on %s (%s)", address, mode)
 		if staticPath != "" {
 			log.Printf("WebSocket static files path: %s", staticPath)
 		}
-		return transportInstance.Start(s.serverCtx)
+		return transportInstance.Start(serverCtx)
 
 	default:
 		return fmt.Errorf("unsupported schema: %s", config.Schema)
@@ -180,7 +323,29 @@ func (s *Server) Stop() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	// 首先主动关闭所有客户端连接
+	// 先停止接受新连接
+	for i, t := range s.transports {
+		if stopper, ok := t.(transport.AcceptStopper); ok {
+			if err := stopper.StopAccepting(); err != nil {
+				log.Printf("Error closing listener of transport %d: %v", i, err)
+			}
+		}
+	}
+
+	// 等待正在执行的命令完成，并在关闭连接前完成最后的持久化
+	for _, h := range s.handlers {
+		if h, ok := h.(gracefulHandler); ok {
+			timeout := s.config.ShutdownTimeout
+			if timeout <= 0 {
+				timeout = defaultShutdownTimeout
+			}
+			if err := h.Shutdown(timeout); err != nil {
+				log.Printf("Error draining handler: %v", err)
+			}
+		}
+	}
+
+	// 然后主动关闭所有客户端连接
 	if s.serverCtx != nil && s.serverCtx.Connections != nil {
 		log.Printf("Closing all active connections before server shutdown")
 		if err := s.serverCtx.Connections.CloseAllConnections(); err != nil {
@@ -223,7 +388,7 @@ func (s *Server) GetConnections() []*transport.ConnInfo {
 }
 
 // registerHandlers 注册处理器
-func (s *Server) registerHandlers() {
+func (s *Server) registerHandlers() error {
 	// var mainHandler handler.Handler
 	/*
 		// 根据服务器模式选择处理器
@@ -257,17 +422,97 @@ func (s *Server) registerHandlers() {
 			}
 		}
 	*/
-	if s.config.ServerMode == "chat" {
+	// 默认模式和监听地址单独指定的模式各创建一个处理器，同一模式的监听地址共享数据
+	modes := []string{s.config.ServerMode}
+	for _, listenConfig := range s.config.ListenConfigs {
+		if listenConfig.Mode != "" {
+			modes = append(modes, listenConfig.Mode)
+		}
+	}
+	s.handlers = make(map[string]transport.Handler)
+	for _, mode := range modes {
+		if _, exists := s.handlers[mode]; exists {
+			continue
+		}
+		h, err := s.newHandler(mode)
+		if err != nil {
+			return err
+		}
+		s.handlers[mode] = h
+		log.Printf("Registered handler for server mode: %s", mode)
+	}
+	// 服务器上下文的处理器用于默认模式的监听地址
+	s.serverCtx.SetHandler(s.handlers[s.config.ServerMode])
+	return nil
+}
+
+// newHandler 按服务器模式创建处理器，未知模式返回 nil
+func (s *Server) newHandler(mode string) (transport.Handler, error) {
+	switch mode {
+	case "chat":
 		chatHandler := handler.NewChatHandler()
 		if s.config.StaticPath != "" {
 			chatHandler.SetStaticPath(s.config.StaticPath)
 		}
-		// 直接设置处理器到服务器上下文
-		s.serverCtx.SetHandler(chatHandler)
-	} else if s.config.ServerMode == "redis" {
-		redisHandler := handler.NewRedisHandler()
-		s.serverCtx.SetHandler(redisHandler)
+		chatHandler.SetHistoryLimit(s.config.ChatHistoryLimit)
+		return chatHandler, nil
+	case "redis":
+		return s.newRedisHandler()
 	}
+	return nil, nil
+}
 
-	log.Printf("Registered handler for server mode: %s", s.config.ServerMode)
+// newRedisHandler 按配置创建 redis 模式的处理器，加载快照或 AOF 并启动后台任务
+func (s *Server) newRedisHandler() (*handler.RedisHandler, error) {
+	redisHandler := handler.NewRedisHandler()
+	redisHandler.SetDebugCommandEnabled(s.config.EnableDebugCommand)
+	redisHandler.SetRequirePass(s.config.RequirePass)
+	if s.config.SlowlogLogSlowerThan != 0 {
+		redisHandler.SetSlowlogLogSlowerThan(s.config.SlowlogLogSlowerThan)
+	}
+	if s.config.SlowlogMaxLen > 0 {
+		redisHandler.SetSlowlogMaxLen(s.config.SlowlogMaxLen)
+	}
+	for _, param := range []struct{ name, value string }{
+		{"appendfsync", s.config.AOFFsync},
+		{"masterauth", s.config.MasterAuth},
+		{"maxmemory", s.config.MaxMemory},
+		{"maxmemory-policy", s.config.MaxMemoryPolicy},
+		{"proto-max-bulk-len", s.config.ProtoMaxBulkLen},
+		{"proto-max-multibulk-len", s.config.ProtoMaxMultiBulkLen},
+		{"replica-read-only", s.config.ReplicaReadOnly},
+		{"save", s.config.Save},
+	} {
+		if param.value == "" {
+			continue
+		}
+		if err := redisHandler.SetConfig(param.name, param.value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", param.name, err)
+		}
+	}
+	redisHandler.SetClientTimeout(s.config.Timeout)
+	redisHandler.SetConfigFile(s.config.ConfigFile)
+	if s.config.SnapshotPath != "" {
+		redisHandler.SetSnapshotPath(s.config.SnapshotPath)
+		// 启用 AOF 时以 AOF 为准，与 Redis 的加载顺序一致
+		if s.config.AOFPath == "" {
+			if err := redisHandler.LoadSnapshot(); err != nil {
+				return nil, fmt.Errorf("failed to load snapshot: %v", err)
+			}
+		}
+	}
+	if s.config.AOFPath != "" {
+		fsync := s.config.AOFFsync
+		if fsync == "" {
+			fsync = handler.AOFFsyncEverySec
+		}
+		if err := redisHandler.EnableAOF(s.config.AOFPath, fsync); err != nil {
+			return nil, fmt.Errorf("failed to enable AOF: %v", err)
+		}
+		log.Printf("AOF enabled: %s (appendfsync %s)", s.config.AOFPath, fsync)
+	}
+	if s.config.ActiveExpireInterval >= 0 {
+		redisHandler.StartActiveExpire(s.config.ActiveExpireInterval, s.config.ActiveExpireSamples)
+	}
+	return redisHandler, nil
 }
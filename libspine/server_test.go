@@ -0,0 +1,483 @@
+package libspine
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"spine-go/libspine/common/resp"
+)
+
+// freePort 获取一个当前可用的本地端口
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return port
+}
+
+func TestStopWaitsForInFlightCommands(t *testing.T) {
+	port := freePort(t)
+	address := "127.0.0.1:" + port
+	aofPath := filepath.Join(t.TempDir(), "appendonly.aof")
+
+	server := NewServer(&Config{
+		ListenConfigs:      []ListenConfig{{Schema: "tcp", Host: "127.0.0.1", Port: port}},
+		ServerMode:         "redis",
+		EnableDebugCommand: true,
+		AOFPath:            aofPath,
+		ShutdownTimeout:    5 * time.Second,
+	})
+	require.NoError(t, server.Start())
+
+	conn, err := net.Dial("tcp", address)
+	require.NoError(t, err)
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	// everysec 策略下这条命令只在缓冲区中，依赖关闭时的最后一次刷盘
+	_, err = conn.Write([]byte("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nvalue\r\n"))
+	require.NoError(t, err)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "+OK\r\n", line)
+
+	_, err = conn.Write([]byte("*3\r\n$5\r\nDEBUG\r\n$5\r\nSLEEP\r\n$3\r\n0.5\r\n"))
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Stop()
+	}()
+
+	// 慢命令在关闭过程中仍然完成并返回结果
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "+OK\r\n", line)
+
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+
+	// 监听器已关闭
+	_, err = net.DialTimeout("tcp", address, time.Second)
+	assert.Error(t, err)
+
+	data, err := os.ReadFile(aofPath)
+	require.NoError(t, err)
+	assert.True(t, strings.Contains(string(data), "value"), "AOF should be flushed on shutdown")
+}
+
+func TestIdleConnectionTimeout(t *testing.T) {
+	port := freePort(t)
+	socketPath := filepath.Join(t.TempDir(), "spine.sock")
+
+	server := NewServer(&Config{
+		ListenConfigs: []ListenConfig{
+			{Schema: "tcp", Host: "127.0.0.1", Port: port},
+			{Schema: "local", Path: socketPath},
+		},
+		ServerMode: "redis",
+		Timeout:    300 * time.Millisecond,
+	})
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	for _, target := range []struct{ network, address string }{
+		{"tcp", "127.0.0.1:" + port},
+		{"unix", socketPath},
+	} {
+		t.Run(target.network, func(t *testing.T) {
+			// 持续有数据的连接不会被关闭
+			active, err := net.Dial(target.network, target.address)
+			require.NoError(t, err)
+			defer active.Close()
+			reader := bufio.NewReader(active)
+			for i := 0; i < 4; i++ {
+				_, err = active.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+				require.NoError(t, err)
+				line, err := reader.ReadString('\n')
+				require.NoError(t, err)
+				require.Equal(t, "+PONG\r\n", line)
+				time.Sleep(150 * time.Millisecond)
+			}
+
+			// 没有任何数据的连接在超时后被关闭
+			idle, err := net.Dial(target.network, target.address)
+			require.NoError(t, err)
+			defer idle.Close()
+
+			start := time.Now()
+			idle.SetReadDeadline(time.Now().Add(3 * time.Second))
+			_, err = idle.Read(make([]byte, 1))
+			assert.Error(t, err)
+			var netErr net.Error
+			if errors.As(err, &netErr) {
+				assert.False(t, netErr.Timeout(), "connection should be closed by the server, not time out on the client")
+			}
+			assert.Less(t, time.Since(start), 2*time.Second)
+		})
+	}
+}
+
+func TestIdleTimeoutFollowsRedisConfig(t *testing.T) {
+	port := freePort(t)
+	address := "127.0.0.1:" + port
+	server := NewServer(&Config{
+		ListenConfigs: []ListenConfig{{Schema: "tcp", Host: "127.0.0.1", Port: port}},
+		ServerMode:    "redis",
+		Timeout:       300 * time.Millisecond,
+	})
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	dial := func() (net.Conn, *bufio.Reader) {
+		conn, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		return conn, bufio.NewReader(conn)
+	}
+	send := func(conn net.Conn, reader *bufio.Reader, command string) string {
+		_, err := conn.Write([]byte(command))
+		require.NoError(t, err)
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		return line
+	}
+
+	// MONITOR 连接空闲超过 timeout 也不会被关闭
+	monitor, monitorReader := dial()
+	require.Equal(t, "+OK\r\n", send(monitor, monitorReader, "*1\r\n$7\r\nMONITOR\r\n"))
+	time.Sleep(time.Second)
+
+	admin, adminReader := dial()
+	require.Equal(t, "+OK\r\n", send(admin, adminReader, "*4\r\n$6\r\nCONFIG\r\n$3\r\nSET\r\n$7\r\ntimeout\r\n$1\r\n0\r\n"))
+	line, err := monitorReader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, `"CONFIG" "SET" "timeout" "0"`)
+
+	// timeout 改为 0 之后空闲连接不再被关闭
+	time.Sleep(time.Second)
+	assert.Equal(t, "+PONG\r\n", send(admin, adminReader, "*1\r\n$4\r\nPING\r\n"))
+}
+
+func TestRedisOverWebSocket(t *testing.T) {
+	port := freePort(t)
+	server := NewServer(&Config{
+		ListenConfigs: []ListenConfig{{Schema: "http", Host: "127.0.0.1", Port: port}},
+		ServerMode:    "redis",
+	})
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	// WebSocket 服务在后台启动，等待其可以连接
+	var conn *websocket.Conn
+	require.Eventually(t, func() bool {
+		var err error
+		conn, _, err = websocket.DefaultDialer.Dial("ws://127.0.0.1:"+port+"/ws", nil)
+		return err == nil
+	}, 3*time.Second, 20*time.Millisecond)
+	defer conn.Close()
+
+	roundTrip := func(command ...string) resp.Value {
+		data, err := resp.SerializeCommand(command[0], command[1:]...)
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, data))
+
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		messageType, reply, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, websocket.BinaryMessage, messageType)
+		value, err := resp.ParseFromBytes(reply)
+		require.NoError(t, err)
+		return value
+	}
+
+	assert.Equal(t, "OK", roundTrip("SET", "key", "value").String)
+	assert.Equal(t, "value", string(roundTrip("GET", "key").Bulk))
+
+	// 一条 RESP 命令可以跨多个 WebSocket 消息
+	data, err := resp.SerializeCommand("GET", "key")
+	require.NoError(t, err)
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, data[:5]))
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, data[5:]))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, reply, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "$5\r\nvalue\r\n", string(reply))
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	tcpPort, httpPort := freePort(t), freePort(t)
+	server := NewServer(&Config{
+		ListenConfigs: []ListenConfig{
+			{Schema: "tcp", Host: "127.0.0.1", Port: tcpPort},
+			{Schema: "http", Host: "127.0.0.1", Port: httpPort},
+		},
+		ServerMode:    "redis",
+		EnableMetrics: true,
+	})
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", "127.0.0.1:"+tcpPort)
+	require.NoError(t, err)
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for _, command := range [][]string{{"SET", "key", "value"}, {"GET", "key"}, {"GET", "missing"}} {
+		data, err := resp.SerializeCommand(command[0], command[1:]...)
+		require.NoError(t, err)
+		_, err = conn.Write(data)
+		require.NoError(t, err)
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, err = resp.NewParser(reader).Parse()
+		require.NoError(t, err)
+	}
+
+	var body string
+	require.Eventually(t, func() bool {
+		response, err := http.Get("http://127.0.0.1:" + httpPort + "/metrics")
+		if err != nil {
+			return false
+		}
+		defer response.Body.Close()
+		data, _ := io.ReadAll(response.Body)
+		body = string(data)
+		return response.StatusCode == http.StatusOK
+	}, 3*time.Second, 20*time.Millisecond)
+
+	assert.Contains(t, body, "spine_keyspace_hits_total 1\n")
+	assert.Contains(t, body, "spine_keyspace_misses_total 1\n")
+	assert.Contains(t, body, "spine_connected_clients 1\n")
+}
+
+func TestParseListenAddressMode(t *testing.T) {
+	config, err := ParseListenAddress("tcp://:6379?mode=redis")
+	require.NoError(t, err)
+	assert.Equal(t, ListenConfig{Schema: "tcp", Port: "6379", Mode: "redis"}, config)
+
+	config, err = ParseListenAddress("local:///tmp/spine.sock?mode=chat")
+	require.NoError(t, err)
+	assert.Equal(t, ListenConfig{Schema: "local", Path: "/tmp/spine.sock", Mode: "chat"}, config)
+
+	config, err = ParseListenAddress("http://127.0.0.1:8000")
+	require.NoError(t, err)
+	assert.Equal(t, ListenConfig{Schema: "http", Host: "127.0.0.1", Port: "8000"}, config)
+
+	for _, addr := range []string{"tcp://:6379?mode=memcached", "tcp://:6379?protocol=redis"} {
+		_, err := ParseListenAddress(addr)
+		assert.Error(t, err, addr)
+	}
+}
+
+func TestParseListenAddressIPv6(t *testing.T) {
+	tests := []struct {
+		addr   string
+		config ListenConfig
+	}{
+		{"tcp://[::1]:8080", ListenConfig{Schema: "tcp", Host: "::1", Port: "8080"}},
+		{"tcp://[2001:db8::1]:6379", ListenConfig{Schema: "tcp", Host: "2001:db8::1", Port: "6379"}},
+		{"http://[::]:8000?mode=chat", ListenConfig{Schema: "http", Host: "::", Port: "8000", Mode: "chat"}},
+		{"tcp://[fe80::1%eth0]:6379", ListenConfig{Schema: "tcp", Host: "fe80::1%eth0", Port: "6379"}},
+		{"tcp://localhost:6379", ListenConfig{Schema: "tcp", Host: "localhost", Port: "6379"}},
+		{"tcp://6379", ListenConfig{Schema: "tcp", Port: "6379"}},
+	}
+	for _, test := range tests {
+		config, err := ParseListenAddress(test.addr)
+		require.NoError(t, err, test.addr)
+		assert.Equal(t, test.config, config, test.addr)
+	}
+
+	for _, addr := range []string{"tcp://::1:8080", "tcp://[::1:8080", "tcp://[::1]8080"} {
+		_, err := ParseListenAddress(addr)
+		assert.Error(t, err, addr)
+	}
+}
+
+func TestListenOnIPv6(t *testing.T) {
+	probe, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback not available: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(probe.Addr().String())
+	probe.Close()
+
+	listenConfig, err := ParseListenAddress("tcp://[::1]:" + port)
+	require.NoError(t, err)
+	server := NewServer(&Config{
+		ListenConfigs:   []ListenConfig{listenConfig},
+		ServerMode:      "redis",
+		ShutdownTimeout: time.Second,
+	})
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", "[::1]:"+port)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	require.NoError(t, err)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "+PONG\r\n", line)
+}
+
+func TestMixedModeListeners(t *testing.T) {
+	redisPort, chatPort, httpPort := freePort(t), freePort(t), freePort(t)
+	var listens []ListenConfig
+	for _, addr := range []string{
+		"tcp://127.0.0.1:" + redisPort + "?mode=redis",
+		"tcp://127.0.0.1:" + chatPort,
+		"ws://127.0.0.1:" + httpPort + "?mode=chat",
+	} {
+		listen, err := ParseListenAddress(addr)
+		require.NoError(t, err)
+		listens = append(listens, listen)
+	}
+	server := NewServer(&Config{ListenConfigs: listens, ServerMode: "chat"})
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	// redis 监听地址使用 RESP
+	redisConn, err := net.Dial("tcp", "127.0.0.1:"+redisPort)
+	require.NoError(t, err)
+	defer redisConn.Close()
+	redisConn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, err = redisConn.Write([]byte("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nvalue\r\n*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n"))
+	require.NoError(t, err)
+	parser := resp.NewParser(bufio.NewReader(redisConn))
+	reply, err := parser.Parse()
+	require.NoError(t, err)
+	assert.Equal(t, "OK", reply.String)
+	reply, err = parser.Parse()
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(reply.Bulk))
+
+	// 没有指定模式的 tcp 监听地址使用默认的 chat 模式
+	chatConn, err := net.Dial("tcp", "127.0.0.1:"+chatPort)
+	require.NoError(t, err)
+	defer chatConn.Close()
+	chatConn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, err = chatConn.Write([]byte(`{"id":"1","method":"PING","path":"/chat"}` + "\n"))
+	require.NoError(t, err)
+	line, err := bufio.NewReader(chatConn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, "pong")
+
+	// ws 监听地址提供 chat 的 WebSocket 和 HTTP 接口
+	var wsConn *websocket.Conn
+	require.Eventually(t, func() bool {
+		wsConn, _, err = websocket.DefaultDialer.Dial("ws://127.0.0.1:"+httpPort+"/ws", nil)
+		return err == nil
+	}, 3*time.Second, 20*time.Millisecond)
+	defer wsConn.Close()
+	require.NoError(t, wsConn.WriteMessage(websocket.TextMessage, []byte(`{"id":"2","method":"PING","path":"/chat"}`)))
+	wsConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, message, err := wsConn.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(message), "pong")
+
+	response, err := http.Get("http://127.0.0.1:" + httpPort + "/health")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestRestartOnSamePort(t *testing.T) {
+	port := freePort(t)
+	config := &Config{
+		ListenConfigs:   []ListenConfig{{Schema: "tcp", Host: "127.0.0.1", Port: port}},
+		ServerMode:      "redis",
+		ShutdownTimeout: time.Second,
+	}
+
+	for i := 0; i < 2; i++ {
+		server := NewServer(config)
+		require.NoError(t, server.Start(), "start %d", i)
+
+		// 服务器主动关闭已建立的连接，端口上会留下 TIME_WAIT 状态的连接
+		conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+		require.NoError(t, err)
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		_, err = conn.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+		require.NoError(t, err)
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "+PONG\r\n", line)
+
+		require.NoError(t, server.Stop())
+		conn.Close()
+	}
+}
+
+func TestProxyProtocol(t *testing.T) {
+	port := freePort(t)
+	server := NewServer(&Config{
+		ListenConfigs:   []ListenConfig{{Schema: "tcp", Host: "127.0.0.1", Port: port}},
+		ServerMode:      "redis",
+		ProxyProtocol:   true,
+		ShutdownTimeout: time.Second,
+	})
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	// clientAddr 发送 header 后执行 CLIENT INFO，返回服务器看到的客户端地址
+	clientAddr := func(header []byte) string {
+		conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+		require.NoError(t, err)
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		command, err := resp.SerializeCommand("CLIENT", "INFO")
+		require.NoError(t, err)
+		_, err = conn.Write(append(header, command...))
+		require.NoError(t, err)
+		reply, err := resp.NewParser(bufio.NewReader(conn)).Parse()
+		require.NoError(t, err)
+		for _, field := range strings.Fields(string(reply.Bulk)) {
+			if strings.HasPrefix(field, "addr=") {
+				return strings.TrimPrefix(field, "addr=")
+			}
+		}
+		t.Fatalf("no addr in CLIENT INFO reply: %q", reply.Bulk)
+		return ""
+	}
+
+	assert.Equal(t, "203.0.113.7:51234", clientAddr([]byte("PROXY TCP4 203.0.113.7 10.0.0.1 51234 6379\r\n")))
+	assert.Equal(t, "[2001:db8::7]:40000", clientAddr([]byte("PROXY TCP6 2001:db8::7 2001:db8::1 40000 6379\r\n")))
+	assert.True(t, strings.HasPrefix(clientAddr([]byte("PROXY UNKNOWN\r\n")), "127.0.0.1:"))
+
+	v2 := append([]byte("\r\n\r\n\x00\r\nQUIT\n"), 0x21, 0x11, 0, 12)
+	v2 = append(v2, 198, 51, 100, 9, 10, 0, 0, 1, 0x30, 0x39, 0x18, 0xeb)
+	assert.Equal(t, "198.51.100.9:12345", clientAddr(v2))
+
+	// 没有 PROXY 协议头的连接被关闭
+	conn, err := net.Dial("tcp", "127.0.0.1:"+port)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	require.NoError(t, err)
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+//go:build !windows
+
+package libspine
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnixSocketPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spine.sock")
+	server := NewServer(&Config{
+		ListenConfigs:   []ListenConfig{{Schema: "local", Path: path}},
+		ServerMode:      "redis",
+		UnixSocketPerm:  0660,
+		UnixSocketGroup: strconv.Itoa(os.Getgid()),
+		ShutdownTimeout: time.Second,
+	})
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSocket, info.Mode().Type())
+	assert.Equal(t, os.FileMode(0660), info.Mode().Perm())
+	assert.Equal(t, uint32(os.Getgid()), info.Sys().(*syscall.Stat_t).Gid)
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestUnixSocketUnknownGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spine.sock")
+	server := NewServer(&Config{
+		ListenConfigs:   []ListenConfig{{Schema: "local", Path: path}},
+		ServerMode:      "redis",
+		UnixSocketGroup: "no-such-group-for-spine",
+	})
+	assert.Error(t, server.Start())
+	defer server.Stop()
+
+	// 设置失败时不留下可连接的 socket
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestReusePort(t *testing.T) {
+	port := freePort(t)
+	config := &Config{
+		ListenConfigs:   []ListenConfig{{Schema: "tcp", Host: "127.0.0.1", Port: port}},
+		ServerMode:      "redis",
+		ReusePort:       true,
+		ShutdownTimeout: time.Second,
+	}
+
+	// 两个服务器可以同时监听同一端口
+	first := NewServer(config)
+	require.NoError(t, first.Start())
+	defer first.Stop()
+	second := NewServer(config)
+	require.NoError(t, second.Start())
+	defer second.Stop()
+}
+
+func TestAbstractUnixSocket(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("abstract unix sockets are only supported on Linux")
+	}
+	name := fmt.Sprintf("@spine-test-%d", os.Getpid())
+	server := NewServer(&Config{
+		ListenConfigs:   []ListenConfig{{Schema: "local", Path: name}},
+		ServerMode:      "redis",
+		UnixSocketPerm:  0600,
+		ShutdownTimeout: time.Second,
+	})
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	conn, err := net.Dial("unix", name)
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	require.NoError(t, err)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "+PONG\r\n", line)
+
+	// 抽象命名空间的 socket 不在文件系统中创建文件
+	_, err = os.Stat(name)
+	assert.True(t, os.IsNotExist(err))
+}
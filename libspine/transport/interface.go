@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"io"
 	"net"
 	"sync"
 )
@@ -43,6 +44,16 @@ func (sc *ServerContext) SetHandler(handler Handler) {
 	sc.Handler = handler
 }
 
+// WithHandler 返回使用另一个处理器的服务器上下文，与原上下文共享服务器信息和连接管理器，
+// 用于不同监听地址运行不同处理器的场景
+func (sc *ServerContext) WithHandler(handler Handler) *ServerContext {
+	return &ServerContext{
+		ServerInfo:  sc.ServerInfo,
+		Connections: sc.Connections,
+		Handler:     handler,
+	}
+}
+
 // GetHandler 获取处理器
 func (sc *ServerContext) GetHandler() Handler {
 	sc.mu.RLock()
@@ -135,6 +146,17 @@ type Transport interface {
 	Stop() error
 }
 
+// AcceptStopper 可以单独停止接受新连接的传输层，用于优雅关闭
+type AcceptStopper interface {
+	// 关闭监听器，已建立的连接不受影响
+	StopAccepting() error
+}
+
+// MetricsProvider 可以导出 Prometheus 文本格式指标的处理器
+type MetricsProvider interface {
+	WriteMetrics(w io.Writer) error
+}
+
 // Handler 处理器接口
 type Handler interface {
 	Handle(ctx *Context, req Reader, res Writer) error
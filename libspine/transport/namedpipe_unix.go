@@ -4,6 +4,7 @@ package transport
 
 import (
 	"fmt"
+	"time"
 )
 
 // NamedPipeTransport Unix/Linux 平台上的 Named Pipe 传输层存根
@@ -15,6 +16,9 @@ func NewNamedPipeTransport(pipeName string) (*NamedPipeTransport, error) {
 	return nil, fmt.Errorf("Named Pipe transport is not supported on Unix/Linux platforms, use Unix socket instead")
 }
 
+// SetIdleTimeout 设置空闲连接超时 - Unix/Linux 上不支持
+func (t *NamedPipeTransport) SetIdleTimeout(timeout time.Duration) {}
+
 // Start 启动传输层 - Unix/Linux 上不支持
 func (t *NamedPipeTransport) Start(serverCtx *ServerContext) error {
 	return fmt.Errorf("Named Pipe transport is not supported on Unix/Linux platforms, use Unix socket instead")
@@ -19,6 +19,8 @@ type NamedPipeTransport struct {
 	mu        sync.RWMutex
 	quitChan  chan struct{}
 	wg        sync.WaitGroup
+	// 空闲超时，连接在该时间内没有收到数据则关闭，0 表示不超时
+	idleTimeout time.Duration
 }
 
 // NewNamedPipeTransport 创建新的 Named Pipe 传输层
@@ -34,6 +36,13 @@ func NewNamedPipeTransport(pipeName string) (*NamedPipeTransport, error) {
 	}, nil
 }
 
+// SetIdleTimeout 设置空闲连接超时，需在 Start 之前调用
+func (t *NamedPipeTransport) SetIdleTimeout(timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.idleTimeout = timeout
+}
+
 // Start 启动 Named Pipe 传输层
 func (t *NamedPipeTransport) Start(serverCtx *ServerContext) error {
 	t.mu.Lock()
@@ -185,7 +194,11 @@ func (t *NamedPipeTransport) handleConnection(handle windows.Handle) {
 	defer windows.CloseHandle(handle)
 
 	conn := &NamedPipeConn{handle: handle}
-	reader := &NamedPipeReader{conn: conn, quitChan: t.quitChan}
+	t.mu.RLock()
+	idleTimeout := t.idleTimeout
+	t.mu.RUnlock()
+
+	reader := &NamedPipeReader{conn: conn, quitChan: t.quitChan, idleTimeout: idleTimeout}
 	writer := &NamedPipeWriter{conn: conn}
 
 	// 创建连接信息
@@ -264,8 +277,9 @@ func (a *NamedPipeAddr) String() string {
 
 // NamedPipeReader Named Pipe 读取器
 type NamedPipeReader struct {
-	conn     *NamedPipeConn
-	quitChan chan struct{}
+	conn        *NamedPipeConn
+	quitChan    chan struct{}
+	idleTimeout time.Duration // 每次读取的超时时间，0 表示不超时
 }
 
 func (r *NamedPipeReader) Read(p []byte) (n int, err error) {
@@ -276,6 +290,14 @@ func (r *NamedPipeReader) Read(p []byte) (n int, err error) {
 	default:
 	}
 
+	// 同步 ReadFile 不支持读取截止时间，超时后由定时器关闭管道使读取返回
+	if r.idleTimeout > 0 {
+		timer := time.AfterFunc(r.idleTimeout, func() {
+			r.conn.Close()
+		})
+		defer timer.Stop()
+	}
+
 	// 使用同步读取，但设置较短的超时
 	var bytesRead uint32
 	err = windows.ReadFile(r.conn.handle, p, &bytesRead, nil)
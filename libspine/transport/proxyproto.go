@@ -0,0 +1,168 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// proxyHeaderTimeout 等待 PROXY 协议头的超时时间
+	proxyHeaderTimeout = 5 * time.Second
+	// proxyV1MaxLength PROXY v1 头的最大长度，含结尾的 \r\n
+	proxyV1MaxLength = 107
+)
+
+// proxyV2Signature PROXY v2 头的固定签名
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// errProxyHeader PROXY 协议头格式错误
+var errProxyHeader = errors.New("invalid PROXY protocol header")
+
+// proxyConn 已读取 PROXY 协议头的连接，Read 先返回缓冲区中头部之后的数据，
+// RemoteAddr 返回协议头中的客户端地址
+type proxyConn struct {
+	net.Conn
+	reader *bufio.Reader
+	remote net.Addr
+}
+
+// Read 从缓冲区读取，符合 io.Reader 接口
+func (c *proxyConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// RemoteAddr 返回真实的客户端地址
+func (c *proxyConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// acceptProxyHeader 读取连接开头的 PROXY v1 或 v2 协议头，返回以真实客户端地址为 RemoteAddr 的连接。
+// 协议头声明 UNKNOWN 或 LOCAL 时（如负载均衡器的健康检查）保留原始地址
+func acceptProxyHeader(conn net.Conn) (net.Conn, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(proxyHeaderTimeout)); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	remote, err := readProxyHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	if remote == nil {
+		remote = conn.RemoteAddr()
+	}
+	return &proxyConn{Conn: conn, reader: reader, remote: remote}, nil
+}
+
+// readProxyHeader 解析 PROXY 协议头，返回其中的源地址，没有地址信息时返回 nil
+func readProxyHeader(reader *bufio.Reader) (net.Addr, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	switch first[0] {
+	case 'P':
+		return readProxyV1(reader)
+	case proxyV2Signature[0]:
+		signature, err := reader.Peek(len(proxyV2Signature))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(signature, proxyV2Signature) {
+			return nil, errProxyHeader
+		}
+		return readProxyV2(reader)
+	}
+	return nil, errProxyHeader
+}
+
+// readProxyV1 解析文本格式的 PROXY v1 头：
+// PROXY TCP4|TCP6 源地址 目标地址 源端口 目标端口\r\n 或 PROXY UNKNOWN ...\r\n
+func readProxyV1(reader *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+		if len(line) >= proxyV1MaxLength {
+			return nil, errProxyHeader
+		}
+	}
+	if !bytes.HasPrefix(line, []byte("PROXY ")) || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errProxyHeader
+	}
+
+	fields := strings.Split(string(line[:len(line)-2]), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errProxyHeader
+	}
+	ip := net.ParseIP(fields[2])
+	if ip == nil || net.ParseIP(fields[3]) == nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, errProxyHeader
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, errProxyHeader
+	}
+	if _, err := strconv.ParseUint(fields[5], 10, 16); err != nil {
+		return nil, errProxyHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2 解析二进制格式的 PROXY v2 头
+func readProxyV2(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, len(proxyV2Signature)+4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+	versionCommand, family := header[12], header[13]
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+	if versionCommand>>4 != 2 {
+		return nil, fmt.Errorf("unsupported PROXY protocol version %d", versionCommand>>4)
+	}
+
+	switch versionCommand & 0x0f {
+	case 0x0:
+		// LOCAL：负载均衡器自身发起的连接
+		return nil, nil
+	case 0x1:
+	default:
+		return nil, errProxyHeader
+	}
+	// 只关心 TCP over IPv4/IPv6，其他协议族保留原始地址
+	switch family {
+	case 0x11:
+		if len(payload) < 12 {
+			return nil, errProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:]))}, nil
+	case 0x21:
+		if len(payload) < 36 {
+			return nil, errProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:]))}, nil
+	}
+	return nil, nil
+}
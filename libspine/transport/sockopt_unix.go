@@ -0,0 +1,27 @@
+//go:build !windows
+
+package transport
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// listenControl 返回监听 socket 的 Control 函数：总是设置 SO_REUSEADDR，
+// 使服务器重启时可以立即绑定仍有 TIME_WAIT 连接的端口；reusePort 时同时设置 SO_REUSEPORT
+func listenControl(reusePort bool) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var sockErr error
+		err := c.Control(func(fd uintptr) {
+			sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1)
+			if sockErr == nil && reusePort {
+				sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return sockErr
+	}
+}
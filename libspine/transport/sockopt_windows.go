@@ -0,0 +1,19 @@
+//go:build windows
+
+package transport
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// listenControl 返回监听 socket 的 Control 函数。Windows 上的 SO_REUSEADDR 允许其他进程抢占端口，
+// 因此不设置；Windows 不支持 SO_REUSEPORT
+func listenControl(reusePort bool) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		if reusePort {
+			return fmt.Errorf("SO_REUSEPORT is not supported on Windows")
+		}
+		return nil
+	}
+}
@@ -1,6 +1,8 @@
 package transport
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -16,20 +18,47 @@ type TCPTransport struct {
 	mu        sync.RWMutex
 	quitChan  chan struct{}
 	wg        sync.WaitGroup
+	// 空闲超时，连接在该时间内没有收到数据则关闭，0 表示不超时
+	idleTimeout time.Duration
+	// 是否先读取 PROXY 协议头
+	proxyProtocol bool
+}
+
+// TCPOptions TCP 监听选项
+type TCPOptions struct {
+	// ReusePort 设置 SO_REUSEPORT，允许多个进程监听同一端口，Windows 上不支持
+	ReusePort bool
+	// ProxyProtocol 每个连接先读取负载均衡器发送的 PROXY v1/v2 协议头，以其中的客户端地址作为连接的远端地址，
+	// 开启后不带协议头的连接会被关闭
+	ProxyProtocol bool
 }
 
 // NewTCPTransport 创建新的 TCP 传输层
 func NewTCPTransport(addr string) (*TCPTransport, error) {
-	listener, err := net.Listen("tcp", addr)
+	return NewTCPTransportWithOptions(addr, TCPOptions{})
+}
+
+// NewTCPTransportWithOptions 按监听选项创建 TCP 传输层
+func NewTCPTransportWithOptions(addr string, options TCPOptions) (*TCPTransport, error) {
+	listenConfig := net.ListenConfig{Control: listenControl(options.ReusePort)}
+	listener, err := listenConfig.Listen(context.Background(), "tcp", addr)
 	if err != nil {
 		return nil, err
 	}
 	return &TCPTransport{
-		listener: listener,
-		quitChan: make(chan struct{}),
+		listener:      listener,
+		quitChan:      make(chan struct{}),
+		proxyProtocol: options.ProxyProtocol,
 	}, nil
 }
 
+// SetIdleTimeout 设置空闲连接超时，需在 Start 之前调用
+func (t *TCPTransport) SetIdleTimeout(timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.idleTimeout = timeout
+}
+
 // Start 启动 TCP 传输层
 func (t *TCPTransport) Start(serverCtx *ServerContext) error {
 	t.mu.Lock()
@@ -75,6 +104,27 @@ func (t *TCPTransport) Stop() error {
 	return nil
 }
 
+// StopAccepting 关闭监听器停止接受新连接，已有连接继续处理直到 Stop
+func (t *TCPTransport) StopAccepting() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.running || t.listener == nil {
+		return nil
+	}
+	return t.listener.Close()
+}
+
+// stopped 是否已调用 Stop，不加锁以免与持锁等待的 Stop 死锁
+func (t *TCPTransport) stopped() bool {
+	select {
+	case <-t.quitChan:
+		return true
+	default:
+		return false
+	}
+}
+
 // acceptConnections 接受连接
 func (t *TCPTransport) acceptConnections() {
 	defer t.wg.Done()
@@ -86,7 +136,7 @@ func (t *TCPTransport) acceptConnections() {
 		default:
 			conn, err := t.listener.Accept()
 			if err != nil {
-				if t.running {
+				if !t.stopped() && !errors.Is(err, net.ErrClosed) {
 					log.Printf("TCP accept error: %v", err)
 				}
 				return
@@ -103,18 +153,45 @@ func (t *TCPTransport) handleConnection(conn net.Conn) {
 	defer t.wg.Done()
 	defer conn.Close()
 
-	reader := &TCPReader{Conn: conn, quitChan: t.quitChan}
+	t.mu.RLock()
+	idleTimeout := t.idleTimeout
+	t.mu.RUnlock()
+
+	// 监听quit信号，如果收到则立即关闭连接
+	go func() {
+		select {
+		case <-t.quitChan:
+			conn.Close()
+		}
+	}()
+
+	// 读取 PROXY 协议头，之后从 readConn 读取数据，远端地址为协议头中的客户端地址
+	readConn := conn
+	if t.proxyProtocol {
+		proxied, err := acceptProxyHeader(conn)
+		if err != nil {
+			log.Printf("TCP connection from %s rejected: %v", conn.RemoteAddr(), err)
+			return
+		}
+		readConn = proxied
+	}
+
+	reader := &TCPReader{Conn: readConn, quitChan: t.quitChan, IdleTimeout: idleTimeout}
 	writer := &TCPWriter{Conn: conn}
 
 	// 创建连接信息
 	connInfo := &ConnInfo{
 		ID:       generateID(),
-		Remote:   conn.RemoteAddr(),
+		Remote:   readConn.RemoteAddr(),
 		Protocol: "tcp",
 		Metadata: make(map[string]interface{}),
 		Reader:   reader,
 		Writer:   writer,
 	}
+	if t.proxyProtocol {
+		// 负载均衡器的地址
+		connInfo.Metadata["proxy_addr"] = conn.RemoteAddr().String()
+	}
 
 	// 添加到连接管理器
 	t.serverCtx.Connections.AddConnection(connInfo)
@@ -129,14 +206,6 @@ func (t *TCPTransport) handleConnection(conn net.Conn) {
 	// 连接关闭时从管理器移除
 	defer t.serverCtx.Connections.RemoveConnection(connInfo.ID)
 
-	// 监听quit信号，如果收到则立即关闭连接
-	go func() {
-		select {
-		case <-t.quitChan:
-			conn.Close()
-		}
-	}()
-
 	// 获取处理器
 	handler := t.serverCtx.GetHandler()
 	if handler != nil {
@@ -156,12 +225,19 @@ func (t *TCPTransport) handleConnection(conn net.Conn) {
 
 // TCPReader TCP 读取器
 type TCPReader struct {
-	Conn     net.Conn
-	quitChan <-chan struct{}
+	Conn        net.Conn
+	quitChan    <-chan struct{}
+	IdleTimeout time.Duration // 每次读取的超时时间，0 表示不超时
 }
 
 // Read 读取数据到提供的缓冲区中，符合 io.Reader 接口
+// 设置了空闲超时时，超时后返回 Timeout() 为 true 的 net.Error
 func (r *TCPReader) Read(p []byte) (n int, err error) {
+	if r.IdleTimeout > 0 {
+		if err := r.Conn.SetReadDeadline(time.Now().Add(r.IdleTimeout)); err != nil {
+			return 0, err
+		}
+	}
 	return r.Conn.Read(p)
 }
 
@@ -3,29 +3,46 @@
 package transport
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 // UnixSocketTransport Unix Socket 传输层实现
 type UnixSocketTransport struct {
 	listener  net.Listener
 	path      string
+	abstract  bool // 是否是 Linux 抽象命名空间的 socket，没有对应的文件
 	serverCtx *ServerContext
 	running   bool
 	mu        sync.RWMutex
 	quitChan  chan struct{}
 	wg        sync.WaitGroup
+	// 空闲超时，连接在该时间内没有收到数据则关闭，0 表示不超时
+	idleTimeout time.Duration
 }
 
 // NewUnixSocketTransport 创建新的 Unix Socket 传输层
+// 以 @ 开头的路径表示 Linux 的抽象命名空间 socket，例如 @spine，不在文件系统中创建文件
 func NewUnixSocketTransport(path string) (*UnixSocketTransport, error) {
+	abstract := strings.HasPrefix(path, "@")
+	if abstract && runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("abstract unix socket %s is only supported on Linux", path)
+	}
+
 	// 如果文件已存在，先删除
-	if _, err := os.Stat(path); err == nil {
-		os.Remove(path)
+	if !abstract {
+		if _, err := os.Stat(path); err == nil {
+			os.Remove(path)
+		}
 	}
 
 	listener, err := net.Listen("unix", path)
@@ -36,10 +53,56 @@ func NewUnixSocketTransport(path string) (*UnixSocketTransport, error) {
 	return &UnixSocketTransport{
 		listener: listener,
 		path:     path,
+		abstract: abstract,
 		quitChan: make(chan struct{}),
 	}, nil
 }
 
+// SetIdleTimeout 设置空闲连接超时，需在 Start 之前调用
+func (u *UnixSocketTransport) SetIdleTimeout(timeout time.Duration) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.idleTimeout = timeout
+}
+
+// SetPermissions 修改 socket 文件的权限和属组，需在 Start 之前调用，失败时关闭监听并删除 socket 文件。
+// perm 为 0 表示保留创建时的权限（受 umask 影响），group 可以是组名或 gid，为空表示不修改属组。
+// 抽象命名空间的 socket 没有文件权限，不做任何修改
+func (u *UnixSocketTransport) SetPermissions(perm os.FileMode, group string) error {
+	if u.abstract {
+		return nil
+	}
+	err := u.setPermissions(perm, group)
+	if err != nil {
+		u.listener.Close()
+		os.Remove(u.path)
+	}
+	return err
+}
+
+// setPermissions 执行 SetPermissions 的修改
+func (u *UnixSocketTransport) setPermissions(perm os.FileMode, group string) error {
+	if perm != 0 {
+		if err := os.Chmod(u.path, perm); err != nil {
+			return err
+		}
+	}
+	if group == "" {
+		return nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		if g, err = user.LookupGroupId(group); err != nil {
+			return fmt.Errorf("unknown group %s", group)
+		}
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return err
+	}
+	return os.Chown(u.path, -1, gid)
+}
+
 // Start 启动 Unix Socket 传输层
 func (u *UnixSocketTransport) Start(serverCtx *ServerContext) error {
 	u.mu.Lock()
@@ -76,13 +139,36 @@ func (u *UnixSocketTransport) Stop() error {
 	}
 
 	// 删除 socket 文件
-	os.Remove(u.path)
+	if !u.abstract {
+		os.Remove(u.path)
+	}
 
 	u.wg.Wait()
 	log.Printf("Unix socket transport stopped")
 	return nil
 }
 
+// StopAccepting 关闭监听器停止接受新连接，已有连接继续处理直到 Stop
+func (u *UnixSocketTransport) StopAccepting() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.running || u.listener == nil {
+		return nil
+	}
+	return u.listener.Close()
+}
+
+// stopped 是否已调用 Stop，不加锁以免与持锁等待的 Stop 死锁
+func (u *UnixSocketTransport) stopped() bool {
+	select {
+	case <-u.quitChan:
+		return true
+	default:
+		return false
+	}
+}
+
 // acceptConnections 接受连接
 func (u *UnixSocketTransport) acceptConnections() {
 	defer u.wg.Done()
@@ -94,7 +180,7 @@ func (u *UnixSocketTransport) acceptConnections() {
 		default:
 			conn, err := u.listener.Accept()
 			if err != nil {
-				if u.running {
+				if !u.stopped() && !errors.Is(err, net.ErrClosed) {
 					log.Printf("Unix socket accept error: %v", err)
 				}
 				return
@@ -111,7 +197,11 @@ func (u *UnixSocketTransport) handleConnection(conn net.Conn) {
 	defer u.wg.Done()
 	defer conn.Close()
 
-	reader := &UnixSocketReader{Conn: conn}
+	u.mu.RLock()
+	idleTimeout := u.idleTimeout
+	u.mu.RUnlock()
+
+	reader := &UnixSocketReader{Conn: conn, IdleTimeout: idleTimeout}
 	writer := &UnixSocketWriter{Conn: conn}
 
 	// 创建连接信息
@@ -137,25 +227,30 @@ func (u *UnixSocketTransport) handleConnection(conn net.Conn) {
 	// 连接关闭时从管理器移除
 	defer u.serverCtx.Connections.RemoveConnection(connInfo.ID)
 
-	// 持续处理连接上的数据
-	for {
-		// 获取处理器并处理数据
-		handler := u.serverCtx.GetHandler()
-		if handler != nil {
-			if err := handler.Handle(ctx, reader, writer); err != nil {
-				log.Printf("Unix socket handler error: %v", err)
-			}
+	// Handle 负责持续处理连接，返回即表示连接结束。
+	// 不能在这里循环调用 Handle，否则连接关闭后会空转
+	handler := u.serverCtx.GetHandler()
+	if handler != nil {
+		if err := handler.Handle(ctx, reader, writer); err != nil {
+			log.Printf("Unix socket handler error: %v", err)
 		}
 	}
 }
 
 // UnixSocketReader Unix Socket 读取器
 type UnixSocketReader struct {
-	Conn net.Conn
+	Conn        net.Conn
+	IdleTimeout time.Duration // 每次读取的超时时间，0 表示不超时
 }
 
 // Read 读取数据到提供的缓冲区中，符合 io.Reader 接口
+// 设置了空闲超时时，超时后返回 Timeout() 为 true 的 net.Error
 func (r *UnixSocketReader) Read(p []byte) (n int, err error) {
+	if r.IdleTimeout > 0 {
+		if err := r.Conn.SetReadDeadline(time.Now().Add(r.IdleTimeout)); err != nil {
+			return 0, err
+		}
+	}
 	return r.Conn.Read(p)
 }
 
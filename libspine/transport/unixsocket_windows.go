@@ -5,6 +5,8 @@ package transport
 import (
 	"fmt"
 	"net"
+	"os"
+	"time"
 )
 
 // UnixSocketTransport Windows 平台上的 Unix Socket 传输层存根
@@ -16,6 +18,14 @@ func NewUnixSocketTransport(socketPath string) (*UnixSocketTransport, error) {
 	return nil, fmt.Errorf("Unix socket transport is not supported on Windows platform")
 }
 
+// SetIdleTimeout 设置空闲连接超时 - Windows 上不支持
+func (t *UnixSocketTransport) SetIdleTimeout(timeout time.Duration) {}
+
+// SetPermissions 修改 socket 文件的权限和属组 - Windows 上不支持
+func (t *UnixSocketTransport) SetPermissions(perm os.FileMode, group string) error {
+	return fmt.Errorf("Unix socket transport is not supported on Windows platform")
+}
+
 // Start 启动传输层 - Windows 上不支持
 func (t *UnixSocketTransport) Start(serverCtx *ServerContext) error {
 	return fmt.Errorf("Unix socket transport is not supported on Windows platform")
@@ -2,10 +2,12 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log"
 	"net"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -58,6 +60,13 @@ func (w *WebSocketTransport) Start(serverCtx *ServerContext) error {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// 启用指标时，处理器支持的情况下提供 Prometheus 指标
+	if serverCtx != nil && serverCtx.ServerInfo != nil && serverCtx.ServerInfo.Config != nil {
+		if enabled, _ := serverCtx.ServerInfo.Config["metrics"].(bool); enabled {
+			w.router.GET("/metrics", w.handleMetrics)
+		}
+	}
+
 	// 获取静态文件路径
 	staticPath := ""
 	if serverCtx != nil && serverCtx.ServerInfo != nil && serverCtx.ServerInfo.Config != nil {
@@ -94,6 +103,19 @@ func (w *WebSocketTransport) Start(serverCtx *ServerContext) error {
 	return nil
 }
 
+// handleMetrics 输出处理器的 Prometheus 指标
+func (w *WebSocketTransport) handleMetrics(c *gin.Context) {
+	provider, ok := w.serverCtx.GetHandler().(MetricsProvider)
+	if !ok {
+		c.String(http.StatusNotFound, "metrics not available\n")
+		return
+	}
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := provider.WriteMetrics(c.Writer); err != nil {
+		log.Printf("Error writing metrics: %v", err)
+	}
+}
+
 // handleWebSocket 处理 WebSocket 连接
 func (w *WebSocketTransport) handleWebSocket(c *gin.Context) {
 	conn, err := w.upgrader.Upgrade(c.Writer, c.Request, nil)
@@ -102,9 +124,9 @@ func (w *WebSocketTransport) handleWebSocket(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	// 创建 Reader 和 Writer
+	// 创建 Reader 和 Writer，回复使用与最近一条请求相同的消息类型
 	reader := &WebSocketReader{conn: conn}
-	writer := &WebSocketWriter{conn: conn}
+	writer := &WebSocketWriter{conn: conn, reader: reader}
 
 	// 创建连接信息
 	remoteAddr := conn.RemoteAddr()
@@ -184,6 +206,7 @@ type WebSocketReader struct {
 	conn       *websocket.Conn
 	reader     io.Reader // 当前消息的 reader
 	messageType int      // 当前消息类型
+	lastType   atomic.Int32 // 最近一条消息的类型，供写入器选择回复类型
 }
 
 // Read 读取数据到提供的缓冲区中，符合 io.Reader 接口
@@ -192,8 +215,15 @@ func (r *WebSocketReader) Read(p []byte) (n int, err error) {
 	if r.reader == nil {
 		r.messageType, r.reader, err = r.conn.NextReader()
 		if err != nil {
+			// 对端关闭连接（包括异常断开）视为流结束，
+			// 避免上层在已失败的连接上反复读取
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				return 0, io.EOF
+			}
 			return 0, err
 		}
+		r.lastType.Store(int32(r.messageType))
 	}
 	
 	// 从当前 reader 读取数据
@@ -225,13 +255,24 @@ func (r *WebSocketReader) Close() error {
 
 // WebSocketWriter WebSocket 写入器
 type WebSocketWriter struct {
-	conn *websocket.Conn
+	conn   *websocket.Conn
+	reader *WebSocketReader // 同一连接的读取器，为 nil 时总是发送文本消息
+}
+
+// messageType 返回回复使用的消息类型
+// 客户端用二进制消息发送请求（例如 RESP 命令）时回复同样使用二进制消息
+func (w *WebSocketWriter) messageType() int {
+	if w.reader != nil && w.reader.lastType.Load() == websocket.BinaryMessage {
+		return websocket.BinaryMessage
+	}
+	return websocket.TextMessage
 }
 
 // Write 写入数据，符合 io.Writer 接口
 func (w *WebSocketWriter) Write(p []byte) (n int, err error) {
-	log.Printf("WebSocketWriter.Write: Sending message type: %d, data: %s", websocket.TextMessage, string(p))
-	err = w.conn.WriteMessage(websocket.TextMessage, p)
+	messageType := w.messageType()
+	log.Printf("WebSocketWriter.Write: Sending message type: %d, data: %s", messageType, string(p))
+	err = w.conn.WriteMessage(messageType, p)
 	if err != nil {
 		return 0, err
 	}
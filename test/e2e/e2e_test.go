@@ -6,6 +6,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"spine-go/libspine"
 )
 
 // E2ETestSuite E2E 测试套件
@@ -15,7 +17,9 @@ type E2ETestSuite struct {
 	messageValidator  *MessageValidator
 	responseValidator *ResponseValidator
 	connectionValidator *ConnectionValidator
+	replyValidator    *ReplyValidator
 	clients           map[string]TestClient
+	redisClients      map[string]*RedisTestClient
 	mu                sync.RWMutex
 }
 
@@ -27,7 +31,9 @@ func NewE2ETestSuite() *E2ETestSuite {
 		messageValidator:    NewMessageValidator(),
 		responseValidator:   NewResponseValidator(),
 		connectionValidator: NewConnectionValidator(),
+		replyValidator:      NewReplyValidator(),
 		clients:             make(map[string]TestClient),
+		redisClients:        make(map[string]*RedisTestClient),
 	}
 }
 
@@ -53,6 +59,10 @@ func (suite *E2ETestSuite) TeardownTest() error {
 		}
 		delete(suite.clients, name)
 	}
+	for name, client := range suite.redisClients {
+		client.Disconnect()
+		delete(suite.redisClients, name)
+	}
 	suite.mu.Unlock()
 
 	// 停止测试服务器
@@ -65,6 +75,31 @@ func (suite *E2ETestSuite) TeardownTest() error {
 	return nil
 }
 
+// SetupRedisTest 以 redis 模式启动 TCP 测试服务器，configure 不为 nil 时用于调整服务器配置
+func (suite *E2ETestSuite) SetupRedisTest(configure func(config *libspine.Config)) error {
+	suite.serverManager.SetServerMode("redis")
+	suite.serverManager.SetConfigHook(configure)
+	return suite.SetupTest([]string{"tcp"})
+}
+
+// CreateRedisClient 创建并连接 redis 测试客户端
+func (suite *E2ETestSuite) CreateRedisClient(name string) (*RedisTestClient, error) {
+	address, err := suite.serverManager.GetServerAddress("tcp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server address: %v", err)
+	}
+
+	client := NewRedisTestClient(address)
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect client: %v", err)
+	}
+
+	suite.mu.Lock()
+	suite.redisClients[name] = client
+	suite.mu.Unlock()
+	return client, nil
+}
+
 // CreateClient 创建并连接客户端
 func (suite *E2ETestSuite) CreateClient(name, protocol string) error {
 	address, err := suite.serverManager.GetServerAddress(protocol)
@@ -0,0 +1,189 @@
+package e2e
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"spine-go/libspine/common/resp"
+)
+
+// ReplyKind Redis 回复的类型
+type ReplyKind int
+
+const (
+	ReplyStatus  ReplyKind = iota // 简单字符串，如 OK
+	ReplyError                    // 错误回复，带错误码
+	ReplyInteger                  // 整数
+	ReplyBulk                     // 批量字符串
+	ReplyArray                    // 数组，RESP3 的 map、set 和 push 也按数组处理
+	ReplyNull                     // 空回复
+)
+
+// String 返回回复类型的名称，用于错误信息
+func (k ReplyKind) String() string {
+	switch k {
+	case ReplyStatus:
+		return "status"
+	case ReplyError:
+		return "error"
+	case ReplyInteger:
+		return "integer"
+	case ReplyBulk:
+		return "bulk"
+	case ReplyArray:
+		return "array"
+	case ReplyNull:
+		return "null"
+	}
+	return fmt.Sprintf("ReplyKind(%d)", int(k))
+}
+
+// RedisReply 解析后的 Redis 回复，测试按 Kind 断言类型后再比较对应字段
+type RedisReply struct {
+	Kind    ReplyKind
+	Str     string        // 简单字符串或批量字符串的内容
+	Int     int64         // 整数回复的值
+	Array   []*RedisReply // 数组回复的元素
+	Code    string        // 错误码，如 ERR、WRONGTYPE、NOAUTH
+	Message string        // 错误码之后的错误信息
+}
+
+// String 返回回复的可读形式，用于错误信息
+func (r *RedisReply) String() string {
+	switch r.Kind {
+	case ReplyStatus:
+		return r.Str
+	case ReplyError:
+		return "(error) " + r.Code + " " + r.Message
+	case ReplyInteger:
+		return fmt.Sprintf("(integer) %d", r.Int)
+	case ReplyBulk:
+		return fmt.Sprintf("%q", r.Str)
+	case ReplyNull:
+		return "(nil)"
+	}
+	items := make([]string, len(r.Array))
+	for i, item := range r.Array {
+		items[i] = item.String()
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// ParseRedisReply 把 RESP 值转换为 RedisReply。错误回复按 Redis 的约定拆分为
+// 第一个空格前的错误码和之后的错误信息
+func ParseRedisReply(value resp.Value) *RedisReply {
+	switch value.Type {
+	case resp.DataType(resp.TypeSimpleString):
+		return &RedisReply{Kind: ReplyStatus, Str: value.String}
+	case resp.DataType(resp.TypeError):
+		code, message, _ := strings.Cut(value.String, " ")
+		return &RedisReply{Kind: ReplyError, Code: code, Message: message}
+	case resp.DataType(resp.TypeInteger):
+		return &RedisReply{Kind: ReplyInteger, Int: value.Int}
+	case resp.DataType(resp.TypeNull):
+		return &RedisReply{Kind: ReplyNull}
+	case resp.DataType(resp.TypeBulkString):
+		if value.IsNull {
+			return &RedisReply{Kind: ReplyNull}
+		}
+		return &RedisReply{Kind: ReplyBulk, Str: string(value.Bulk)}
+	case resp.DataType(resp.TypeArray), resp.DataType(resp.TypeSet), resp.DataType(resp.TypePush):
+		if value.IsNull {
+			return &RedisReply{Kind: ReplyNull}
+		}
+		reply := &RedisReply{Kind: ReplyArray, Array: make([]*RedisReply, len(value.Array))}
+		for i, item := range value.Array {
+			reply.Array[i] = ParseRedisReply(item)
+		}
+		return reply
+	case resp.DataType(resp.TypeMap):
+		reply := &RedisReply{Kind: ReplyArray, Array: make([]*RedisReply, 0, len(value.Map)*2)}
+		for _, item := range value.Map {
+			reply.Array = append(reply.Array, ParseRedisReply(item.Key), ParseRedisReply(item.Value))
+		}
+		return reply
+	}
+	// 其他 RESP3 类型按文本处理
+	return &RedisReply{Kind: ReplyBulk, Str: value.String}
+}
+
+// RedisTestClient redis 模式的测试客户端，发送 RESP 命令并解析回复，错误回复作为 RedisReply 返回
+type RedisTestClient struct {
+	address string
+	conn    net.Conn
+	parser  *resp.Parser
+	mu      sync.Mutex
+}
+
+// NewRedisTestClient 创建新的 redis 测试客户端
+func NewRedisTestClient(address string) *RedisTestClient {
+	return &RedisTestClient{address: address}
+}
+
+// Connect 连接到服务器
+func (c *RedisTestClient) Connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		return fmt.Errorf("client is already connected")
+	}
+	conn, err := net.DialTimeout("tcp", c.address, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", c.address, err)
+	}
+	c.conn = conn
+	c.parser = resp.NewParser(bufio.NewReader(conn))
+	return nil
+}
+
+// Disconnect 断开连接
+func (c *RedisTestClient) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	c.parser = nil
+	return err
+}
+
+// IsConnected 检查连接状态
+func (c *RedisTestClient) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn != nil
+}
+
+// Do 发送命令并等待回复，只有连接错误才返回 error
+func (c *RedisTestClient) Do(args ...string) (*RedisReply, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil, fmt.Errorf("client is not connected")
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	data, err := resp.SerializeCommand(args[0], args[1:]...)
+	if err != nil {
+		return nil, err
+	}
+	c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.conn.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to send command: %v", err)
+	}
+	value, err := c.parser.Parse()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reply: %v", err)
+	}
+	return ParseRedisReply(value), nil
+}
@@ -0,0 +1,245 @@
+package e2e
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"spine-go/libspine"
+	"spine-go/libspine/common/resp"
+)
+
+func TestParseRedisReply(t *testing.T) {
+	validator := NewReplyValidator()
+
+	reply := ParseRedisReply(resp.Value{Type: resp.DataType(resp.TypeError), String: "WRONGTYPE Operation against a key holding the wrong kind of value"})
+	if err := validator.ValidateError(reply, "WRONGTYPE", "Operation against"); err != nil {
+		t.Fatal(err)
+	}
+	// 错误码必须完全一致，WRONGTYPE 不能当作 ERR
+	if err := validator.ValidateError(reply, "ERR", ""); err == nil {
+		t.Fatal("WRONGTYPE error validated as ERR")
+	}
+	if err := validator.ValidateBulk(reply, ""); err == nil {
+		t.Fatal("error reply validated as bulk")
+	}
+
+	reply = ParseRedisReply(resp.NewArray([]resp.Value{
+		resp.NewInteger(1),
+		resp.NewBulkStringString("value"),
+		{Type: resp.DataType(resp.TypeBulkString), IsNull: true},
+		resp.NewSimpleString("OK"),
+	}))
+	if err := validator.ValidateArrayLen(reply, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := validator.ValidateInteger(reply.Array[0], 1); err != nil {
+		t.Error(err)
+	}
+	if err := validator.ValidateBulk(reply.Array[1], "value"); err != nil {
+		t.Error(err)
+	}
+	if err := validator.ValidateNull(reply.Array[2]); err != nil {
+		t.Error(err)
+	}
+	if err := validator.ValidateStatus(reply.Array[3], "OK"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRedisReplyTypes(t *testing.T) {
+	suite := NewE2ETestSuite()
+	if err := suite.SetupRedisTest(nil); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer suite.TeardownTest()
+
+	client, err := suite.CreateRedisClient("client1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	do := func(args ...string) *RedisReply {
+		t.Helper()
+		reply, err := client.Do(args...)
+		if err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		return reply
+	}
+
+	validator := suite.replyValidator
+	checks := []error{
+		validator.ValidateStatus(do("SET", "key", "value"), "OK"),
+		validator.ValidateBulk(do("GET", "key"), "value"),
+		validator.ValidateNull(do("GET", "missing")),
+		validator.ValidateInteger(do("DBSIZE"), 1),
+		validator.ValidateArrayLen(do("COMMAND", "INFO", "get", "set"), 2),
+		validator.ValidateError(do("NOSUCHCOMMAND"), "ERR", "unknown command"),
+		validator.ValidateError(do("GET"), "ERR", "wrong number of arguments for 'get' command"),
+		validator.ValidateError(do("SET", "key", "value", "NX", "XX"), "ERR", "syntax error"),
+	}
+	for _, err := range checks {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRedisErrorCodes(t *testing.T) {
+	suite := NewE2ETestSuite()
+	err := suite.SetupRedisTest(func(config *libspine.Config) {
+		config.RequirePass = "secret"
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer suite.TeardownTest()
+
+	client, err := suite.CreateRedisClient("client1")
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	do := func(args ...string) *RedisReply {
+		t.Helper()
+		reply, err := client.Do(args...)
+		if err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		return reply
+	}
+
+	validator := suite.replyValidator
+	checks := []error{
+		validator.ValidateError(do("GET", "key"), "NOAUTH", "Authentication required."),
+		validator.ValidateError(do("AUTH", "wrong"), "WRONGPASS", "invalid username-password pair"),
+		validator.ValidateStatus(do("AUTH", "secret"), "OK"),
+		validator.ValidateStatus(do("ACL", "SETUSER", "reader", "on", ">pw", "~*", "+get"), "OK"),
+		validator.ValidateStatus(do("AUTH", "reader", "pw"), "OK"),
+		validator.ValidateNull(do("GET", "key")),
+		validator.ValidateError(do("SET", "key", "value"), "NOPERM", "User reader has no permissions"),
+	}
+	for _, err := range checks {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestRedisConcurrentClients 多个客户端并发读写共享的键，检查最终结果与各客户端执行的操作一致。
+// 使用 go test -race 运行时可以发现存储层的数据竞争
+func TestRedisConcurrentClients(t *testing.T) {
+	const (
+		clientCount = 16
+		iterations  = 200
+		total       = clientCount * iterations
+	)
+
+	suite := NewE2ETestSuite()
+	if err := suite.SetupRedisTest(nil); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	defer suite.TeardownTest()
+
+	clients := make([]*RedisTestClient, clientCount)
+	for i := range clients {
+		client, err := suite.CreateRedisClient(fmt.Sprintf("client%d", i))
+		if err != nil {
+			t.Fatalf("Failed to create client %d: %v", i, err)
+		}
+		clients[i] = client
+	}
+
+	validator := suite.replyValidator
+	// 每次 APPEND 返回追加后的长度，操作是原子的则所有返回值互不相同
+	lengths := make([][]int64, clientCount)
+	var wg sync.WaitGroup
+	errorChan := make(chan error, clientCount)
+	for i, client := range clients {
+		wg.Add(1)
+		go func(index int, client *RedisTestClient) {
+			defer wg.Done()
+			own := fmt.Sprintf("client:%d", index)
+			for j := 0; j < iterations; j++ {
+				value := strconv.Itoa(j)
+				steps := []struct {
+					args  []string
+					check func(*RedisReply) error
+				}{
+					{[]string{"APPEND", "counter", "x"}, func(reply *RedisReply) error {
+						if reply.Kind != ReplyInteger {
+							return fmt.Errorf("APPEND returned %s", reply)
+						}
+						lengths[index] = append(lengths[index], reply.Int)
+						return nil
+					}},
+					{[]string{"SETBIT", "bitmap", strconv.Itoa(index*iterations + j), "1"}, func(reply *RedisReply) error {
+						return validator.ValidateInteger(reply, 0)
+					}},
+					{[]string{"SET", own, value, "EX", "3600"}, func(reply *RedisReply) error {
+						return validator.ValidateStatus(reply, "OK")
+					}},
+					{[]string{"GET", own}, func(reply *RedisReply) error {
+						return validator.ValidateBulk(reply, value)
+					}},
+					{[]string{"SET", "shared", own}, func(reply *RedisReply) error {
+						return validator.ValidateStatus(reply, "OK")
+					}},
+					{[]string{"GET", "shared"}, func(reply *RedisReply) error {
+						if reply.Kind != ReplyBulk || !strings.HasPrefix(reply.Str, "client:") {
+							return fmt.Errorf("GET shared returned %s", reply)
+						}
+						return nil
+					}},
+				}
+				for _, step := range steps {
+					reply, err := client.Do(step.args...)
+					if err == nil {
+						err = step.check(reply)
+					}
+					if err != nil {
+						errorChan <- fmt.Errorf("client %d: %v: %v", index, step.args, err)
+						return
+					}
+				}
+			}
+		}(i, client)
+	}
+	wg.Wait()
+	close(errorChan)
+	for err := range errorChan {
+		t.Fatal(err)
+	}
+
+	seen := make(map[int64]bool, total)
+	for _, clientLengths := range lengths {
+		for _, length := range clientLengths {
+			if length < 1 || length > total || seen[length] {
+				t.Fatalf("APPEND returned duplicate or out of range length %d", length)
+			}
+			seen[length] = true
+		}
+	}
+
+	client := clients[0]
+	do := func(args ...string) *RedisReply {
+		t.Helper()
+		reply, err := client.Do(args...)
+		if err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		return reply
+	}
+	checks := []error{
+		validator.ValidateInteger(do("STRLEN", "counter"), total),
+		validator.ValidateInteger(do("BITCOUNT", "bitmap"), total),
+		validator.ValidateBulk(do("GET", "client:3"), strconv.Itoa(iterations-1)),
+		validator.ValidateInteger(do("DBSIZE"), clientCount+3),
+	}
+	for _, err := range checks {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -22,6 +22,8 @@ type TestServerManager struct {
 	isRunning  bool
 	startTime  time.Time
 	testPorts  map[string]int // 协议 -> 端口映射
+	serverMode string         // 服务器模式，为空时为 chat
+	configure  func(config *libspine.Config)
 }
 
 // NewTestServerManager 创建新的测试服务器管理器
@@ -34,6 +36,20 @@ func NewTestServerManager() *TestServerManager {
 	}
 }
 
+// SetServerMode 设置下次启动时的服务器模式，"chat" 或 "redis"
+func (tsm *TestServerManager) SetServerMode(mode string) {
+	tsm.mu.Lock()
+	defer tsm.mu.Unlock()
+	tsm.serverMode = mode
+}
+
+// SetConfigHook 设置下次启动前调整服务器配置的函数，例如设置 RequirePass
+func (tsm *TestServerManager) SetConfigHook(configure func(config *libspine.Config)) {
+	tsm.mu.Lock()
+	defer tsm.mu.Unlock()
+	tsm.configure = configure
+}
+
 // StartServer 启动测试服务器
 func (tsm *TestServerManager) StartServer(protocols []string) error {
 	tsm.mu.Lock()
@@ -83,11 +99,18 @@ func (tsm *TestServerManager) StartServer(protocols []string) error {
 	}
 
 	// 创建服务器配置
+	serverMode := tsm.serverMode
+	if serverMode == "" {
+		serverMode = "chat"
+	}
 	tsm.config = &libspine.Config{
 		ListenConfigs: listenConfigs,
-		ServerMode:    "chat",
+		ServerMode:    serverMode,
 		StaticPath:    "", // 测试时不需要静态文件
 	}
+	if tsm.configure != nil {
+		tsm.configure(tsm.config)
+	}
 
 	// 创建并启动服务器
 	tsm.server = libspine.NewServer(tsm.config)
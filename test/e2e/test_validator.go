@@ -3,6 +3,7 @@ package e2e
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -277,3 +278,86 @@ func (v *ConnectionValidator) ValidateServerConnections(server *TestServerManage
 
 	return nil
 }
+
+// ReplyValidator Redis 回复验证器，按类型和错误码精确比较回复
+type ReplyValidator struct{}
+
+// NewReplyValidator 创建新的回复验证器
+func NewReplyValidator() *ReplyValidator {
+	return &ReplyValidator{}
+}
+
+// expectKind 验证回复的类型
+func (v *ReplyValidator) expectKind(reply *RedisReply, kind ReplyKind) error {
+	if reply == nil {
+		return fmt.Errorf("reply is nil")
+	}
+	if reply.Kind != kind {
+		return fmt.Errorf("expected %s reply, got %s reply %s", kind, reply.Kind, reply)
+	}
+	return nil
+}
+
+// ValidateStatus 验证简单字符串回复，如 OK
+func (v *ReplyValidator) ValidateStatus(reply *RedisReply, expected string) error {
+	if err := v.expectKind(reply, ReplyStatus); err != nil {
+		return err
+	}
+	if reply.Str != expected {
+		return fmt.Errorf("expected status %q, got %q", expected, reply.Str)
+	}
+	return nil
+}
+
+// ValidateInteger 验证整数回复
+func (v *ReplyValidator) ValidateInteger(reply *RedisReply, expected int64) error {
+	if err := v.expectKind(reply, ReplyInteger); err != nil {
+		return err
+	}
+	if reply.Int != expected {
+		return fmt.Errorf("expected integer %d, got %d", expected, reply.Int)
+	}
+	return nil
+}
+
+// ValidateBulk 验证批量字符串回复
+func (v *ReplyValidator) ValidateBulk(reply *RedisReply, expected string) error {
+	if err := v.expectKind(reply, ReplyBulk); err != nil {
+		return err
+	}
+	if reply.Str != expected {
+		return fmt.Errorf("expected bulk %q, got %q", expected, reply.Str)
+	}
+	return nil
+}
+
+// ValidateNull 验证空回复
+func (v *ReplyValidator) ValidateNull(reply *RedisReply) error {
+	return v.expectKind(reply, ReplyNull)
+}
+
+// ValidateArrayLen 验证数组回复的元素个数
+func (v *ReplyValidator) ValidateArrayLen(reply *RedisReply, expected int) error {
+	if err := v.expectKind(reply, ReplyArray); err != nil {
+		return err
+	}
+	if len(reply.Array) != expected {
+		return fmt.Errorf("expected array of %d elements, got %d: %s", expected, len(reply.Array), reply)
+	}
+	return nil
+}
+
+// ValidateError 验证错误回复的错误码，如 ERR、WRONGTYPE、NOAUTH；
+// messagePrefix 不为空时同时验证错误信息的开头
+func (v *ReplyValidator) ValidateError(reply *RedisReply, code, messagePrefix string) error {
+	if err := v.expectKind(reply, ReplyError); err != nil {
+		return err
+	}
+	if reply.Code != code {
+		return fmt.Errorf("expected %s error, got %s", code, reply)
+	}
+	if !strings.HasPrefix(reply.Message, messagePrefix) {
+		return fmt.Errorf("expected error message starting with %q, got %q", messagePrefix, reply.Message)
+	}
+	return nil
+}
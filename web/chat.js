@@ -225,7 +225,7 @@ class SpineChat {
         const joinRequest = {
             method: 'JOIN',
             path: '/chat',
-            data: {}
+            data: { user: this.username }
         };
         
         // 在发送请求前先设置标志，防止重复发送